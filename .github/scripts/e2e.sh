@@ -70,6 +70,15 @@ assert_contains() {
   fi
 }
 
+assert_not_contains() {
+  local file="$1"
+  local unexpected="$2"
+  if grep -Fq "${unexpected}" "${file}"; then
+    echo "Expected ${file} not to contain: ${unexpected}" >&2
+    return 1
+  fi
+}
+
 stop_app() {
   if [[ -n "${APP_PID}" ]]; then
     kill -INT "${APP_PID}" >/dev/null 2>&1 || true
@@ -89,8 +98,17 @@ start_app() {
     export NOAA_API_URL="${MOCK_URL}${tide_path}"
     export SPACEDEVS_API_URL="${MOCK_URL}/launches/upcoming/"
     export SURF_API_URL="${MOCK_URL}/surf"
+    export NWS_API_URL="${MOCK_URL}/nws"
+    export NHC_API_URL="${MOCK_URL}/nhc/CurrentStorms.json"
     export AUTO_REFRESH_SECONDS=60
     export LAUNCH_API_TIMEOUT_SECONDS=5
+    export FORECAST_HOURS=1,3,5
+    export FORECAST_HOURLY_DETAILS=description,rain,wind,uv
+    export PANELS=all,water,surf,storm,daily
+    export DASHBOARDS=kids
+    export DASHBOARD_KIDS_LOCATION_NAME="E2E Kids"
+    export DASHBOARD_KIDS_PANELS=forecast,moon,sun
+    export DASHBOARD_KIDS_FORECAST_HOURLY_DETAILS=description
     exec "${TMPDIR}/kindle-weather"
   ) > "${TMPDIR}/app.log" 2>&1 &
   APP_PID="$!"
@@ -108,16 +126,55 @@ wait_for_http "${MOCK_URL}/health" "mock api"
 
 start_app "/tide"
 curl -fsS "${APP_URL}/" > "${TMPDIR}/page.html"
+curl -fsS "${APP_URL}/d/kids" > "${TMPDIR}/page-kids.html"
+curl -fsS "${APP_URL}/image.png" > "${TMPDIR}/page.png"
 curl -fsS "${APP_URL}/css/kindle.css" > "${TMPDIR}/kindle.css"
+curl -fsS "${APP_URL}/api/v1/dashboard" > "${TMPDIR}/dashboard.json"
+curl -fsS "${APP_URL}/api/v1/tide" > "${TMPDIR}/tide.json"
+curl -fsS "${APP_URL}/api/v1/surf" > "${TMPDIR}/surf.json"
 curl -fsS "${APP_URL}/metrics" > "${TMPDIR}/metrics.txt"
 assert_contains "${TMPDIR}/page.html" "Weather & Tide"
 assert_contains "${TMPDIR}/page.html" "E2E clear skies"
-assert_contains "${TMPDIR}/page.html" "3:17 AM"
-assert_contains "${TMPDIR}/page.html" "9:24 AM"
+assert_contains "${TMPDIR}/page.html" "H 4.2 ft"
+assert_contains "${TMPDIR}/page.html" 'class="tide-now"'
+assert_contains "${TMPDIR}/page.html" 'class="tide-observed"'
+assert_contains "${TMPDIR}/page.html" "1.3 ft surge"
+assert_contains "${TMPDIR}/page.html" "Water 72°F"
+assert_contains "${TMPDIR}/page.html" "Hurricane Esteban, Cat 2, 412 mi SE"
 assert_contains "${TMPDIR}/page.html" "id=\"launches\""
+assert_contains "${TMPDIR}/page.html" 'id="daily-forecast"'
+assert_contains "${TMPDIR}/page.html" "20% · SW 12"
+assert_contains "${TMPDIR}/page.html" 'class="forecast with-daily with-details"'
+assert_contains "${TMPDIR}/page.html" 'transform="rotate(270 8 8)"'
+assert_contains "${TMPDIR}/page.html" "UV 4"
+assert_contains "${TMPDIR}/page-kids.html" "E2E Kids Weather & Tide"
+assert_not_contains "${TMPDIR}/page-kids.html" "tide-section"
+assert_contains "${TMPDIR}/page-kids.html" "Small Craft Advisory until"
+assert_not_contains "${TMPDIR}/page-kids.html" 'id="storm"'
+assert_not_contains "${TMPDIR}/page-kids.html" 'id="daily-forecast"'
+assert_contains "${TMPDIR}/page-kids.html" '<div class="forecast">'
+assert_not_contains "${TMPDIR}/page-kids.html" 'class="colDetails"'
+assert_contains "${TMPDIR}/page.png" "PNG"
 assert_contains "${TMPDIR}/kindle.css" ".tide-section"
 assert_contains "${TMPDIR}/metrics.txt" "http_requests_total"
 assert_contains "${TMPDIR}/metrics.txt" 'api_requests_total{api="surf"}'
+assert_contains "${TMPDIR}/metrics.txt" 'api_requests_total{api="tide_curve"}'
+assert_not_contains "${TMPDIR}/app.log" "Error getting tide curve"
+assert_contains "${TMPDIR}/page.html" '<div id="source">OpenWeather</div>'
+assert_contains "${TMPDIR}/metrics.txt" 'weather_provider_requests_total{provider="openweather",result="success"}'
+assert_contains "${TMPDIR}/dashboard.json" '"version":"v1"'
+assert_contains "${TMPDIR}/dashboard.json" '"summary":"E2E clear skies"'
+assert_contains "${TMPDIR}/dashboard.json" '"water_temperature_f":72.3'
+assert_contains "${TMPDIR}/dashboard.json" '"rip_current_risk":"moderate"'
+assert_contains "${TMPDIR}/dashboard.json" '"event":"Small Craft Advisory"'
+assert_contains "${TMPDIR}/dashboard.json" '"title":"Hurricane Esteban"'
+assert_contains "${TMPDIR}/dashboard.json" '"high_f":84'
+assert_contains "${TMPDIR}/dashboard.json" '"precipitation_percent":12,'
+assert_not_contains "${TMPDIR}/app.log" "Error getting beach hazards"
+assert_contains "${TMPDIR}/tide.json" '"station":"8720218"'
+assert_contains "${TMPDIR}/surf.json" '"rating":'
+assert_contains "${TMPDIR}/surf.json" '"outlook":'
+assert_contains "${TMPDIR}/surf.json" '"swell_height_ft":2.1'
 stop_app
 
 start_app "/tide-empty"
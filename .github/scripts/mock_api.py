@@ -2,9 +2,11 @@
 import argparse
 import datetime as dt
 import json
+import math
 import time
 from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
-from urllib.parse import urlparse
+from urllib.parse import parse_qs, urlparse
+from zoneinfo import ZoneInfo
 
 
 def utc_timestamp(offset_seconds=0):
@@ -43,6 +45,27 @@ def weather_payload():
             "rain": {"1h": 0},
         })
 
+    daily = []
+    for day in range(7):
+        daily.append({
+            "dt": now + day * 86400,
+            "moonrise": now + day * 86400 + 1200,
+            "moonset": now + day * 86400 + 43200,
+            "moon_phase": 0.5,
+            "summary": "E2E clear skies",
+            "temp": {"min": 70 + day, "max": 84 + day},
+            "pop": 0.2,
+            "wind_speed": 11.6,
+            "wind_gust": 18,
+            "wind_deg": 225,
+            "weather": [{
+                "id": 800,
+                "main": "Clear",
+                "description": "clear sky",
+                "icon": "01d",
+            }],
+        })
+
     return {
         "timezone": "America/New_York",
         "timezone_offset": -14400,
@@ -69,25 +92,94 @@ def weather_payload():
             }],
         },
         "hourly": hourly,
-        "daily": [{
-            "moonrise": now + 1200,
-            "moonset": now + 43200,
-            "moon_phase": 0.5,
-            "summary": "E2E clear skies",
+        "daily": daily,
+        "alerts": [{
+            "sender_name": "NWS Jacksonville FL",
+            "event": "Small Craft Advisory",
+            "start": now - 3600,
+            "end": now + 6 * 3600,
+            "description": "E2E advisory",
         }],
     }
 
 
-def tide_payload():
-    today = dt.date.today().isoformat()
-    return {
-        "predictions": [
-            {"t": f"{today} 03:17", "type": "L", "v": "0.1"},
-            {"t": f"{today} 09:24", "type": "H", "v": "4.2"},
-            {"t": f"{today} 15:41", "type": "L", "v": "0.3"},
-            {"t": f"{today} 21:58", "type": "H", "v": "4.0"},
-        ],
-    }
+def daily_tides(begin, end):
+    # Same four tides every day across the requested range, so the chart
+    # window always has highs and lows whatever time the test runs.
+    tides = []
+    day = begin
+    while day <= end:
+        for clock, kind, height in (("03:17", "L", 0.1), ("09:24", "H", 4.2), ("15:41", "L", 0.3), ("21:58", "H", 4.0)):
+            tides.append((dt.datetime.fromisoformat(f"{day.isoformat()} {clock}"), kind, height))
+        day += dt.timedelta(days=1)
+    return tides
+
+
+def tide_height(tides, at):
+    for (prev_at, _, prev_height), (next_at, _, next_height) in zip(tides, tides[1:]):
+        if prev_at <= at <= next_at:
+            fraction = (at - prev_at) / (next_at - prev_at)
+            return prev_height + (next_height - prev_height) * (1 - math.cos(math.pi * fraction)) / 2
+    return None
+
+
+def tide_payload(query):
+    product = query.get("product", [""])[0]
+    if product == "water_level":
+        return water_level_payload(query)
+    if product in ("water_temperature", "air_pressure", "wind"):
+        return sensor_payload(product)
+
+    today = dt.date.today()
+    begin = parse_noaa_date(query.get("begin_date", [""])[0], today)
+    end = parse_noaa_date(query.get("end_date", [""])[0], today)
+    tides = daily_tides(begin, end)
+
+    interval = query.get("interval", ["hilo"])[0]
+    if interval == "hilo":
+        return {"predictions": [
+            {"t": at.strftime("%Y-%m-%d %H:%M"), "type": kind, "v": f"{height:.1f}"}
+            for at, kind, height in tides
+        ]}
+
+    # Interval series: a cosine between the highs and lows, like NOAA's plot.
+    step = dt.timedelta(hours=1) if interval == "h" else dt.timedelta(minutes=int(interval))
+    predictions = []
+    at = tides[0][0]
+    while at < tides[-1][0]:
+        predictions.append({"t": at.strftime("%Y-%m-%d %H:%M"), "v": f"{tide_height(tides, at):.3f}"})
+        at += step
+    return {"predictions": predictions}
+
+
+def water_level_payload(query):
+    # Observations running 1.3 ft over the prediction, on the station's
+    # local clock like the real API.
+    now = dt.datetime.now(ZoneInfo("America/New_York")).replace(tzinfo=None, second=0, microsecond=0)
+    now -= dt.timedelta(minutes=now.minute % 6)
+    hours = int(query.get("range", ["6"])[0])
+    tides = daily_tides(now.date() - dt.timedelta(days=1), now.date())
+    data = []
+    at = now - dt.timedelta(hours=hours)
+    while at <= now:
+        data.append({"t": at.strftime("%Y-%m-%d %H:%M"), "v": f"{tide_height(tides, at) + 1.3:.3f}"})
+        at += dt.timedelta(minutes=6)
+    return {"data": data}
+
+
+def sensor_payload(product):
+    now = dt.datetime.now(ZoneInfo("America/New_York")).strftime("%Y-%m-%d %H:%M")
+    if product == "wind":
+        return {"data": [{"t": now, "s": "8.55", "d": "110.00", "dr": "ESE", "g": "11.66", "f": "0,0"}]}
+    value = "72.3" if product == "water_temperature" else "1016.4"
+    return {"data": [{"t": now, "v": value, "f": "0,0,0"}]}
+
+
+def parse_noaa_date(value, default):
+    try:
+        return dt.datetime.strptime(value, "%Y%m%d").date()
+    except ValueError:
+        return default
 
 
 def launch_payload():
@@ -111,25 +203,65 @@ def surf_payload():
             "wave_height": [2.5],
             "wave_direction": [90],
             "wave_period": [9],
+            "swell_wave_height": [2.1],
+            "swell_wave_period": [11],
+            "swell_wave_direction": [95],
+            "wind_wave_height": [0.6],
         },
     }
 
 
+def nws_payload(path):
+    if path.startswith("/nws/points/"):
+        return {"properties": {"forecastZone": "https://api.weather.gov/zones/forecast/FLZ133", "timeZone": "America/New_York",
+                               "forecast": "https://api.weather.gov/gridpoints/JAX/80,40/forecast",
+                               "forecastHourly": "https://api.weather.gov/gridpoints/JAX/80,40/forecast/hourly"}}
+    if path == "/nws/zones/forecast/FLZ133":
+        return {"properties": {"id": "FLZ133", "cwa": ["JAX"]}}
+    if path == "/nws/alerts/active":
+        return {"type": "FeatureCollection", "features": []}
+    if path == "/nws/products/types/SRF/locations/JAX":
+        return {"@graph": [{"id": "e2e-srf", "issuanceTime": rfc3339_utc(-3600)}]}
+    if path == "/nws/products/e2e-srf":
+        return {"id": "e2e-srf", "issuanceTime": rfc3339_utc(-3600), "productText": (
+            "SRFJAX\n\nSurf Zone Forecast\n\nFLZ133-138-112000-\nCoastal St. Johns-Coastal Flagler-\n\n"
+            ".TODAY...\nRip Current Risk*...........Moderate.\nSurf Height.................2 to 3 feet.\n\n$$\n"
+        )}
+    return None
+
+
+def storms_payload():
+    return {"activeStorms": [{
+        "id": "al992024",
+        "name": "Esteban",
+        "classification": "HU",
+        "intensity": "85",
+        "latitudeNumeric": 25.0,
+        "longitudeNumeric": -77.0,
+        "lastUpdate": rfc3339_utc(-3600),
+    }]}
+
+
 class Handler(BaseHTTPRequestHandler):
     def do_GET(self):
-        path = urlparse(self.path).path
+        url = urlparse(self.path)
+        path = url.path
         if path == "/health":
             self.write_json({"status": "healthy"})
         elif path == "/weather":
             self.write_json(weather_payload())
         elif path == "/tide":
-            self.write_json(tide_payload())
+            self.write_json(tide_payload(parse_qs(url.query)))
         elif path == "/tide-empty":
             self.write_json({"predictions": []})
         elif path.startswith("/launches/upcoming"):
             self.write_json(launch_payload())
         elif path == "/surf":
             self.write_json(surf_payload())
+        elif path == "/nhc/CurrentStorms.json":
+            self.write_json(storms_payload())
+        elif path.startswith("/nws/") and nws_payload(path) is not None:
+            self.write_json(nws_payload(path))
         else:
             self.send_error(404)
 
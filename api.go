@@ -0,0 +1,644 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// apiVersion is reported in every JSON response. The api* types below are
+// the public contract, kept separate from the template structs so the HTML
+// can change without breaking widgets; a breaking change to them needs a new
+// version and route prefix.
+const apiVersion = "v1"
+
+// apiMeta starts every response.
+type apiMeta struct {
+	Version     string    `json:"version"`
+	Dashboard   string    `json:"dashboard"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type apiDashboard struct {
+	apiMeta
+	Location     apiLocation       `json:"location"`
+	Weather      apiWeather        `json:"weather"`
+	Forecast     []apiForecastHour `json:"forecast,omitempty"`
+	Daily        []apiForecastDay  `json:"daily,omitempty"`
+	Tide         *apiTide          `json:"tide,omitempty"`
+	Launches     []apiLaunch       `json:"launches,omitempty"`
+	BeachStatus  *apiBeachStatus   `json:"beach_status,omitempty"`
+	BeachHazards *apiBeachHazards  `json:"beach_hazards,omitempty"`
+	Storm        *apiStorm         `json:"storm,omitempty"`
+	SurfOutlook  []apiSurfDay      `json:"surf_outlook,omitempty"`
+	Station      *apiStation       `json:"station,omitempty"`
+	Moon         *apiMoon          `json:"moon,omitempty"`
+}
+
+// apiStation is the latest tide station sensor readings; sensors the
+// station lacks are omitted.
+type apiStation struct {
+	WaterTemperature *float64 `json:"water_temperature_f,omitempty"`
+	AirPressure      *float64 `json:"air_pressure_mb,omitempty"`
+	WindSpeed        *float64 `json:"wind_speed_kt,omitempty"`
+	WindGust         *float64 `json:"wind_gust_kt,omitempty"`
+	WindDeg          *float64 `json:"wind_direction_deg,omitempty"`
+}
+
+type apiLocation struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+type apiWeather struct {
+	Source      string     `json:"source"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+	Stale       bool       `json:"stale"`
+	Temperature float64    `json:"temperature_f"`
+	FeelsLike   float64    `json:"feels_like_f"`
+	Humidity    int        `json:"humidity_percent"`
+	WindSpeed   float64    `json:"wind_speed_mph"`
+	WindDeg     int        `json:"wind_direction_deg"`
+	Condition   string     `json:"condition"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"`
+	Summary     string     `json:"summary,omitempty"`
+	Sunrise     *time.Time `json:"sunrise,omitempty"`
+	Sunset      *time.Time `json:"sunset,omitempty"`
+	Alerts      []apiAlert `json:"alerts"`
+}
+
+// apiAlert is a weather warning, watch or advisory as the provider gave it.
+type apiAlert struct {
+	Event  string     `json:"event"`
+	Sender string     `json:"sender,omitempty"`
+	Start  *time.Time `json:"start,omitempty"`
+	End    *time.Time `json:"end,omitempty"`
+}
+
+type apiForecastHour struct {
+	Time          time.Time `json:"time"`
+	Temperature   float64   `json:"temperature_f"`
+	Description   string    `json:"description"`
+	Icon          string    `json:"icon"`
+	PrecipPercent int       `json:"precipitation_percent"`
+	WindSpeed     float64   `json:"wind_speed_mph"`
+	WindDeg       int       `json:"wind_direction_deg"`
+	UVIndex       float64   `json:"uv_index"`
+}
+
+// apiForecastDay leaves out a high or low the provider had no figure for.
+type apiForecastDay struct {
+	Date          string   `json:"date"`
+	High          *float64 `json:"high_f,omitempty"`
+	Low           *float64 `json:"low_f,omitempty"`
+	PrecipPercent int      `json:"precipitation_percent"`
+	WindSpeed     float64  `json:"wind_speed_mph"`
+	WindGust      float64  `json:"wind_gust_mph"`
+	WindDeg       int      `json:"wind_direction_deg"`
+	Description   string   `json:"description"`
+	Icon          string   `json:"icon"`
+}
+
+type apiTide struct {
+	Station     string              `json:"station"`
+	Predictions []apiTidePrediction `json:"predictions"`
+}
+
+type apiTidePrediction struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Height float64   `json:"height_ft"`
+}
+
+type apiSurf struct {
+	GoodSurfToday bool           `json:"good_surf_today"`
+	BestWindow    *apiSurfWindow `json:"best_window,omitempty"`
+	Outlook       []apiSurfDay   `json:"outlook"`
+	Hours         []apiSurfHour  `json:"hours"`
+}
+
+// apiSurfHour leaves out the swell and wind wave components Open-Meteo did
+// not send, and the tide when it was not fetched or does not cover the hour.
+type apiSurfHour struct {
+	Time           time.Time `json:"time"`
+	Rating         int       `json:"rating"`
+	WaveHeight     float64   `json:"wave_height_ft"`
+	WavePeriod     float64   `json:"wave_period_s"`
+	WaveDirection  float64   `json:"wave_direction_deg"`
+	SwellHeight    *float64  `json:"swell_height_ft,omitempty"`
+	SwellPeriod    *float64  `json:"swell_period_s,omitempty"`
+	SwellDirection *float64  `json:"swell_direction_deg,omitempty"`
+	WindWaveHeight *float64  `json:"wind_wave_height_ft,omitempty"`
+	Wind           string    `json:"wind,omitempty"`
+	TideHeight     *float64  `json:"tide_height_ft,omitempty"`
+	TideStage      string    `json:"tide_stage,omitempty"`
+	TideDirection  string    `json:"tide_direction,omitempty"`
+}
+
+type apiSurfDay struct {
+	Date      string `json:"date"`
+	Rating    int    `json:"rating"`
+	WavesOnly bool   `json:"waves_only"`
+}
+
+type apiSurfWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Rating int       `json:"rating"`
+	Text   string    `json:"text"`
+}
+
+type apiLaunch struct {
+	Name      string `json:"name"`
+	Scheduled string `json:"scheduled"`
+}
+
+type apiBeachStatus struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+// apiBeachHazards is the NWS view of the water; RipCurrentRisk is omitted
+// when there is no current Surf Zone Forecast for the zone.
+type apiBeachHazards struct {
+	Zone           string          `json:"zone"`
+	RipCurrentRisk string          `json:"rip_current_risk,omitempty"`
+	Alerts         []apiBeachAlert `json:"alerts"`
+}
+
+type apiBeachAlert struct {
+	Event    string     `json:"event"`
+	Headline string     `json:"headline,omitempty"`
+	Onset    *time.Time `json:"onset,omitempty"`
+	Ends     *time.Time `json:"ends,omitempty"`
+}
+
+// apiStorm is the nearest active tropical cyclone. Category is the
+// Saffir-Simpson category, 0 below hurricane strength.
+type apiStorm struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Title          string    `json:"title"`
+	Classification string    `json:"classification"`
+	Category       int       `json:"category"`
+	WindKnots      float64   `json:"wind_kt"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	DistanceMiles  float64   `json:"distance_miles"`
+	BearingDeg     float64   `json:"bearing_deg"`
+	Direction      string    `json:"direction"`
+	LastUpdate     time.Time `json:"last_update"`
+}
+
+type apiMoon struct {
+	Phase float64 `json:"phase"`
+	Icon  string  `json:"icon"`
+}
+
+type apiTideResponse struct {
+	apiMeta
+	Tide apiTide `json:"tide"`
+}
+
+type apiSurfResponse struct {
+	apiMeta
+	Surf apiSurf `json:"surf"`
+}
+
+type apiLaunchesResponse struct {
+	apiMeta
+	Launches []apiLaunch `json:"launches"`
+}
+
+// apiDashboardHandler serves the same model the Kindle page is rendered from.
+// Panels the dashboard has turned off are left out.
+func apiDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown dashboard")
+		return
+	}
+
+	page, err := buildDashboardPage(r.Context(), r, dashboard)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error getting weather data: %v", err),
+		})
+		writeAPIError(w, http.StatusBadGateway, "could not get weather data")
+		return
+	}
+
+	writeAPIJSON(w, newAPIDashboard(dashboard, page, time.Now()))
+}
+
+// apiTideHandler serves the tide predictions for the dashboard's station
+// over the same window as the chart.
+func apiTideHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown dashboard")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), dashboardFetchTimeout)
+	defer cancel()
+
+	tide, err := getTide(ctx, dashboard.Location)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error getting tide data: %v", err),
+		})
+		writeAPIError(w, http.StatusBadGateway, "could not get tide data")
+		return
+	}
+
+	now := time.Now()
+	writeAPIJSON(w, apiTideResponse{apiMeta: newAPIMeta(dashboard, now), Tide: newAPITide(dashboard.Location, tide, now)})
+}
+
+// apiSurfHandler serves the hourly swell forecast and the same good-surf
+// verdict the beach notice uses.
+func apiSurfHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown dashboard")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), dashboardFetchTimeout)
+	defer cancel()
+
+	forecast, err := getSurfForecast(ctx, dashboard.Location)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error getting surf data: %v", err),
+		})
+		writeAPIError(w, http.StatusBadGateway, "could not get surf data")
+		return
+	}
+	// The ratings need the wind; without weather no hour rates as good.
+	weather, err := getWeatherWithCache(ctx, dashboard.Location)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Error getting weather data: %v", err),
+		})
+	}
+
+	// A tide failure only drops the profile's tide preferences. The curve
+	// gives the same tide heights the page rates with.
+	var (
+		tide      TideData
+		tideCurve TideCurve
+	)
+	if dashboard.Location.Surf.usesTide() {
+		if tide, err = getTide(ctx, dashboard.Location); err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Error getting tide data: %v", err),
+			})
+		}
+		if tideCurve, err = getTideCurve(ctx, dashboard.Location); err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Error getting tide curve, drawing it from highs and lows: %v", err),
+			})
+		}
+	}
+
+	now := time.Now().In(dashboard.Location.timeLocation())
+	hours := rateSurfHours(forecast, weather, tide, tideCurve, dashboard.Location.Surf, surfLocation(weather, now.Location()))
+	writeAPIJSON(w, apiSurfResponse{
+		apiMeta: newAPIMeta(dashboard, now),
+		Surf:    newAPISurf(hours, dashboard.Location.Surf, weather, now),
+	})
+}
+
+// apiLaunchesHandler serves today's launches from the dashboard's launch
+// site. The list is empty on days without one.
+func apiLaunchesHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown dashboard")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), dashboardFetchTimeout)
+	defer cancel()
+
+	launch, err := getTodayKennedyLaunch(ctx, dashboard.Location)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error getting launch data: %v", err),
+		})
+		writeAPIError(w, http.StatusBadGateway, "could not get launch data")
+		return
+	}
+
+	launches := newAPILaunches(launch)
+	if launches == nil {
+		launches = []apiLaunch{}
+	}
+	writeAPIJSON(w, apiLaunchesResponse{apiMeta: newAPIMeta(dashboard, time.Now()), Launches: launches})
+}
+
+func newAPIDashboard(dashboard Dashboard, page dashboardPage, now time.Time) apiDashboard {
+	loc := dashboard.Location
+	result := apiDashboard{
+		apiMeta: newAPIMeta(dashboard, now),
+		Location: apiLocation{
+			Name:      loc.Name,
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+			Timezone:  loc.Timezone,
+		},
+		Weather: newAPIWeather(page.Weather, page.WeatherSource, page.WeatherUpdated != "", loc),
+	}
+
+	if page.Panels.Forecast {
+		for _, hour := range page.ForecastHours {
+			forecastHour := apiForecastHour{
+				Time:          time.Unix(hour.Dt, 0).In(loc.timeLocation()),
+				Temperature:   hour.Temp,
+				PrecipPercent: int(hour.Pop),
+				WindSpeed:     hour.WindSpeed,
+				WindDeg:       hour.WindDeg,
+				UVIndex:       hour.Uvi,
+			}
+			if len(hour.Weather) > 0 {
+				forecastHour.Description = hour.Weather[0].Description
+				forecastHour.Icon = getIconClassName(hour.Weather[0].Icon, hour.Weather[0].ID)
+			}
+			result.Forecast = append(result.Forecast, forecastHour)
+		}
+	}
+	if page.Panels.Daily {
+		result.Daily = newAPIForecastDays(page.ForecastDays)
+	}
+	if page.Panels.Tide && len(page.Tide.Predictions) > 0 {
+		tide := newAPITide(loc, page.Tide, now)
+		result.Tide = &tide
+	}
+	if page.Panels.Launch {
+		result.Launches = newAPILaunches(page.KennedyLaunch)
+	}
+	if page.BeachStatus != nil {
+		result.BeachStatus = &apiBeachStatus{Kind: page.BeachStatus.Kind, Text: page.BeachStatus.Text}
+	}
+	if page.Panels.Beach && page.BeachHazards.Zone != "" {
+		result.BeachHazards = newAPIBeachHazards(page.BeachHazards)
+	}
+	if page.Panels.Storm && page.Storm != nil {
+		result.Storm = newAPIStorm(*page.Storm)
+	}
+	if page.Panels.Surf {
+		result.SurfOutlook = newAPISurfOutlook(page.SurfOutlook)
+	}
+	if page.Panels.Water {
+		result.Station = newAPIStation(page.Station)
+	}
+	if page.Panels.Moon && len(page.Weather.Daily) > 0 {
+		result.Moon = &apiMoon{Phase: page.Weather.Daily[0].MoonPhase, Icon: page.MoonPhaseIcon}
+	}
+	return result
+}
+
+func newAPIStorm(storm NearbyStorm) *apiStorm {
+	return &apiStorm{
+		ID:             storm.ID,
+		Name:           storm.Name,
+		Title:          storm.Title(),
+		Classification: storm.Classification,
+		Category:       storm.Category(),
+		WindKnots:      storm.WindKnots,
+		Latitude:       storm.Latitude,
+		Longitude:      storm.Longitude,
+		DistanceMiles:  math.Round(storm.DistanceMiles),
+		BearingDeg:     math.Round(storm.BearingDeg),
+		Direction:      storm.Direction(),
+		LastUpdate:     storm.LastUpdate.UTC(),
+	}
+}
+
+func newAPIBeachHazards(hazards BeachHazards) *apiBeachHazards {
+	result := &apiBeachHazards{Zone: hazards.Zone, RipCurrentRisk: hazards.RipCurrentRisk, Alerts: []apiBeachAlert{}}
+	for _, alert := range hazards.Alerts {
+		result.Alerts = append(result.Alerts, apiBeachAlert{
+			Event:    alert.Event,
+			Headline: alert.Headline,
+			Onset:    utcOrNil(alert.Onset),
+			Ends:     utcOrNil(alert.Ends),
+		})
+	}
+	return result
+}
+
+// newAPIStation returns nil when no sensor has a current reading.
+func newAPIStation(sensors StationSensors) *apiStation {
+	var result apiStation
+	if sensors.WaterTemperature != nil {
+		result.WaterTemperature = &sensors.WaterTemperature.Value
+	}
+	if sensors.AirPressure != nil {
+		result.AirPressure = &sensors.AirPressure.Value
+	}
+	if wind := sensors.Wind; wind != nil {
+		result.WindSpeed, result.WindGust, result.WindDeg = &wind.Speed, &wind.Gust, &wind.Direction
+	}
+	if result == (apiStation{}) {
+		return nil
+	}
+	return &result
+}
+
+func newAPIWeather(weather WeatherData, source string, stale bool, loc Location) apiWeather {
+	tz := loc.timeLocation()
+	current := weather.Current
+	result := apiWeather{
+		Source:      source,
+		Stale:       stale,
+		Temperature: current.Temp,
+		FeelsLike:   current.FeelsLike,
+		Humidity:    current.Humidity,
+		WindSpeed:   current.WindSpeed,
+		WindDeg:     current.WindDeg,
+		Sunrise:     apiUnixTime(current.Sunrise, tz),
+		Sunset:      apiUnixTime(current.Sunset, tz),
+		Alerts:      []apiAlert{},
+	}
+	for _, alert := range weather.Alerts {
+		result.Alerts = append(result.Alerts, apiAlert{
+			Event:  alert.Event,
+			Sender: alert.SenderName,
+			Start:  apiUnixTime(alert.Start, tz),
+			End:    apiUnixTime(alert.End, tz),
+		})
+	}
+	if !weather.FetchedAt.IsZero() {
+		fetchedAt := weather.FetchedAt.UTC()
+		result.FetchedAt = &fetchedAt
+	}
+	if len(current.Weather) > 0 {
+		result.Condition = current.Weather[0].Main
+		result.Description = current.Weather[0].Description
+		result.Icon = getIconClassName(current.Weather[0].Icon, current.Weather[0].ID)
+	}
+	if len(weather.Daily) > 0 {
+		result.Summary = weather.Daily[0].Summary
+	}
+	return result
+}
+
+// newAPITide lists the predictions for the chart window around now.
+func newAPITide(loc Location, tide TideData, now time.Time) apiTide {
+	tz := loc.timeLocation()
+	start, end := now.Add(-tideWindowPast), now.Add(tideWindowFuture)
+	result := apiTide{Station: loc.TideStation, Predictions: []apiTidePrediction{}}
+	for _, prediction := range sortedTidePredictions(tide.Predictions) {
+		if prediction.Time.Before(start) || prediction.Time.After(end) {
+			continue
+		}
+		result.Predictions = append(result.Predictions, apiTidePrediction{
+			Time:   prediction.Time.In(tz),
+			Type:   prediction.Type,
+			Height: prediction.Height,
+		})
+	}
+	return result
+}
+
+func newAPISurf(hours []SurfHour, profile SurfProfile, weather WeatherData, now time.Time) apiSurf {
+	result := apiSurf{
+		Outlook: newAPISurfOutlook(surfOutlook(hours, profile, weather, now, surfOutlookDays)),
+		Hours:   []apiSurfHour{},
+	}
+	if best := bestSurfWindow(hours, weather, now); best != nil {
+		result.GoodSurfToday = true
+		result.BestWindow = &apiSurfWindow{
+			Start:  best.Start.UTC(),
+			End:    best.End.UTC(),
+			Rating: best.Rating,
+			Text:   surfWindowText(*best),
+		}
+	}
+	for _, hour := range hours {
+		result.Hours = append(result.Hours, apiSurfHour{
+			Time:           hour.Time.UTC(),
+			Rating:         hour.Rating,
+			WaveHeight:     hour.WaveHeight,
+			WavePeriod:     hour.WavePeriod,
+			WaveDirection:  hour.WaveDirection,
+			SwellHeight:    finiteOrNil(hour.SwellHeight),
+			SwellPeriod:    finiteOrNil(hour.SwellPeriod),
+			SwellDirection: finiteOrNil(hour.SwellDirection),
+			WindWaveHeight: finiteOrNil(hour.WindWaveHeight),
+			Wind:           hour.Wind,
+			TideHeight:     finiteOrNil(hour.TideHeight),
+			TideStage:      hour.TideStage,
+			TideDirection:  hour.tideDirection(),
+		})
+	}
+	return result
+}
+
+func newAPIForecastDays(days []ForecastDay) []apiForecastDay {
+	result := []apiForecastDay{}
+	for _, day := range days {
+		forecastDay := apiForecastDay{
+			Date:          day.Date.Format(time.DateOnly),
+			High:          day.Day.Temp.Max,
+			Low:           day.Day.Temp.Min,
+			PrecipPercent: day.Pop,
+			WindSpeed:     day.Day.WindSpeed,
+			WindGust:      day.Day.WindGust,
+			WindDeg:       day.Day.WindDeg,
+			Icon:          day.Icon,
+		}
+		if len(day.Day.Weather) > 0 {
+			forecastDay.Description = day.Day.Weather[0].Description
+		}
+		result = append(result, forecastDay)
+	}
+	return result
+}
+
+func newAPISurfOutlook(outlook []SurfDay) []apiSurfDay {
+	result := []apiSurfDay{}
+	for _, day := range outlook {
+		result = append(result, apiSurfDay{Date: day.Date.Format(time.DateOnly), Rating: day.Rating, WavesOnly: day.WavesOnly})
+	}
+	return result
+}
+
+// finiteOrNil returns nil for NaN, which JSON cannot carry.
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// utcOrNil returns nil for the zero time.
+func utcOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func newAPILaunches(launch *LaunchInfo) []apiLaunch {
+	if launch == nil {
+		return nil
+	}
+	return []apiLaunch{{Name: launch.Name, Scheduled: launch.Scheduled}}
+}
+
+func newAPIMeta(dashboard Dashboard, now time.Time) apiMeta {
+	name := dashboard.Name
+	if name == "" {
+		name = "default"
+	}
+	return apiMeta{Version: apiVersion, Dashboard: name, GeneratedAt: now.UTC()}
+}
+
+func apiUnixTime(seconds int64, tz *time.Location) *time.Time {
+	if seconds == 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0).In(tz)
+	return &t
+}
+
+func writeAPIJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error encoding API response: %v", err),
+		})
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"version": apiVersion, "error": message})
+}
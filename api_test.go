@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func newAPITestMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/dashboard", apiDashboardHandler)
+	mux.HandleFunc("GET /api/v1/tide", apiTideHandler)
+	mux.HandleFunc("GET /api/v1/d/{name}/tide", apiTideHandler)
+	mux.HandleFunc("GET /api/v1/surf", apiSurfHandler)
+	return mux
+}
+
+func TestAPIDashboardHandler(t *testing.T) {
+	oldProviders, oldCache, oldDefault := weatherProviders, weatherCache, defaultDashboard
+	defer func() { weatherProviders, weatherCache, defaultDashboard = oldProviders, oldCache, oldDefault }()
+
+	var hits int
+	weatherProviders = []WeatherProvider{stubWeatherProvider{name: weatherProviderOpenMeteo, hits: &hits}}
+	weatherCache = cache.New(time.Hour, time.Hour)
+	defaultDashboard = Dashboard{Location: defaultLocation, Panels: Panels{Forecast: true, Moon: true}, AutoRefresh: time.Hour}
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dashboard", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+
+	var body struct {
+		Version   string `json:"version"`
+		Dashboard string `json:"dashboard"`
+		Weather   struct {
+			Source      string  `json:"source"`
+			Temperature float64 `json:"temperature_f"`
+			Stale       bool    `json:"stale"`
+		} `json:"weather"`
+		Tide *json.RawMessage `json:"tide"`
+		Moon *struct {
+			Phase float64 `json:"phase"`
+			Icon  string  `json:"icon"`
+		} `json:"moon"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Version != "v1" || body.Dashboard != "default" {
+		t.Fatalf("version/dashboard = %q/%q", body.Version, body.Dashboard)
+	}
+	if body.Weather.Source != "Open-Meteo" || body.Weather.Temperature != 72 || body.Weather.Stale {
+		t.Fatalf("weather = %+v", body.Weather)
+	}
+	if body.Tide != nil {
+		t.Fatalf("tide = %s; want it omitted when the panel is off", *body.Tide)
+	}
+	if body.Moon == nil || body.Moon.Phase != 0.5 || body.Moon.Icon != "wi-moon-full" {
+		t.Fatalf("moon = %+v", body.Moon)
+	}
+}
+
+func TestAPIDashboardHandler_WeatherFields(t *testing.T) {
+	oldCache, oldDefault := weatherCache, defaultDashboard
+	defer func() { weatherCache, defaultDashboard = oldCache, oldDefault }()
+
+	weatherCache = cache.New(time.Hour, time.Hour)
+	defaultDashboard = Dashboard{Location: defaultLocation, Panels: Panels{Forecast: true}, AutoRefresh: time.Hour}
+	sunrise := time.Date(2026, time.August, 11, 6, 45, 0, 0, defaultLocation.timeLocation())
+	fetchedAt := time.Date(2026, time.August, 11, 12, 0, 0, 0, time.UTC)
+	weatherCache.Set(weatherCacheKey(defaultLocation), WeatherData{
+		Source:    weatherProviderNWS,
+		FetchedAt: fetchedAt,
+		Timezone:  defaultLocation.Timezone,
+		Current: CurrentWeather{
+			Temp:      84,
+			FeelsLike: 91,
+			Humidity:  70,
+			WindSpeed: 12,
+			WindDeg:   225,
+			Sunrise:   sunrise.Unix(),
+			Weather:   []WeatherCondition{{ID: 500, Main: "Rain", Description: "light rain", Icon: "10d"}},
+		},
+		Daily:  []DailyWeather{{Summary: "Rain in the afternoon"}},
+		Alerts: []WeatherAlert{{SenderName: "NWS Jacksonville", Event: "Heat Advisory"}},
+	}, cache.DefaultExpiration)
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dashboard", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	var body apiDashboard
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got := body.Weather
+	if got.Source != "NWS" || got.Temperature != 84 || got.FeelsLike != 91 || got.Humidity != 70 || got.WindSpeed != 12 || got.WindDeg != 225 {
+		t.Fatalf("weather = %+v", got)
+	}
+	if got.Condition != "Rain" || got.Description != "light rain" || got.Icon == "" || got.Summary != "Rain in the afternoon" {
+		t.Fatalf("condition = %q/%q/%q, summary %q", got.Condition, got.Description, got.Icon, got.Summary)
+	}
+	if got.FetchedAt == nil || !got.FetchedAt.Equal(fetchedAt) || got.Sunrise == nil || !got.Sunrise.Equal(sunrise) {
+		t.Fatalf("fetched at %v, sunrise %v; want %v, %v", got.FetchedAt, got.Sunrise, fetchedAt, sunrise)
+	}
+	if len(got.Alerts) != 1 || got.Alerts[0].Event != "Heat Advisory" || got.Alerts[0].Sender != "NWS Jacksonville" {
+		t.Fatalf("alerts = %+v", got.Alerts)
+	}
+}
+
+func TestAPIDashboardHandler_WeatherFailure(t *testing.T) {
+	oldProviders, oldCache, oldDefault := weatherProviders, weatherCache, defaultDashboard
+	defer func() { weatherProviders, weatherCache, defaultDashboard = oldProviders, oldCache, oldDefault }()
+
+	var hits int
+	weatherProviders = []WeatherProvider{stubWeatherProvider{name: weatherProviderOpenMeteo, err: errors.New("status code 503"), hits: &hits}}
+	weatherCache = cache.New(time.Hour, time.Hour)
+	defaultDashboard = Dashboard{Location: defaultLocation, Panels: Panels{Forecast: true}, AutoRefresh: time.Hour}
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dashboard", nil))
+	assertAPIError(t, rec, http.StatusBadGateway, "could not get weather data")
+}
+
+func TestAPITideHandler(t *testing.T) {
+	oldCache, oldDefault, oldDashboards := tideCache, defaultDashboard, dashboards
+	defer func() { tideCache, defaultDashboard, dashboards = oldCache, oldDefault, oldDashboards }()
+
+	tideCache = cache.New(time.Hour, time.Hour)
+	defaultDashboard = Dashboard{Location: defaultLocation, Panels: allPanels}
+	dashboards = map[string]Dashboard{}
+	now := time.Now()
+	highAt := now.Add(2 * time.Hour).In(defaultLocation.timeLocation()).Truncate(time.Minute)
+	tideCache.Set(tideCacheKey(now, defaultLocation), TideData{Predictions: []TidePrediction{
+		{Time: now.AddDate(0, 0, -3), Type: "L", Height: 0.1},
+		{Time: highAt, Type: "H", Height: 4.2},
+	}}, cache.DefaultExpiration)
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/tide", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	var body apiTideResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Tide.Station != defaultLocation.TideStation || len(body.Tide.Predictions) != 1 {
+		t.Fatalf("tide = %+v", body.Tide)
+	}
+	if got := body.Tide.Predictions[0]; !got.Time.Equal(highAt) || got.Type != "H" || got.Height != 4.2 {
+		t.Fatalf("prediction = %+v; want H 4.2 ft at %v", got, highAt)
+	}
+
+	rec = httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/d/nowhere/tide", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown dashboard status = %d; want 404", rec.Code)
+	}
+}
+
+func TestAPISurfHandler(t *testing.T) {
+	oldSurf, oldWeather, oldTide, oldInterval, oldDefault := surfCache, weatherCache, tideCache, tideCurveInterval, defaultDashboard
+	defer func() {
+		surfCache, weatherCache, tideCache, tideCurveInterval, defaultDashboard = oldSurf, oldWeather, oldTide, oldInterval, oldDefault
+	}()
+
+	surfCache = cache.New(time.Hour, time.Hour)
+	weatherCache = cache.New(time.Hour, time.Hour)
+	tideCache = cache.New(time.Hour, time.Hour)
+	tideCurveInterval = "6"
+	loc := defaultLocation
+	loc.Surf = defaultSurfProfile.clone()
+	minTide := 1.0
+	loc.Surf.MinTideFeet = &minTide
+	defaultDashboard = Dashboard{Location: loc, Panels: Panels{Surf: true}, AutoRefresh: time.Hour}
+
+	now := time.Now()
+	at := now.Add(time.Hour).Truncate(time.Hour)
+	surfCache.Set(surfCacheKey+":"+loc.coordinatesKey(), SurfForecast{Hourly: SurfHourlyForecast{
+		Time:          []int64{at.Unix()},
+		WaveHeight:    []float64{3},
+		WavePeriod:    []float64{9},
+		WaveDirection: []float64{90},
+	}}, cache.DefaultExpiration)
+	weatherCache.Set(weatherCacheKey(loc), WeatherData{
+		Hourly: []HourlyWeather{{Dt: at.Unix(), WindSpeed: 4, WindDeg: 90}},
+	}, cache.DefaultExpiration)
+	// The highs and lows alone put the hour near 3 ft; the curve holds it
+	// at half a foot, under the profile's minimum.
+	tideCache.Set(tideCacheKey(now, loc), TideData{Predictions: []TidePrediction{
+		{Time: at.Add(-3 * time.Hour), Type: "L", Height: 0},
+		{Time: at.Add(3 * time.Hour), Type: "H", Height: 4},
+	}}, cache.DefaultExpiration)
+	tideCache.Set(tideCurveCacheKey(now, loc), TideCurve{Levels: []TideLevel{
+		{At: at.Add(-time.Hour), Height: 0.5},
+		{At: at.Add(time.Hour), Height: 0.5},
+	}}, cache.DefaultExpiration)
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/surf", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	var body apiSurfResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Surf.Hours) != 1 {
+		t.Fatalf("hours = %+v; want 1", body.Surf.Hours)
+	}
+	hour := body.Surf.Hours[0]
+	if !hour.Time.Equal(at) || hour.WaveHeight != 3 || hour.WavePeriod != 9 || hour.WaveDirection != 90 || hour.Wind != surfWindLight {
+		t.Fatalf("hour = %+v", hour)
+	}
+	if hour.TideHeight == nil || *hour.TideHeight != 0.5 {
+		t.Fatalf("tide height = %v; want 0.5 ft from the curve", hour.TideHeight)
+	}
+	if hour.Rating != 1 || body.Surf.GoodSurfToday {
+		t.Fatalf("rating = %d, good surf = %v; want the hour capped at 1 by the tide", hour.Rating, body.Surf.GoodSurfToday)
+	}
+}
+
+func TestAPISurfHandler_SurfFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	oldSurf, oldURL, oldClient, oldDefault := surfCache, surfAPIURL, httpClient, defaultDashboard
+	defer func() { surfCache, surfAPIURL, httpClient, defaultDashboard = oldSurf, oldURL, oldClient, oldDefault }()
+
+	surfCache = cache.New(time.Hour, time.Hour)
+	surfAPIURL = server.URL
+	httpClient = server.Client()
+	defaultDashboard = Dashboard{Location: defaultLocation, Panels: Panels{Surf: true}, AutoRefresh: time.Hour}
+
+	rec := httptest.NewRecorder()
+	newAPITestMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/surf", nil))
+	assertAPIError(t, rec, http.StatusBadGateway, "could not get surf data")
+}
+
+func assertAPIError(t *testing.T, rec *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d; want %d; body %s", rec.Code, status, rec.Body)
+	}
+	var body struct {
+		Version string `json:"version"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Version != "v1" || body.Error != message {
+		t.Fatalf("error body = %+v; want %q", body, message)
+	}
+}
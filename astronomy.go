@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Providers other than OpenWeather do not report sun and moon data, so these
+// are computed locally. Both are accurate to a minute or two, which is all a
+// clock reading on the dashboard needs.
+
+const (
+	julianUnixEpoch = 2440587.5
+	julianJ2000     = 2451545.0
+
+	// synodicMonthDays is the mean time between new moons, and
+	// referenceNewMoonJD the new moon of 2000-01-06 18:14 UTC.
+	synodicMonthDays   = 29.530588853
+	referenceNewMoonJD = 2451550.1
+)
+
+func julianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + julianUnixEpoch
+}
+
+func timeFromJulianDay(jd float64) time.Time {
+	return time.Unix(int64(math.Round((jd-julianUnixEpoch)*86400)), 0)
+}
+
+// sunTimes returns sunrise and sunset for the calendar day of day in its own
+// zone. ok is false during polar day or night.
+func sunTimes(day time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(julianDay(noon) - julianJ2000)
+
+	meanSolarTime := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	m := degreesToRadians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.0200*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	eclipticLongitude := degreesToRadians(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := julianJ2000 + meanSolarTime + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*eclipticLongitude)
+
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(degreesToRadians(23.4397)))
+	lat := degreesToRadians(latitude)
+	cosHourAngle := (math.Sin(degreesToRadians(-0.833)) - math.Sin(lat)*math.Sin(declination)) /
+		(math.Cos(lat) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := radiansToDegrees(math.Acos(cosHourAngle))
+
+	return timeFromJulianDay(transit - hourAngle/360).In(day.Location()),
+		timeFromJulianDay(transit + hourAngle/360).In(day.Location()),
+		true
+}
+
+// moonPhase returns the phase for the calendar day of day on OpenWeather's
+// 0..1 scale (0 new, 0.25 first quarter, 0.5 full, 0.75 last quarter).
+// OpenWeather reports the exact quarter values on the day a quarter falls,
+// and getMoonPhaseIcon relies on that, so days containing one are snapped.
+func moonPhase(day time.Time) float64 {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	startPhase := moonAge(start)
+	endPhase := moonAge(end)
+
+	for _, quarter := range []float64{0.25, 0.5, 0.75, 1} {
+		if startPhase < quarter && (endPhase >= quarter || endPhase < startPhase) {
+			return math.Mod(quarter, 1)
+		}
+	}
+	return moonAge(start.Add(12 * time.Hour))
+}
+
+func moonAge(t time.Time) float64 {
+	age := math.Mod((julianDay(t)-referenceNewMoonJD)/synodicMonthDays, 1)
+	if age < 0 {
+		age++
+	}
+	return age
+}
+
+func degreesToRadians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func radiansToDegrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
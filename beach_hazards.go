@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	nwsHazardTimeout = 3 * time.Second
+	// beachHazardCacheExpiration keeps alerts fresh; the surf zone forecast
+	// itself is only issued a couple of times a day.
+	beachHazardCacheExpiration = 10 * time.Minute
+	// surfZoneForecastMaxAge ignores a surf zone forecast the office has
+	// stopped issuing, as many do outside the beach season.
+	surfZoneForecastMaxAge = 24 * time.Hour
+
+	ripCurrentRiskLow      = "low"
+	ripCurrentRiskModerate = "moderate"
+	ripCurrentRiskHigh     = "high"
+)
+
+var nwsAPIURL = nwsAPIURLDefault
+
+// beachAlertEvents are the NWS alerts that concern the beach, most serious
+// first.
+var beachAlertEvents = []string{
+	"High Surf Warning",
+	"Rip Current Statement",
+	"High Surf Advisory",
+	"Beach Hazards Statement",
+}
+
+var (
+	nwsZonePattern        = regexp.MustCompile(`^[A-Z]{2}Z[0-9]{3}$`)
+	ugcStartPattern       = regexp.MustCompile(`^[A-Z]{2}[CZ][0-9]{3}`)
+	ugcEndPattern         = regexp.MustCompile(`[0-9]{6}-$`)
+	ripCurrentRiskPattern = regexp.MustCompile(`(?i)rip current risk\*?[ .:]*(low|moderate|high)`)
+)
+
+// BeachHazards is what the NWS says about the water at a forecast zone: the
+// rip current risk from the latest Surf Zone Forecast, empty when the office
+// has none for the zone, and the active beach alerts.
+type BeachHazards struct {
+	Zone           string
+	RipCurrentRisk string
+	Alerts         []NWSAlert
+}
+
+// NWSAlert is one active alert. Ends falls back to the expiry when the NWS
+// gives no end time.
+type NWSAlert struct {
+	Event    string
+	Headline string
+	Sender   string
+	Onset    time.Time
+	Ends     time.Time
+}
+
+// nwsZoneInfo is the forecast office responsible for a zone, which issues
+// its Surf Zone Forecast.
+type nwsZoneInfo struct {
+	Office string
+}
+
+type nwsZone struct {
+	Properties struct {
+		CWA []string `json:"cwa"`
+	} `json:"properties"`
+}
+
+type nwsAlerts struct {
+	Features []struct {
+		Properties struct {
+			Event      string     `json:"event"`
+			Headline   string     `json:"headline"`
+			SenderName string     `json:"senderName"`
+			Onset      *time.Time `json:"onset"`
+			Ends       *time.Time `json:"ends"`
+			Expires    *time.Time `json:"expires"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+type nwsProductList struct {
+	Graph []struct {
+		ID           string    `json:"id"`
+		IssuanceTime time.Time `json:"issuanceTime"`
+	} `json:"@graph"`
+}
+
+type nwsProduct struct {
+	IssuanceTime time.Time `json:"issuanceTime"`
+	ProductText  string    `json:"productText"`
+}
+
+// getBeachHazards returns the beach hazards for loc from the cache or the
+// NWS.
+func getBeachHazards(ctx context.Context, loc Location) (BeachHazards, error) {
+	if cachedData, found := weatherCache.Get(beachHazardsCacheKey(loc)); found {
+		return cachedData.(BeachHazards), nil
+	}
+	return refreshBeachHazards(ctx, loc)
+}
+
+func beachHazardsCacheKey(loc Location) string {
+	return "nws-hazards:" + beachHazardsKey(loc)
+}
+
+// beachHazardsKey is the configured zone, or the coordinates the zone is
+// looked up from.
+func beachHazardsKey(loc Location) string {
+	if loc.NWSZone != "" {
+		return loc.NWSZone
+	}
+	return loc.coordinatesKey()
+}
+
+// refreshBeachHazards fetches the alerts and the Surf Zone Forecast and
+// caches them, ignoring any cached entry. One of the two failing only leaves
+// its part empty.
+func refreshBeachHazards(ctx context.Context, loc Location) (BeachHazards, error) {
+	cacheKey := beachHazardsCacheKey(loc)
+	result, err := shareUpstream(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		zone, err := nwsForecastZone(ctx, loc)
+		if err != nil {
+			return BeachHazards{}, err
+		}
+		hazards := BeachHazards{Zone: zone}
+
+		alerts, alertsErr := fetchNWSAlerts(ctx, zone)
+		if alertsErr == nil {
+			hazards.Alerts = beachAlerts(alerts)
+		}
+		var riskErr error
+		hazards.RipCurrentRisk, riskErr = fetchRipCurrentRisk(ctx, zone, time.Now())
+		if alertsErr != nil && riskErr != nil {
+			return BeachHazards{}, errors.Join(alertsErr, riskErr)
+		}
+		if err := errors.Join(alertsErr, riskErr); err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Beach hazards are incomplete: %v", err),
+			})
+		}
+
+		weatherCache.Set(cacheKey, hazards, beachHazardCacheExpiration)
+		return hazards, nil
+	})
+	if err != nil {
+		return BeachHazards{}, err
+	}
+	return result.(BeachHazards), nil
+}
+
+// nwsForecastZone is the configured zone, or the one the NWS assigns to the
+// location's coordinates.
+func nwsForecastZone(ctx context.Context, loc Location) (string, error) {
+	if loc.NWSZone != "" {
+		return loc.NWSZone, nil
+	}
+	point, err := nwsProvider{baseURL: nwsAPIURL}.point(ctx, loc)
+	if err != nil {
+		return "", err
+	}
+	zone := path.Base(point.Properties.ForecastZone)
+	if !nwsZonePattern.MatchString(zone) {
+		return "", &APIError{URL: nwsAPIURL, Operation: "process NWS point", Err: fmt.Errorf("no forecast zone for this location")}
+	}
+	return zone, nil
+}
+
+// nwsZoneOffice looks up the office for a zone. Zones practically never
+// change hands, so the lookup is cached without expiry.
+func nwsZoneOffice(ctx context.Context, zone string) (string, error) {
+	cacheKey := "nws-zone:" + zone
+	if cached, found := weatherCache.Get(cacheKey); found {
+		return cached.(nwsZoneInfo).Office, nil
+	}
+
+	zoneURL, err := nwsURL("zones", "forecast", zone)
+	if err != nil {
+		return "", err
+	}
+	var info nwsZone
+	if err := getNWSJSON(ctx, zoneURL, "zone", &info); err != nil {
+		return "", err
+	}
+	if len(info.Properties.CWA) == 0 {
+		return "", &APIError{URL: zoneURL, Operation: "process NWS zone", Err: fmt.Errorf("no forecast office for zone %s", zone)}
+	}
+
+	office := info.Properties.CWA[0]
+	weatherCache.Set(cacheKey, nwsZoneInfo{Office: office}, cache.NoExpiration)
+	return office, nil
+}
+
+func fetchNWSAlerts(ctx context.Context, zone string) ([]NWSAlert, error) {
+	apiRequestsTotal.WithLabelValues("nws_alerts").Inc()
+	alertsURL, err := nwsURL("alerts", "active")
+	if err != nil {
+		return nil, err
+	}
+	alertsURL += "?" + url.Values{"zone": {zone}}.Encode()
+
+	var response nwsAlerts
+	if err := getNWSJSON(ctx, alertsURL, "alerts", &response); err != nil {
+		return nil, err
+	}
+	return response.alerts(), nil
+}
+
+func (r nwsAlerts) alerts() []NWSAlert {
+	alerts := []NWSAlert{}
+	for _, feature := range r.Features {
+		p := feature.Properties
+		alert := NWSAlert{Event: p.Event, Headline: p.Headline, Sender: p.SenderName}
+		if p.Onset != nil {
+			alert.Onset = *p.Onset
+		}
+		if p.Ends != nil {
+			alert.Ends = *p.Ends
+		} else if p.Expires != nil {
+			alert.Ends = *p.Expires
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// beachAlerts keeps the alerts in beachAlertEvents, most serious first.
+func beachAlerts(alerts []NWSAlert) []NWSAlert {
+	var result []NWSAlert
+	for _, event := range beachAlertEvents {
+		for _, alert := range alerts {
+			if strings.EqualFold(alert.Event, event) {
+				result = append(result, alert)
+			}
+		}
+	}
+	return result
+}
+
+// fetchRipCurrentRisk reads the zone's rip current risk from the office's
+// latest Surf Zone Forecast. It returns "" without an error when the office
+// issues none, or none recent, or the zone is not in it.
+func fetchRipCurrentRisk(ctx context.Context, zone string, now time.Time) (string, error) {
+	apiRequestsTotal.WithLabelValues("surf_zone_forecast").Inc()
+	office, err := nwsZoneOffice(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+
+	listURL, err := nwsURL("products", "types", "SRF", "locations", office)
+	if err != nil {
+		return "", err
+	}
+	var list nwsProductList
+	if err := getNWSJSON(ctx, listURL, "surf zone forecasts", &list); err != nil {
+		return "", err
+	}
+	latest := -1
+	for i, product := range list.Graph {
+		if latest < 0 || product.IssuanceTime.After(list.Graph[latest].IssuanceTime) {
+			latest = i
+		}
+	}
+	if latest < 0 || now.Sub(list.Graph[latest].IssuanceTime) > surfZoneForecastMaxAge {
+		return "", nil
+	}
+
+	productURL, err := nwsURL("products", list.Graph[latest].ID)
+	if err != nil {
+		return "", err
+	}
+	var product nwsProduct
+	if err := getNWSJSON(ctx, productURL, "surf zone forecast", &product); err != nil {
+		return "", err
+	}
+	return ripCurrentRiskForZone(product.ProductText, zone), nil
+}
+
+// ripCurrentRiskForZone finds the zone's segment of a Surf Zone Forecast and
+// returns its first rip current risk, which is today's.
+func ripCurrentRiskForZone(text, zone string) string {
+	for _, segment := range strings.Split(text, "$$") {
+		if !slices.Contains(ugcZones(segmentUGC(segment)), zone) {
+			continue
+		}
+		if match := ripCurrentRiskPattern.FindStringSubmatch(segment); match != nil {
+			return strings.ToLower(match[1])
+		}
+		return ""
+	}
+	return ""
+}
+
+// segmentUGC returns the UGC line that opens a product segment, joined when
+// it wraps, or "" when the segment has none.
+func segmentUGC(segment string) string {
+	var ugc strings.Builder
+	for _, line := range strings.Split(segment, "\n") {
+		line = strings.TrimSpace(line)
+		if ugc.Len() == 0 && !ugcStartPattern.MatchString(line) {
+			continue
+		}
+		ugc.WriteString(line)
+		if ugcEndPattern.MatchString(line) {
+			return ugc.String()
+		}
+	}
+	return ""
+}
+
+// ugcZones expands a UGC line such as "FLZ124-125-133>135-172000-" into the
+// zones it names. A three-letter prefix carries over to the bare numbers
+// after it, ">" marks a range and the six-digit expiry ends the list.
+func ugcZones(ugc string) []string {
+	var zones []string
+	prefix := ""
+	for _, token := range strings.Split(ugc, "-") {
+		if len(token) == 6 && strings.Trim(token, "0123456789") == "" {
+			break
+		}
+		if ugcStartPattern.MatchString(token) {
+			prefix, token = token[:3], token[3:]
+		}
+		first, last, isRange := strings.Cut(token, ">")
+		start, err := strconv.Atoi(first)
+		if err != nil || prefix == "" {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		for n := start; n <= end; n++ {
+			zones = append(zones, fmt.Sprintf("%s%03d", prefix, n))
+		}
+	}
+	return zones
+}
+
+func nwsURL(segments ...string) (string, error) {
+	u, err := url.Parse(nwsAPIURL)
+	if err != nil {
+		return "", &APIError{URL: nwsAPIURL, Operation: "build NWS request", Err: err}
+	}
+	return u.JoinPath(segments...).String(), nil
+}
+
+// getNWSJSON GETs an NWS resource under nwsHazardTimeout; what names it in
+// errors.
+func getNWSJSON(ctx context.Context, apiURL, what string, out any) error {
+	requestContext, cancel := context.WithTimeout(ctx, nwsHazardTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return &APIError{URL: apiURL, Operation: "build NWS request", Err: err}
+	}
+	req.Header.Set("User-Agent", "kindle-weather/1.0")
+	req.Header.Set("Accept", "application/geo+json, application/ld+json, application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &APIError{URL: apiURL, Operation: "GET NWS " + what, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{URL: apiURL, Operation: "GET NWS " + what, Err: fmt.Errorf("status code %d", resp.StatusCode)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &APIError{URL: apiURL, Operation: "decode NWS " + what, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// newNWSFixtureServer serves the recorded NWS payloads in testdata and
+// points the NWS client at it for the test.
+func newNWSFixtureServer(t *testing.T) *int {
+	t.Helper()
+	fixtures := map[string]string{
+		"/points/29.65,-81.2":                            "nws_point.json",
+		"/zones/forecast/FLZ133":                         "nws_zone_flz133.json",
+		"/alerts/active":                                 "nws_alerts_flz133.json",
+		"/products/types/SRF/locations/JAX":              "nws_srf_list_jax.json",
+		"/products/5e0b1c2d-0000-4000-8000-000000000002": "nws_srf_jax.json",
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		name, ok := fixtures[r.URL.Path]
+		if !ok || r.Header.Get("User-Agent") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/alerts/active" && r.URL.Query().Get("zone") != "FLZ133" {
+			http.Error(w, "unexpected zone", http.StatusBadRequest)
+			return
+		}
+		data, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL, oldClient, oldCache := nwsAPIURL, httpClient, weatherCache
+	nwsAPIURL = server.URL
+	httpClient = server.Client()
+	weatherCache = cache.New(time.Hour, time.Hour)
+	t.Cleanup(func() { nwsAPIURL, httpClient, weatherCache = oldURL, oldClient, oldCache })
+	return &requests
+}
+
+func TestRefreshBeachHazards_LooksUpZoneAndKeepsBeachAlerts(t *testing.T) {
+	requests := newNWSFixtureServer(t)
+
+	hazards, err := refreshBeachHazards(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("refreshBeachHazards() error = %v", err)
+	}
+	if hazards.Zone != "FLZ133" {
+		t.Fatalf("zone = %q; want FLZ133 from the point lookup", hazards.Zone)
+	}
+	if len(hazards.Alerts) != 1 || hazards.Alerts[0].Event != "Rip Current Statement" {
+		t.Fatalf("alerts = %+v; want only the rip current statement", hazards.Alerts)
+	}
+	if ends := hazards.Alerts[0].Ends; !ends.Equal(time.Date(2026, time.August, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("alert ends = %v; want the ends time rather than the expiry", ends)
+	}
+	// The recorded forecast is months older than the test clock.
+	if hazards.RipCurrentRisk != "" {
+		t.Fatalf("rip current risk = %q; want none from a stale forecast", hazards.RipCurrentRisk)
+	}
+
+	before := *requests
+	if _, err := getBeachHazards(context.Background(), defaultLocation); err != nil {
+		t.Fatalf("getBeachHazards() error = %v", err)
+	}
+	if *requests != before {
+		t.Fatalf("getBeachHazards() made %d requests; want the cached hazards", *requests-before)
+	}
+}
+
+func TestFetchRipCurrentRisk(t *testing.T) {
+	newNWSFixtureServer(t)
+	issued := time.Date(2026, time.August, 11, 7, 24, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		zone string
+		now  time.Time
+		want string
+	}{
+		{zone: "FLZ133", now: issued.Add(2 * time.Hour), want: ripCurrentRiskHigh},
+		{zone: "FLZ133", now: issued.Add(30 * time.Hour)},
+	} {
+		got, err := fetchRipCurrentRisk(context.Background(), tt.zone, tt.now)
+		if err != nil {
+			t.Fatalf("fetchRipCurrentRisk(%s) error = %v", tt.zone, err)
+		}
+		if got != tt.want {
+			t.Errorf("fetchRipCurrentRisk(%s, %v) = %q; want %q", tt.zone, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestRipCurrentRiskForZone(t *testing.T) {
+	data, err := os.ReadFile("testdata/nws_srf_jax.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var product nwsProduct
+	if err := json.Unmarshal(data, &product); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	for zone, want := range map[string]string{
+		"FLZ124": ripCurrentRiskModerate,
+		"FLZ133": ripCurrentRiskHigh,
+		"FLZ138": ripCurrentRiskHigh,
+		"GAZ166": ripCurrentRiskLow,
+		"FLZ999": "",
+	} {
+		if got := ripCurrentRiskForZone(product.ProductText, zone); got != want {
+			t.Errorf("ripCurrentRiskForZone(%s) = %q; want %q", zone, got, want)
+		}
+	}
+	if got := ripCurrentRiskForZone("FLZ133-112000-\n.TODAY...\nRIP CURRENT RISK......LOW.\n$$", "FLZ133"); got != ripCurrentRiskLow {
+		t.Errorf("upper case product = %q; want low", got)
+	}
+}
+
+func TestUGCZones(t *testing.T) {
+	tests := map[string][]string{
+		"FLZ124-125-112000-":            {"FLZ124", "FLZ125"},
+		"FLZ133>135-GAZ154-112000-":     {"FLZ133", "FLZ134", "FLZ135", "GAZ154"},
+		"FLZ124-125-133-138-112000-X-Y": {"FLZ124", "FLZ125", "FLZ133", "FLZ138"},
+		"":                              nil,
+	}
+	for ugc, want := range tests {
+		if got := ugcZones(ugc); !reflect.DeepEqual(got, want) {
+			t.Errorf("ugcZones(%q) = %v; want %v", ugc, got, want)
+		}
+	}
+}
+
+func TestGetBeachStatus_HazardsComeFirst(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	now := time.Date(2026, time.August, 11, 6, 0, 0, 0, loc)
+	at := func(hour int) time.Time { return time.Date(2026, time.August, 11, hour, 0, 0, 0, loc) }
+	statement := NWSAlert{Event: "Rip Current Statement", Onset: at(3), Ends: at(20)}
+	surge := 1.5
+
+	tests := []struct {
+		name     string
+		hazards  BeachHazards
+		anomaly  float64
+		wantKind string
+		wantText string
+	}{
+		{name: "alert", hazards: BeachHazards{RipCurrentRisk: ripCurrentRiskHigh, Alerts: []NWSAlert{statement}}, anomaly: surge, wantKind: "hazard", wantText: "Rip current statement until 8 PM"},
+		{name: "high risk", hazards: BeachHazards{RipCurrentRisk: ripCurrentRiskHigh}, anomaly: surge, wantKind: "hazard", wantText: "High rip current risk"},
+		{name: "ended alert", hazards: BeachHazards{Alerts: []NWSAlert{{Event: "Rip Current Statement", Ends: at(5)}}}, wantKind: "surf"},
+		{name: "moderate risk", hazards: BeachHazards{RipCurrentRisk: ripCurrentRiskModerate}, wantKind: "surf"},
+		{name: "low risk", hazards: BeachHazards{RipCurrentRisk: ripCurrentRiskLow}, wantKind: "surf"},
+	}
+	for _, tt := range tests {
+		status := getBeachStatus(tt.hazards, nil, tt.anomaly, testSurfWindow(now), now)
+		if status == nil || status.Kind != tt.wantKind || (tt.wantText != "" && status.Text != tt.wantText) {
+			t.Errorf("%s: getBeachStatus() = %+v; want %s %q", tt.name, status, tt.wantKind, tt.wantText)
+		}
+	}
+
+	status := getBeachStatus(BeachHazards{RipCurrentRisk: ripCurrentRiskModerate}, nil, 0, nil, now)
+	if status == nil || status.Kind != "rip" || status.Text != "Moderate rip current risk" {
+		t.Fatalf("getBeachStatus() = %+v; want the moderate risk when nothing else applies", status)
+	}
+}
+
+func TestBeachAlertText(t *testing.T) {
+	now := time.Date(2026, time.August, 11, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		alert NWSAlert
+		want  string
+	}{
+		{alert: NWSAlert{Event: "High Surf Advisory", Onset: now.Add(-time.Hour), Ends: now.Add(9*time.Hour + 30*time.Minute)}, want: "High surf advisory until 6:30 PM"},
+		{alert: NWSAlert{Event: "Beach Hazards Statement", Onset: now.Add(5 * time.Hour), Ends: now.Add(30 * time.Hour)}, want: "Beach hazards statement from 2 PM"},
+		{alert: NWSAlert{Event: "Rip Current Statement", Ends: now.Add(24 * time.Hour)}, want: "Rip current statement until Wed 9 AM"},
+		{alert: NWSAlert{Event: "Rip Current Statement"}, want: "Rip current statement"},
+	}
+	for _, tt := range tests {
+		if got := beachAlertText(tt.alert, now); got != tt.want {
+			t.Errorf("beachAlertText(%+v) = %q; want %q", tt.alert, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -16,56 +18,138 @@ type BeachStatus struct {
 	Text string
 }
 
-func getBeachStatus(predictions []TidePrediction, goodSurfToday bool, now time.Time) *BeachStatus {
+// getBeachStatus picks the one notice shown under the weather. NWS beach
+// alerts and a high rip current risk come first, a moderate risk only when
+// there is nothing else to say. anomaly is the observed-minus-predicted
+// water level, or 0 when unknown; surf is today's best surf window, or nil
+// when there is none.
+func getBeachStatus(hazards BeachHazards, predictions []TidePrediction, anomaly float64, surf *SurfWindow, now time.Time) *BeachStatus {
+	if alert := currentBeachAlert(hazards.Alerts, now); alert != nil {
+		return &BeachStatus{Kind: "hazard", Text: beachAlertText(*alert, now)}
+	}
+	if hazards.RipCurrentRisk == ripCurrentRiskHigh {
+		return &BeachStatus{Kind: "hazard", Text: "High rip current risk"}
+	}
+	if text := tideAnomalyText(anomaly); text != "" {
+		return &BeachStatus{Kind: "surge", Text: text}
+	}
 	if tide := upcomingSuperLowTide(predictions, now); tide != nil {
 		return &BeachStatus{
 			Kind: "tide",
-			Text: fmt.Sprintf("Super low tide at %s", tide.Time),
+			Text: fmt.Sprintf("Super low tide at %s", tide.Time.In(now.Location()).Format("3:04 PM")),
 		}
 	}
-	if goodSurfToday {
-		return &BeachStatus{Kind: "surf", Text: "Good surf today"}
+	if surf != nil {
+		return &BeachStatus{Kind: "surf", Text: surfWindowText(*surf)}
+	}
+	if hazards.RipCurrentRisk == ripCurrentRiskModerate {
+		return &BeachStatus{Kind: "rip", Text: "Moderate rip current risk"}
 	}
 	return nil
 }
 
+// currentBeachAlert returns the most serious alert in effect now or later
+// today. alerts must be ordered as beachAlerts orders them.
+func currentBeachAlert(alerts []NWSAlert, now time.Time) *NWSAlert {
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	for i, alert := range alerts {
+		if !alert.Ends.IsZero() && !alert.Ends.After(now) {
+			continue
+		}
+		if alert.Onset.IsZero() || alert.Onset.Before(endOfDay) {
+			return &alerts[i]
+		}
+	}
+	return nil
+}
+
+// beachAlertText names the alert and when it starts, or when it ends once
+// it is in effect: "Rip current statement until 8 PM".
+func beachAlertText(alert NWSAlert, now time.Time) string {
+	event := strings.ToUpper(alert.Event[:1]) + strings.ToLower(alert.Event[1:])
+	switch {
+	case alert.Onset.After(now):
+		return event + " from " + alertClock(alert.Onset, now)
+	case !alert.Ends.IsZero():
+		return event + " until " + alertClock(alert.Ends, now)
+	default:
+		return event
+	}
+}
+
+// alertClock formats t in now's zone, naming the weekday when it is not
+// today: "8 PM", "Sat 8:30 AM".
+func alertClock(t, now time.Time) string {
+	t = t.In(now.Location())
+	clock := t.Format("3:04 PM")
+	if t.Minute() == 0 {
+		clock = t.Format("3 PM")
+	}
+	if t.YearDay() != now.YearDay() || t.Year() != now.Year() {
+		return t.Format("Mon ") + clock
+	}
+	return clock
+}
+
+// upcomingSuperLowTide finds the next qualifying low tide during waking
+// hours on now's date, in now's zone. Predictions for other days are
+// ignored, so callers can pass the whole multi-day range.
 func upcomingSuperLowTide(predictions []TidePrediction, now time.Time) *TidePrediction {
-	loc := easternLocation()
-	localNow := now.In(loc)
-	start := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), wakingHoursStart, 0, 0, 0, loc)
-	end := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), wakingHoursEnd, 0, 0, 0, loc)
+	loc := now.Location()
+	start := time.Date(now.Year(), now.Month(), now.Day(), wakingHoursStart, 0, 0, 0, loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), wakingHoursEnd, 0, 0, 0, loc)
 
 	var next *TidePrediction
-	var nextTime time.Time
 	for i := range predictions {
 		prediction := predictions[i]
 		if prediction.Type != "L" || prediction.Height > superLowTideMaxFeet {
 			continue
 		}
-
-		clockTime, err := time.Parse("3:04 PM", prediction.Time)
-		if err != nil {
-			continue
-		}
-		predictionTime := time.Date(
-			localNow.Year(), localNow.Month(), localNow.Day(),
-			clockTime.Hour(), clockTime.Minute(), 0, 0, loc,
-		)
-		if predictionTime.Before(localNow) || predictionTime.Before(start) || predictionTime.After(end) {
+		if prediction.Time.Before(now) || prediction.Time.Before(start) || prediction.Time.After(end) {
 			continue
 		}
-		if next == nil || predictionTime.Before(nextTime) {
+		if next == nil || prediction.Time.Before(next.Time) {
 			next = &predictions[i]
-			nextTime = predictionTime
 		}
 	}
 
 	return next
 }
 
-func easternLocation() *time.Location {
-	if loc, err := time.LoadLocation("America/New_York"); err == nil {
-		return loc
+// tideAnomalyText describes a water level at least tideAnomalyThreshold away
+// from the prediction, or returns "" for anything smaller.
+func tideAnomalyText(anomaly float64) string {
+	if math.Abs(anomaly) < tideAnomalyThreshold {
+		return ""
+	}
+	if anomaly > 0 {
+		return fmt.Sprintf("%+.1f ft surge", anomaly)
+	}
+	return fmt.Sprintf("%.1f ft below predicted tide", -anomaly)
+}
+
+// surfWindowText describes a surf window by its hours and first hour, e.g.
+// "Best 7–10 AM, 3 ft @ 9 s, offshore".
+func surfWindowText(w SurfWindow) string {
+	wind := w.Hour.Wind
+	if wind == surfWindLight {
+		wind = "light wind"
+	}
+	return fmt.Sprintf("Best %s, %.0f ft @ %.0f s, %s",
+		formatHourRange(w.Start, w.End), math.Round(w.Hour.WaveHeight), math.Round(w.Hour.WavePeriod), wind)
+}
+
+// formatHourRange writes a span of clock times compactly, naming AM or PM
+// once when both ends share it: "7–10 AM", "11 AM–1 PM".
+func formatHourRange(start, end time.Time) string {
+	clock := func(t time.Time) string {
+		if t.Minute() == 0 {
+			return t.Format("3")
+		}
+		return t.Format("3:04")
+	}
+	if start.Format("PM") == end.Format("PM") {
+		return clock(start) + "–" + clock(end) + " " + end.Format("PM")
 	}
-	return time.FixedZone("Eastern", -5*60*60)
+	return clock(start) + " " + start.Format("PM") + "–" + clock(end) + " " + end.Format("PM")
 }
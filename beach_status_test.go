@@ -10,7 +10,10 @@ func TestUpcomingSuperLowTide(t *testing.T) {
 	if err != nil {
 		t.Fatalf("time.LoadLocation() error = %v", err)
 	}
-	now := time.Date(2026, time.August, 11, 8, 0, 0, 0, loc)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.August, day, hour, minute, 0, 0, loc)
+	}
+	now := at(11, 8, 0)
 
 	tests := []struct {
 		name        string
@@ -20,41 +23,65 @@ func TestUpcomingSuperLowTide(t *testing.T) {
 		{
 			name: "next negative daytime low tide",
 			predictions: []TidePrediction{
-				{Time: "6:30 AM", Type: "L", Height: -0.4},
-				{Time: "1:45 PM", Type: "L", Height: -0.2},
+				{Time: at(11, 6, 30), Type: "L", Height: -0.4},
+				{Time: at(11, 13, 45), Type: "L", Height: -0.2},
 			},
 			wantTime: "1:45 PM",
 		},
 		{
 			name: "zero feet qualifies",
 			predictions: []TidePrediction{
-				{Time: "10:15 AM", Type: "L", Height: 0},
+				{Time: at(11, 10, 15), Type: "L", Height: 0},
 			},
 			wantTime: "10:15 AM",
 		},
 		{
 			name: "ordinary low tide does not qualify",
 			predictions: []TidePrediction{
-				{Time: "10:15 AM", Type: "L", Height: 0.1},
+				{Time: at(11, 10, 15), Type: "L", Height: 0.1},
 			},
 		},
 		{
 			name: "past low tide does not qualify",
 			predictions: []TidePrediction{
-				{Time: "7:30 AM", Type: "L", Height: -0.3},
+				{Time: at(11, 7, 30), Type: "L", Height: -0.3},
 			},
 		},
 		{
 			name: "low tide after waking hours does not qualify",
 			predictions: []TidePrediction{
-				{Time: "7:30 PM", Type: "L", Height: -0.3},
+				{Time: at(11, 19, 30), Type: "L", Height: -0.3},
 			},
 		},
 		{
 			name: "high tide does not qualify",
 			predictions: []TidePrediction{
-				{Time: "10:15 AM", Type: "H", Height: -0.2},
+				{Time: at(11, 10, 15), Type: "H", Height: -0.2},
+			},
+		},
+		{
+			name: "other days in a multi-day range do not qualify",
+			predictions: []TidePrediction{
+				{Time: at(10, 13, 45), Type: "L", Height: -0.5},
+				{Time: at(12, 0, 30), Type: "L", Height: -0.5},
+				{Time: at(12, 10, 15), Type: "L", Height: -0.5},
+			},
+		},
+		{
+			name: "earliest of several days wins regardless of order",
+			predictions: []TidePrediction{
+				{Time: at(12, 11, 0), Type: "L", Height: -0.5},
+				{Time: at(11, 16, 20), Type: "L", Height: -0.1},
+				{Time: at(11, 12, 5), Type: "L", Height: -0.3},
+			},
+			wantTime: "12:05 PM",
+		},
+		{
+			name: "times in another zone are compared as instants",
+			predictions: []TidePrediction{
+				{Time: at(11, 14, 0).UTC(), Type: "L", Height: -0.3},
 			},
+			wantTime: "2:00 PM",
 		},
 	}
 
@@ -67,30 +94,142 @@ func TestUpcomingSuperLowTide(t *testing.T) {
 				}
 				return
 			}
-			if got == nil || got.Time != tt.wantTime {
+			if got == nil || got.Time.In(loc).Format("3:04 PM") != tt.wantTime {
 				t.Fatalf("upcomingSuperLowTide() = %+v; want time %q", got, tt.wantTime)
 			}
 		})
 	}
 }
 
+func TestUpcomingSuperLowTide_DSTTransitionDays(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		now      string
+		raw      []string
+		wantTime string
+	}{
+		{
+			// Clocks skip 2:00-3:00 AM on March 8, 2026.
+			name:     "spring forward",
+			now:      "2026-03-08 07:30",
+			raw:      []string{"2026-03-08 01:40", "2026-03-08 08:05", "2026-03-09 08:50"},
+			wantTime: "8:05 AM",
+		},
+		{
+			// 1:00-2:00 AM happens twice on November 1, 2026.
+			name:     "fall back",
+			now:      "2026-11-01 07:30",
+			raw:      []string{"2026-11-01 01:30", "2026-11-01 18:55"},
+			wantTime: "6:55 PM",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw noaaPredictions
+			for _, clock := range tt.raw {
+				raw.Predictions = append(raw.Predictions, struct {
+					Time   string `json:"t"`
+					Type   string `json:"type"`
+					Height string `json:"v"`
+				}{Time: clock, Type: "L", Height: "-0.3"})
+			}
+			data, err := processTideData(raw, loc)
+			if err != nil {
+				t.Fatalf("processTideData() error = %v", err)
+			}
+			now, err := time.ParseInLocation("2006-01-02 15:04", tt.now, loc)
+			if err != nil {
+				t.Fatalf("time.ParseInLocation() error = %v", err)
+			}
+
+			got := upcomingSuperLowTide(data.Predictions, now)
+			if got == nil || got.Time.Format("3:04 PM") != tt.wantTime {
+				t.Fatalf("upcomingSuperLowTide() = %+v; want %s", got, tt.wantTime)
+			}
+			if status := getBeachStatus(BeachHazards{}, data.Predictions, 0, nil, now); status == nil || status.Text != "Super low tide at "+tt.wantTime {
+				t.Fatalf("getBeachStatus() = %+v", status)
+			}
+		})
+	}
+}
+
 func TestGetBeachStatus_TideTakesPrecedenceOverSurf(t *testing.T) {
 	loc, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		t.Fatalf("time.LoadLocation() error = %v", err)
 	}
 	now := time.Date(2026, time.August, 11, 8, 0, 0, 0, loc)
-	predictions := []TidePrediction{{Time: "1:45 PM", Type: "L", Height: -0.2}}
+	predictions := []TidePrediction{{Time: time.Date(2026, time.August, 11, 13, 45, 0, 0, loc), Type: "L", Height: -0.2}}
 
-	status := getBeachStatus(predictions, true, now)
+	status := getBeachStatus(BeachHazards{}, predictions, 0, testSurfWindow(now), now)
 	if status == nil || status.Kind != "tide" || status.Text != "Super low tide at 1:45 PM" {
 		t.Fatalf("getBeachStatus() = %+v; want upcoming tide status", status)
 	}
 }
 
 func TestGetBeachStatus_FallsBackToSurf(t *testing.T) {
-	status := getBeachStatus(nil, true, time.Now())
-	if status == nil || status.Kind != "surf" || status.Text != "Good surf today" {
+	now := time.Date(2026, time.August, 11, 6, 0, 0, 0, time.UTC)
+	status := getBeachStatus(BeachHazards{}, nil, 0, testSurfWindow(now), now)
+	if status == nil || status.Kind != "surf" || status.Text != "Best 7–10 AM, 3 ft @ 9 s, offshore" {
 		t.Fatalf("getBeachStatus() = %+v; want surf status", status)
 	}
 }
+
+// testSurfWindow is a 7–10 AM window on now's date.
+func testSurfWindow(now time.Time) *SurfWindow {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 7, 0, 0, 0, now.Location())
+	return &SurfWindow{
+		Start:  start,
+		End:    start.Add(3 * time.Hour),
+		Rating: 4,
+		Hour:   SurfHour{Time: start, Rating: 4, WaveHeight: 2.8, WavePeriod: 9.2, Wind: surfWindOffshore},
+	}
+}
+
+func TestSurfWindowText(t *testing.T) {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2026, time.August, 11, hour, minute, 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		start, end time.Time
+		wind       string
+		want       string
+	}{
+		{day(7, 0), day(10, 0), surfWindOffshore, "Best 7–10 AM, 3 ft @ 9 s, offshore"},
+		{day(11, 0), day(13, 0), surfWindLight, "Best 11 AM–1 PM, 3 ft @ 9 s, light wind"},
+		{day(15, 30), day(16, 30), surfWindOffshore, "Best 3:30–4:30 PM, 3 ft @ 9 s, offshore"},
+	} {
+		window := SurfWindow{Start: tt.start, End: tt.end, Hour: SurfHour{WaveHeight: 2.5, WavePeriod: 9, Wind: tt.wind}}
+		if got := surfWindowText(window); got != tt.want {
+			t.Errorf("surfWindowText() = %q; want %q", got, tt.want)
+		}
+	}
+}
+
+func TestGetBeachStatus_SurgeTakesPrecedence(t *testing.T) {
+	oldThreshold := tideAnomalyThreshold
+	defer func() { tideAnomalyThreshold = oldThreshold }()
+	tideAnomalyThreshold = 1.0
+
+	now := time.Date(2026, time.August, 11, 8, 0, 0, 0, time.UTC)
+	predictions := []TidePrediction{{Time: now.Add(5 * time.Hour), Type: "L", Height: -0.2}}
+	for _, tt := range []struct {
+		anomaly  float64
+		wantKind string
+		wantText string
+	}{
+		{1.24, "surge", "+1.2 ft surge"},
+		{-1.5, "surge", "1.5 ft below predicted tide"},
+		{0.9, "tide", "Super low tide at 1:00 PM"},
+		{-0.9, "tide", "Super low tide at 1:00 PM"},
+	} {
+		status := getBeachStatus(BeachHazards{}, predictions, tt.anomaly, testSurfWindow(now), now)
+		if status == nil || status.Kind != tt.wantKind || status.Text != tt.wantText {
+			t.Errorf("getBeachStatus(anomaly %.2f) = %+v; want %s %q", tt.anomaly, status, tt.wantKind, tt.wantText)
+		}
+	}
+}
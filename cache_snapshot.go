@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	cacheSnapshotFile = "cache.gob"
+	// cacheSnapshotVersion is bumped whenever a cached type changes shape in a
+	// way gob cannot decode; older snapshots are then ignored rather than
+	// failing startup.
+	cacheSnapshotVersion = 3
+)
+
+func init() {
+	// Everything stored in a snapshotted cache has to be registered so gob
+	// can decode it back into the interface{} go-cache hands out.
+	gob.Register(WeatherData{})
+	gob.Register(nwsPoint{})
+	gob.Register(TideData{})
+	gob.Register(TideCurve{})
+	gob.Register(WaterLevelData{})
+	gob.Register(StationSensors{})
+	gob.Register(launchCacheEntry{})
+	gob.Register(SurfForecast{})
+	gob.Register(BeachHazards{})
+	gob.Register(nwsZoneInfo{})
+	gob.Register(stormsCacheEntry{})
+}
+
+// cacheSnapshot is the on-disk form of every persisted cache. Gob is used
+// rather than JSON so fields the API responses never carry, like
+// WeatherData.FetchedAt, survive the round trip.
+type cacheSnapshot struct {
+	Version int
+	SavedAt time.Time
+	Caches  map[string]map[string]cachedItem
+}
+
+// cachedItem mirrors cache.Item. Expiration is the absolute expiry in Unix
+// nanoseconds, or 0 for entries that never expire.
+type cachedItem struct {
+	Object     any
+	Expiration int64
+}
+
+// snapshotCaches names the caches that are written to disk. It is a function
+// because configureRuntime replaces the cache instances.
+func snapshotCaches() map[string]*cache.Cache {
+	return map[string]*cache.Cache{
+		"weather": weatherCache,
+		"tide":    tideCache,
+		"launch":  launchCache,
+		"surf":    surfCache,
+	}
+}
+
+// saveCacheSnapshot writes every unexpired cache entry to dir. The file is
+// replaced atomically so a crash mid-write leaves the previous snapshot.
+func saveCacheSnapshot(dir string) error {
+	now := time.Now().UnixNano()
+	snapshot := cacheSnapshot{Version: cacheSnapshotVersion, SavedAt: time.Now(), Caches: map[string]map[string]cachedItem{}}
+	for name, c := range snapshotCaches() {
+		items := map[string]cachedItem{}
+		for key, item := range c.Items() {
+			if item.Expiration > 0 && item.Expiration <= now {
+				continue
+			}
+			items[key] = cachedItem{Object: item.Object, Expiration: item.Expiration}
+		}
+		snapshot.Caches[name] = items
+	}
+
+	tmp, err := os.CreateTemp(dir, cacheSnapshotFile+".*")
+	if err != nil {
+		return fmt.Errorf("create cache snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode cache snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cache snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, cacheSnapshotFile)); err != nil {
+		return fmt.Errorf("replace cache snapshot: %w", err)
+	}
+	return nil
+}
+
+// loadCacheSnapshot restores the entries saved in dir. Each entry keeps its
+// original expiry, so anything that went stale while the process was down is
+// dropped and the rest expire when they would have. A missing snapshot is not
+// an error. It returns the number of entries restored.
+func loadCacheSnapshot(dir string) (int, error) {
+	f, err := os.Open(filepath.Join(dir, cacheSnapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open cache snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snapshot cacheSnapshot
+	if err := gob.NewDecoder(f).Decode(&snapshot); err != nil {
+		return 0, fmt.Errorf("decode cache snapshot: %w", err)
+	}
+	if snapshot.Version != cacheSnapshotVersion {
+		return 0, fmt.Errorf("cache snapshot version %d is not supported (want %d)", snapshot.Version, cacheSnapshotVersion)
+	}
+
+	restored := 0
+	now := time.Now()
+	for name, c := range snapshotCaches() {
+		for key, item := range snapshot.Caches[name] {
+			expiration := cache.NoExpiration
+			if item.Expiration > 0 {
+				expiration = time.Unix(0, item.Expiration).Sub(now)
+				if expiration <= 0 {
+					continue
+				}
+			}
+			c.Set(key, item.Object, expiration)
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+// startCacheSnapshots saves the caches to dir every interval and once more
+// when ctx is cancelled, so a clean shutdown loses nothing. The returned
+// WaitGroup finishes after that final save.
+func startCacheSnapshots(ctx context.Context, dir string, interval time.Duration) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logCacheSnapshotError(saveCacheSnapshot(dir))
+				return
+			case <-ticker.C:
+				logCacheSnapshotError(saveCacheSnapshot(dir))
+			}
+		}
+	}()
+	return &wg
+}
+
+func logCacheSnapshotError(err error) {
+	if err == nil {
+		return
+	}
+	logJSON(logEntry{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Level:     "WARN",
+		Message:   fmt.Sprintf("Error saving cache snapshot: %v", err),
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestCacheSnapshot_RoundTripKeepsExpiry(t *testing.T) {
+	oldWeather, oldTide, oldLaunch, oldSurf := weatherCache, tideCache, launchCache, surfCache
+	defer func() { weatherCache, tideCache, launchCache, surfCache = oldWeather, oldTide, oldLaunch, oldSurf }()
+	resetCaches := func() {
+		weatherCache = cache.New(time.Hour, time.Hour)
+		tideCache = cache.New(time.Hour, time.Hour)
+		launchCache = cache.New(time.Hour, time.Hour)
+		surfCache = cache.New(time.Hour, time.Hour)
+	}
+	resetCaches()
+
+	fetchedAt := time.Date(2024, 4, 8, 9, 0, 0, 0, time.UTC)
+	weatherCache.Set("weather:29.65,-81.2", WeatherData{Current: CurrentWeather{Temp: 72}, Source: weatherProviderOpenMeteo, FetchedAt: fetchedAt}, 30*time.Minute)
+	weatherCache.Set("weather:29.65,-81.2:"+weatherCacheKeyLatest, WeatherData{FetchedAt: fetchedAt}, cache.NoExpiration)
+	weatherCache.Set("weather:35.6,-82.55", WeatherData{}, time.Millisecond)
+	tideCache.Set("8720218:2024-04-08", TideData{Predictions: []TidePrediction{{Time: time.Date(2024, time.April, 8, 13, 45, 0, 0, time.UTC), Type: "H", Height: 4.2}}}, cache.DefaultExpiration)
+	launchCache.Set("launch:27", launchCacheEntry{}, cache.DefaultExpiration)
+	surfCache.Set("surf:29.65,-81.2", SurfForecast{Hourly: SurfHourlyForecast{Time: []int64{1712566800}, WaveHeight: []float64{0.9}}}, cache.DefaultExpiration)
+	time.Sleep(5 * time.Millisecond)
+
+	dir := t.TempDir()
+	if err := saveCacheSnapshot(dir); err != nil {
+		t.Fatalf("saveCacheSnapshot() error = %v", err)
+	}
+
+	resetCaches()
+	restored, err := loadCacheSnapshot(dir)
+	if err != nil {
+		t.Fatalf("loadCacheSnapshot() error = %v", err)
+	}
+	if restored != 5 {
+		t.Fatalf("restored %d entries; want 5 (the expired one is dropped)", restored)
+	}
+
+	cached, expiresAt, found := weatherCache.GetWithExpiration("weather:29.65,-81.2")
+	if !found {
+		t.Fatal("weather entry was not restored")
+	}
+	weather := cached.(WeatherData)
+	if !weather.FetchedAt.Equal(fetchedAt) || weather.Current.Temp != 72 || weather.Source != weatherProviderOpenMeteo {
+		t.Fatalf("restored weather = %+v", weather)
+	}
+	if remaining := time.Until(expiresAt); remaining <= 29*time.Minute || remaining > 30*time.Minute {
+		t.Fatalf("restored weather expires in %v; want the original 30m", remaining)
+	}
+	if _, expiresAt, _ := weatherCache.GetWithExpiration("weather:29.65,-81.2:" + weatherCacheKeyLatest); !expiresAt.IsZero() {
+		t.Fatalf("latest-successful entry expires at %v; want never", expiresAt)
+	}
+	if _, found := weatherCache.Get("weather:35.6,-82.55"); found {
+		t.Fatal("expired weather entry was restored")
+	}
+	if cached, found := launchCache.Get("launch:27"); !found || cached.(launchCacheEntry).Launch != nil {
+		t.Fatalf("launch entry = %+v, %v; want an empty entry", cached, found)
+	}
+	if cached, found := tideCache.Get("8720218:2024-04-08"); !found || cached.(TideData).Predictions[0].Height != 4.2 {
+		t.Fatalf("tide entry = %+v, %v", cached, found)
+	}
+	if cached, found := surfCache.Get("surf:29.65,-81.2"); !found || cached.(SurfForecast).Hourly.WaveHeight[0] != 0.9 {
+		t.Fatalf("surf entry = %+v, %v", cached, found)
+	}
+}
+
+func TestLoadCacheSnapshot_MissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if restored, err := loadCacheSnapshot(dir); err != nil || restored != 0 {
+		t.Fatalf("loadCacheSnapshot(empty dir) = %d, %v; want 0, nil", restored, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, cacheSnapshotFile), []byte("not gob"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCacheSnapshot(dir); err == nil {
+		t.Fatal("loadCacheSnapshot() expected error for a corrupt snapshot")
+	}
+}
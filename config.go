@@ -0,0 +1,640 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is everything that can be set in the YAML config file. Every field
+// also has an environment variable, and environment variables win over the
+// file so a deployment can patch a single value without editing it.
+type Config struct {
+	AutoRefreshSeconds  int             `yaml:"auto_refresh_seconds"`
+	EnableRocketPreview bool            `yaml:"enable_rocket_preview"`
+	Panels              []string        `yaml:"panels"`
+	Location            Location        `yaml:"location"`
+	APIs                APIConfig       `yaml:"apis"`
+	Cache               CacheConfig     `yaml:"cache"`
+	Tide                TideConfig      `yaml:"tide"`
+	Surf                SurfConfig      `yaml:"surf"`
+	Forecast            ForecastConfig  `yaml:"forecast"`
+	Telemetry           TelemetryConfig `yaml:"telemetry"`
+
+	// Dashboards holds the named dashboards, resolved against the top-level
+	// settings. The raw YAML is kept separately so a dashboard only has to
+	// list what differs from the default one.
+	Dashboards    map[string]DashboardConfig `yaml:"-"`
+	RawDashboards map[string]yaml.Node       `yaml:"dashboards"`
+}
+
+type APIConfig struct {
+	WeatherProvider      string   `yaml:"weather_provider"`
+	WeatherFallbacks     []string `yaml:"weather_fallbacks"`
+	WeatherURL           string   `yaml:"weather_url"`
+	OpenWeatherAPIKey    string   `yaml:"openweather_api_key"`
+	OpenMeteoURL         string   `yaml:"open_meteo_url"`
+	NWSURL               string   `yaml:"nws_url"`
+	NOAAURL              string   `yaml:"noaa_url"`
+	SpacedevsURL         string   `yaml:"spacedevs_url"`
+	SurfURL              string   `yaml:"surf_url"`
+	NHCURL               string   `yaml:"nhc_url"`
+	LaunchTimeoutSeconds int      `yaml:"launch_timeout_seconds"`
+}
+
+type CacheConfig struct {
+	WeatherSeconds         int    `yaml:"weather_seconds"`
+	CleanupIntervalSeconds int    `yaml:"cleanup_interval_seconds"`
+	TideSeconds            int    `yaml:"tide_seconds"`
+	LaunchSeconds          int    `yaml:"launch_seconds"`
+	SurfSeconds            int    `yaml:"surf_seconds"`
+	Prefetch               bool   `yaml:"prefetch"`
+	Dir                    string `yaml:"dir"`
+	SnapshotSeconds        int    `yaml:"snapshot_seconds"`
+}
+
+// TideConfig sets the span of the tide chart around the current time, the
+// NOAA prediction interval its curve is drawn from, and the observed water
+// level overlay.
+type TideConfig struct {
+	WindowPastHours      int     `yaml:"window_past_hours"`
+	WindowFutureHours    int     `yaml:"window_future_hours"`
+	CurveInterval        string  `yaml:"curve_interval"`
+	Observed             bool    `yaml:"observed"`
+	AnomalyThresholdFeet float64 `yaml:"anomaly_threshold_feet"`
+}
+
+// SurfConfig sets the surf outlook row. What counts as good surf is part of
+// each location's profile.
+type SurfConfig struct {
+	OutlookDays int `yaml:"outlook_days"`
+}
+
+// ForecastConfig sets the hourly forecast columns and the daily forecast
+// strip. Hours are offsets from now, one column each; HourlyDetails lists
+// hourlyDetailNames to show under each column's temperature.
+type ForecastConfig struct {
+	Hours         []int    `yaml:"hours"`
+	HourlyDetails []string `yaml:"hourly_details"`
+	Days          int      `yaml:"days"`
+}
+
+type TelemetryConfig struct {
+	OTLPEndpoint       string `yaml:"otlp_endpoint"`
+	OTLPTracesEndpoint string `yaml:"otlp_traces_endpoint"`
+	ServiceName        string `yaml:"service_name"`
+}
+
+type DashboardConfig struct {
+	Location           Location       `yaml:"location"`
+	Panels             []string       `yaml:"panels"`
+	AutoRefreshSeconds int            `yaml:"auto_refresh_seconds"`
+	Forecast           ForecastConfig `yaml:"forecast"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		AutoRefreshSeconds: 1800,
+		Panels:             []string{"all"},
+		Location:           defaultLocation,
+		APIs: APIConfig{
+			WeatherProvider:      weatherProviderOpenWeather,
+			WeatherURL:           weatherAPIURLDefault,
+			OpenMeteoURL:         openMeteoAPIURLDefault,
+			NWSURL:               nwsAPIURLDefault,
+			NOAAURL:              noaaAPIURLDefault,
+			SpacedevsURL:         spacedevsAPIURLDefault,
+			SurfURL:              surfAPIURLDefault,
+			NHCURL:               nhcAPIURLDefault,
+			LaunchTimeoutSeconds: 2,
+		},
+		Cache: CacheConfig{
+			WeatherSeconds:         3600,
+			CleanupIntervalSeconds: 7200,
+			TideSeconds:            1800,
+			LaunchSeconds:          900,
+			SurfSeconds:            1800,
+			Prefetch:               true,
+			SnapshotSeconds:        300,
+		},
+		Tide: TideConfig{
+			WindowPastHours:      6,
+			WindowFutureHours:    30,
+			CurveInterval:        "6",
+			Observed:             true,
+			AnomalyThresholdFeet: 1.0,
+		},
+		Surf: SurfConfig{
+			OutlookDays: maxSurfOutlookDays,
+		},
+		Forecast: ForecastConfig{
+			Hours:         []int{2, 4, 6, 8},
+			HourlyDetails: []string{"description"},
+			Days:          5,
+		},
+		Telemetry: TelemetryConfig{
+			ServiceName: "kindle-weather",
+		},
+		Dashboards: map[string]DashboardConfig{},
+	}
+}
+
+// configErrors collects every problem found while loading the config so a
+// single --check-config run reports all of them.
+type configErrors []error
+
+func (e *configErrors) add(field, format string, args ...any) {
+	*e = append(*e, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (e configErrors) err() error {
+	return errors.Join(e...)
+}
+
+// loadConfig layers the defaults, the optional YAML file at path and the
+// environment, then validates the result.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	var errs configErrors
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("read config file: %w", err)
+		}
+		if err := decodeStrictYAML(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	applyEnv(&cfg, &errs)
+	resolveDashboards(&cfg, &errs)
+	validateConfig(cfg, &errs)
+
+	return cfg, errs.err()
+}
+
+func decodeStrictYAML(data []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// resolveDashboards turns the raw dashboard YAML and the DASHBOARDS variable
+// into dashboard configs. Each one starts as a copy of the top-level settings
+// and its own DASHBOARD_<NAME>_* variables are applied last.
+func resolveDashboards(cfg *Config, errs *configErrors) {
+	base := DashboardConfig{
+		Location:           cfg.Location,
+		Panels:             cfg.Panels,
+		AutoRefreshSeconds: cfg.AutoRefreshSeconds,
+		Forecast:           cfg.Forecast,
+	}
+
+	names := map[string]bool{}
+	for name := range cfg.RawDashboards {
+		names[name] = true
+	}
+	listed := map[string]bool{}
+	for _, name := range strings.Split(os.Getenv("DASHBOARDS"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "" {
+			continue
+		}
+		if listed[name] {
+			errs.add("DASHBOARDS", "dashboard %q is listed twice", name)
+		}
+		listed[name] = true
+		names[name] = true
+	}
+
+	cfg.Dashboards = map[string]DashboardConfig{}
+	for name := range names {
+		field := "dashboards." + name
+		if !dashboardNamePattern.MatchString(name) {
+			errs.add(field, "invalid dashboard name; use lowercase letters, digits, '-' and '_'")
+			continue
+		}
+
+		dashboard := base
+		dashboard.Panels = append([]string(nil), base.Panels...)
+		dashboard.Location.Surf = base.Location.Surf.clone()
+		dashboard.Forecast.Hours = append([]int(nil), base.Forecast.Hours...)
+		dashboard.Forecast.HourlyDetails = append([]string(nil), base.Forecast.HourlyDetails...)
+		if node, ok := cfg.RawDashboards[name]; ok {
+			data, err := yaml.Marshal(&node)
+			if err == nil {
+				err = decodeStrictYAML(data, &dashboard)
+			}
+			if err != nil {
+				errs.add(field, "%v", err)
+				continue
+			}
+		}
+
+		prefix := dashboardEnvPrefix(name)
+		applyLocationEnv(prefix, &dashboard.Location, errs)
+		envList(prefix+"PANELS", &dashboard.Panels)
+		envInt(errs, prefix+"AUTO_REFRESH_SECONDS", &dashboard.AutoRefreshSeconds)
+		applyForecastEnv(prefix, &dashboard.Forecast, errs)
+		cfg.Dashboards[name] = dashboard
+	}
+}
+
+func dashboardEnvPrefix(name string) string {
+	return "DASHBOARD_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+func applyEnv(cfg *Config, errs *configErrors) {
+	envInt(errs, "AUTO_REFRESH_SECONDS", &cfg.AutoRefreshSeconds)
+	envBool(errs, "ENABLE_ROCKET_PREVIEW", &cfg.EnableRocketPreview)
+	envList("PANELS", &cfg.Panels)
+	applyLocationEnv("", &cfg.Location, errs)
+
+	envString("WEATHER_PROVIDER", &cfg.APIs.WeatherProvider)
+	envList("WEATHER_FALLBACKS", &cfg.APIs.WeatherFallbacks)
+	envString("WEATHER_API_URL", &cfg.APIs.WeatherURL)
+	envString("OPENWEATHER_API_KEY", &cfg.APIs.OpenWeatherAPIKey)
+	envString("OPEN_METEO_API_URL", &cfg.APIs.OpenMeteoURL)
+	envString("NWS_API_URL", &cfg.APIs.NWSURL)
+	envString("NOAA_API_URL", &cfg.APIs.NOAAURL)
+	envString("SPACEDEVS_API_URL", &cfg.APIs.SpacedevsURL)
+	envString("SURF_API_URL", &cfg.APIs.SurfURL)
+	envString("NHC_API_URL", &cfg.APIs.NHCURL)
+	envInt(errs, "LAUNCH_API_TIMEOUT_SECONDS", &cfg.APIs.LaunchTimeoutSeconds)
+
+	envInt(errs, "CACHE_EXPIRATION", &cfg.Cache.WeatherSeconds)
+	envInt(errs, "CACHE_CLEANUP_INTERVAL", &cfg.Cache.CleanupIntervalSeconds)
+	envInt(errs, "TIDE_CACHE_EXPIRATION", &cfg.Cache.TideSeconds)
+	envInt(errs, "LAUNCH_CACHE_EXPIRATION", &cfg.Cache.LaunchSeconds)
+	envInt(errs, "SURF_CACHE_EXPIRATION", &cfg.Cache.SurfSeconds)
+	envBool(errs, "CACHE_PREFETCH", &cfg.Cache.Prefetch)
+	envString("CACHE_DIR", &cfg.Cache.Dir)
+	envInt(errs, "CACHE_SNAPSHOT_SECONDS", &cfg.Cache.SnapshotSeconds)
+	envInt(errs, "TIDE_WINDOW_PAST_HOURS", &cfg.Tide.WindowPastHours)
+	envInt(errs, "TIDE_WINDOW_FUTURE_HOURS", &cfg.Tide.WindowFutureHours)
+	envString("TIDE_CURVE_INTERVAL", &cfg.Tide.CurveInterval)
+	envBool(errs, "TIDE_OBSERVED", &cfg.Tide.Observed)
+	envFloat(errs, "TIDE_ANOMALY_THRESHOLD_FEET", &cfg.Tide.AnomalyThresholdFeet)
+	envInt(errs, "SURF_OUTLOOK_DAYS", &cfg.Surf.OutlookDays)
+	applyForecastEnv("", &cfg.Forecast, errs)
+
+	envString("OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.Telemetry.OTLPEndpoint)
+	envString("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", &cfg.Telemetry.OTLPTracesEndpoint)
+	envString("OTEL_SERVICE_NAME", &cfg.Telemetry.ServiceName)
+}
+
+// applyLocationEnv overrides loc with the LOCATION_* variables, each name
+// prefixed with prefix (empty for the top-level location).
+func applyLocationEnv(prefix string, loc *Location, errs *configErrors) {
+	envString(prefix+"LOCATION_NAME", &loc.Name)
+	envFloat(errs, prefix+"LOCATION_LATITUDE", &loc.Latitude)
+	envFloat(errs, prefix+"LOCATION_LONGITUDE", &loc.Longitude)
+	envString(prefix+"LOCATION_TIMEZONE", &loc.Timezone)
+	envString(prefix+"NOAA_TIDE_STATION", &loc.TideStation)
+	envInt(errs, prefix+"LAUNCH_LOCATION_ID", &loc.LaunchLocationID)
+	envString(prefix+"NWS_ZONE", &loc.NWSZone)
+
+	surf := &loc.Surf
+	envFloat(errs, prefix+"SURF_MIN_WAVE_HEIGHT_FEET", &surf.MinWaveHeightFeet)
+	envFloat(errs, prefix+"SURF_MAX_WAVE_HEIGHT_FEET", &surf.MaxWaveHeightFeet)
+	envFloat(errs, prefix+"SURF_MIN_WAVE_PERIOD_SECONDS", &surf.MinWavePeriodSeconds)
+	envFloat(errs, prefix+"SURF_MAX_WAVE_PERIOD_SECONDS", &surf.MaxWavePeriodSeconds)
+	envFloat(errs, prefix+"SURF_MIN_SWELL_DIRECTION_DEGREES", &surf.MinSwellDirectionDegrees)
+	envFloat(errs, prefix+"SURF_MAX_SWELL_DIRECTION_DEGREES", &surf.MaxSwellDirectionDegrees)
+	envFloat(errs, prefix+"SURF_MIN_OFFSHORE_WIND_DEGREES", &surf.MinOffshoreWindDegrees)
+	envFloat(errs, prefix+"SURF_MAX_OFFSHORE_WIND_DEGREES", &surf.MaxOffshoreWindDegrees)
+	envFloat(errs, prefix+"SURF_LIGHT_WIND_MPH", &surf.LightWindMPH)
+	envFloat(errs, prefix+"SURF_MAX_WIND_MPH", &surf.MaxWindMPH)
+	envList(prefix+"SURF_TIDE_PHASES", &surf.TidePhases)
+	envOptionalFloat(errs, prefix+"SURF_MIN_TIDE_FEET", &surf.MinTideFeet)
+	envOptionalFloat(errs, prefix+"SURF_MAX_TIDE_FEET", &surf.MaxTideFeet)
+}
+
+// applyForecastEnv overrides forecast with the FORECAST_* variables, each
+// name prefixed with prefix (empty for the top-level forecast).
+func applyForecastEnv(prefix string, forecast *ForecastConfig, errs *configErrors) {
+	envIntList(errs, prefix+"FORECAST_HOURS", &forecast.Hours)
+	envList(prefix+"FORECAST_HOURLY_DETAILS", &forecast.HourlyDetails)
+	envInt(errs, prefix+"FORECAST_DAYS", &forecast.Days)
+}
+
+func envString(key string, target *string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*target = v
+	}
+}
+
+func envList(key string, target *[]string) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*target = list
+}
+
+// envInt parses a whole number; range checks happen in validateConfig so
+// file and environment values are held to the same rules.
+func envInt(errs *configErrors, key string, target *int) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		errs.add(key, "%q is not a whole number", v)
+		return
+	}
+	*target = n
+}
+
+func envIntList(errs *configErrors, key string, target *[]int) {
+	var items []string
+	envList(key, &items)
+	if items == nil {
+		return
+	}
+	list := make([]int, 0, len(items))
+	for _, item := range items {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			errs.add(key, "%q is not a whole number", item)
+			return
+		}
+		list = append(list, n)
+	}
+	*target = list
+}
+
+func envFloat(errs *configErrors, key string, target *float64) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		errs.add(key, "%q is not a number", v)
+		return
+	}
+	*target = f
+}
+
+// envOptionalFloat is envFloat for a setting where nil means unset.
+func envOptionalFloat(errs *configErrors, key string, target **float64) {
+	if strings.TrimSpace(os.Getenv(key)) == "" {
+		return
+	}
+	var f float64
+	if *target != nil {
+		f = **target
+	}
+	envFloat(errs, key, &f)
+	*target = &f
+}
+
+func envBool(errs *configErrors, key string, target *bool) {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch v {
+	case "":
+	case "1", "true", "yes", "on":
+		*target = true
+	case "0", "false", "no", "off":
+		*target = false
+	default:
+		errs.add(key, "%q is not a boolean", v)
+	}
+}
+
+func validateConfig(cfg Config, errs *configErrors) {
+	validatePositive(errs, "auto_refresh_seconds", cfg.AutoRefreshSeconds)
+	validatePanels(errs, "panels", cfg.Panels)
+	validateLocation(errs, "location", cfg.Location)
+
+	if _, err := newWeatherProvider(cfg.APIs.WeatherProvider, cfg.APIs); err != nil {
+		errs.add("apis.weather_provider", "%q is not one of %s", cfg.APIs.WeatherProvider, strings.Join(weatherProviderNames(), ", "))
+	}
+	seenProviders := map[string]bool{cfg.APIs.WeatherProvider: true}
+	for _, name := range cfg.APIs.WeatherFallbacks {
+		if _, err := newWeatherProvider(name, cfg.APIs); err != nil {
+			errs.add("apis.weather_fallbacks", "%q is not one of %s", name, strings.Join(weatherProviderNames(), ", "))
+		} else if seenProviders[name] {
+			errs.add("apis.weather_fallbacks", "%q is listed more than once", name)
+		}
+		seenProviders[name] = true
+	}
+	validateURL(errs, "apis.weather_url", cfg.APIs.WeatherURL)
+	validateURL(errs, "apis.open_meteo_url", cfg.APIs.OpenMeteoURL)
+	validateURL(errs, "apis.nws_url", cfg.APIs.NWSURL)
+	validateURL(errs, "apis.noaa_url", cfg.APIs.NOAAURL)
+	validateURL(errs, "apis.spacedevs_url", cfg.APIs.SpacedevsURL)
+	validateURL(errs, "apis.surf_url", cfg.APIs.SurfURL)
+	validateURL(errs, "apis.nhc_url", cfg.APIs.NHCURL)
+	validatePositive(errs, "apis.launch_timeout_seconds", cfg.APIs.LaunchTimeoutSeconds)
+
+	validatePositive(errs, "cache.weather_seconds", cfg.Cache.WeatherSeconds)
+	validatePositive(errs, "cache.cleanup_interval_seconds", cfg.Cache.CleanupIntervalSeconds)
+	validatePositive(errs, "cache.tide_seconds", cfg.Cache.TideSeconds)
+	validatePositive(errs, "cache.launch_seconds", cfg.Cache.LaunchSeconds)
+	validatePositive(errs, "cache.surf_seconds", cfg.Cache.SurfSeconds)
+	validatePositive(errs, "cache.snapshot_seconds", cfg.Cache.SnapshotSeconds)
+
+	if cfg.Tide.WindowPastHours < 0 || cfg.Tide.WindowPastHours > maxTideWindowHours {
+		errs.add("tide.window_past_hours", "must be between 0 and %d, got %d", maxTideWindowHours, cfg.Tide.WindowPastHours)
+	}
+	if cfg.Tide.WindowFutureHours <= 0 || cfg.Tide.WindowFutureHours > maxTideWindowHours {
+		errs.add("tide.window_future_hours", "must be between 1 and %d, got %d", maxTideWindowHours, cfg.Tide.WindowFutureHours)
+	}
+	if !slices.Contains(tideCurveIntervals, cfg.Tide.CurveInterval) {
+		errs.add("tide.curve_interval", "must be one of %s, got %q", strings.Join(tideCurveIntervals, ", "), cfg.Tide.CurveInterval)
+	}
+	if cfg.Tide.AnomalyThresholdFeet <= 0 {
+		errs.add("tide.anomaly_threshold_feet", "must be greater than zero, got %g", cfg.Tide.AnomalyThresholdFeet)
+	}
+
+	if cfg.Surf.OutlookDays < minSurfOutlookDays || cfg.Surf.OutlookDays > maxSurfOutlookDays {
+		errs.add("surf.outlook_days", "must be between %d and %d, got %d", minSurfOutlookDays, maxSurfOutlookDays, cfg.Surf.OutlookDays)
+	}
+	validateForecast(errs, "forecast", cfg.Forecast)
+
+	if cfg.Telemetry.OTLPEndpoint != "" {
+		validateURL(errs, "telemetry.otlp_endpoint", cfg.Telemetry.OTLPEndpoint)
+	}
+	if cfg.Telemetry.OTLPTracesEndpoint != "" {
+		validateURL(errs, "telemetry.otlp_traces_endpoint", cfg.Telemetry.OTLPTracesEndpoint)
+	}
+
+	names := make([]string, 0, len(cfg.Dashboards))
+	for name := range cfg.Dashboards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dashboard := cfg.Dashboards[name]
+		field := "dashboards." + name
+		validatePositive(errs, field+".auto_refresh_seconds", dashboard.AutoRefreshSeconds)
+		validatePanels(errs, field+".panels", dashboard.Panels)
+		validateLocation(errs, field+".location", dashboard.Location)
+		validateForecast(errs, field+".forecast", dashboard.Forecast)
+	}
+}
+
+func validatePositive(errs *configErrors, field string, value int) {
+	if value <= 0 {
+		errs.add(field, "must be greater than zero, got %d", value)
+	}
+}
+
+func validatePanels(errs *configErrors, field string, panels []string) {
+	if _, err := parsePanels(panels); err != nil {
+		errs.add(field, "%v", err)
+	}
+}
+
+func validateURL(errs *configErrors, field, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(field, "%q is not an http(s) URL", value)
+	}
+}
+
+func validateForecast(errs *configErrors, field string, forecast ForecastConfig) {
+	if len(forecast.Hours) == 0 || len(forecast.Hours) > maxForecastColumns {
+		errs.add(field+".hours", "must list 1 to %d hours, got %d", maxForecastColumns, len(forecast.Hours))
+	}
+	for i, hour := range forecast.Hours {
+		if hour < 0 || hour > maxForecastHourOffset {
+			errs.add(field+".hours", "must be between 0 and %d, got %d", maxForecastHourOffset, hour)
+		} else if i > 0 && hour <= forecast.Hours[i-1] {
+			errs.add(field+".hours", "must be in increasing order, got %d after %d", hour, forecast.Hours[i-1])
+		}
+	}
+	for _, detail := range forecast.HourlyDetails {
+		if !slices.Contains(hourlyDetailNames, detail) {
+			errs.add(field+".hourly_details", "%q is not one of %s", detail, strings.Join(hourlyDetailNames, ", "))
+		}
+	}
+	if forecast.Days < minForecastDays || forecast.Days > maxForecastDays {
+		errs.add(field+".days", "must be between %d and %d, got %d", minForecastDays, maxForecastDays, forecast.Days)
+	}
+}
+
+func validateLocation(errs *configErrors, field string, loc Location) {
+	if loc.Latitude < -90 || loc.Latitude > 90 {
+		errs.add(field+".latitude", "%v is out of range [-90, 90]", loc.Latitude)
+	}
+	if loc.Longitude < -180 || loc.Longitude > 180 {
+		errs.add(field+".longitude", "%v is out of range [-180, 180]", loc.Longitude)
+	}
+	if _, err := time.LoadLocation(loc.Timezone); err != nil || loc.Timezone == "" {
+		errs.add(field+".timezone", "%q is not an IANA timezone", loc.Timezone)
+	}
+	if strings.TrimSpace(loc.TideStation) == "" {
+		errs.add(field+".tide_station", "must not be empty")
+	}
+	if loc.LaunchLocationID < 0 {
+		errs.add(field+".launch_location_id", "must not be negative, got %d", loc.LaunchLocationID)
+	}
+	if loc.NWSZone != "" && !nwsZonePattern.MatchString(loc.NWSZone) {
+		errs.add(field+".nws_zone", "%q is not an NWS forecast zone like FLZ133", loc.NWSZone)
+	}
+	validateSurfProfile(errs, field+".surf", loc.Surf)
+}
+
+func validateSurfProfile(errs *configErrors, field string, p SurfProfile) {
+	if p.MinWaveHeightFeet < 0 {
+		errs.add(field+".min_wave_height_feet", "must not be negative, got %g", p.MinWaveHeightFeet)
+	}
+	if p.MaxWaveHeightFeet <= p.MinWaveHeightFeet {
+		errs.add(field+".max_wave_height_feet", "must be greater than min_wave_height_feet (%g), got %g", p.MinWaveHeightFeet, p.MaxWaveHeightFeet)
+	}
+	if p.MinWavePeriodSeconds < 0 {
+		errs.add(field+".min_wave_period_seconds", "must not be negative, got %g", p.MinWavePeriodSeconds)
+	}
+	if p.MaxWavePeriodSeconds != 0 && p.MaxWavePeriodSeconds <= p.MinWavePeriodSeconds {
+		errs.add(field+".max_wave_period_seconds", "must be 0 (no limit) or greater than min_wave_period_seconds (%g), got %g", p.MinWavePeriodSeconds, p.MaxWavePeriodSeconds)
+	}
+	for _, arc := range []struct {
+		name    string
+		degrees float64
+	}{
+		{"min_swell_direction_degrees", p.MinSwellDirectionDegrees},
+		{"max_swell_direction_degrees", p.MaxSwellDirectionDegrees},
+		{"min_offshore_wind_degrees", p.MinOffshoreWindDegrees},
+		{"max_offshore_wind_degrees", p.MaxOffshoreWindDegrees},
+	} {
+		if arc.degrees < 0 || arc.degrees > 360 {
+			errs.add(field+"."+arc.name, "%g is out of range [0, 360]", arc.degrees)
+		}
+	}
+	if p.LightWindMPH < 0 {
+		errs.add(field+".light_wind_mph", "must not be negative, got %g", p.LightWindMPH)
+	}
+	if p.MaxWindMPH < p.LightWindMPH {
+		errs.add(field+".max_wind_mph", "must be at least light_wind_mph (%g), got %g", p.LightWindMPH, p.MaxWindMPH)
+	}
+	for _, phase := range p.TidePhases {
+		if !slices.Contains(surfTidePhases, phase) {
+			errs.add(field+".tide_phases", "%q is not one of %s", phase, strings.Join(surfTidePhases, ", "))
+		}
+	}
+	if p.MinTideFeet != nil && p.MaxTideFeet != nil && *p.MaxTideFeet < *p.MinTideFeet {
+		errs.add(field+".max_tide_feet", "must be at least min_tide_feet (%g), got %g", *p.MinTideFeet, *p.MaxTideFeet)
+	}
+}
+
+func secondsDuration(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
+
+// configPath returns the config file named by --config, falling back to
+// CONFIG_FILE. An empty path means defaults and environment only.
+func configPath(args []string) string {
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v, ok := strings.CutPrefix(arg, "--config="); ok {
+			return v
+		}
+	}
+	return strings.TrimSpace(os.Getenv("CONFIG_FILE"))
+}
+
+// runCheckConfig implements --check-config: it loads the config the same
+// way the server would and prints every problem it finds.
+func runCheckConfig(args []string) int {
+	path := configPath(args)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "--") {
+		path = args[0]
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config is invalid:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		return 1
+	}
+
+	fmt.Printf("config is valid (%d named dashboards)\n", len(cfg.Dashboards))
+	return 0
+}
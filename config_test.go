@@ -0,0 +1,240 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_FromFile(t *testing.T) {
+	path := writeConfigFile(t, `
+auto_refresh_seconds: 600
+panels: [forecast, tide]
+location:
+  name: Ocean Beach
+  latitude: 37.76
+  longitude: -122.51
+  timezone: America/Los_Angeles
+  tide_station: "9414290"
+  launch_location_id: 11
+  surf:
+    min_swell_direction_degrees: 200
+    max_swell_direction_degrees: 330
+    min_offshore_wind_degrees: 20
+    max_offshore_wind_degrees: 140
+    tide_phases: [mid, rising]
+    min_tide_feet: 0.5
+apis:
+  weather_url: http://weather.test/onecall
+cache:
+  tide_seconds: 120
+forecast:
+  hours: [1, 2, 3]
+dashboards:
+  kids:
+    panels: [forecast, moon]
+    forecast:
+      days: 3
+    location:
+      name: Kids Room
+      surf:
+        max_wave_height_feet: 3
+        tide_phases: [low]
+        min_tide_feet: -1
+`)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.AutoRefreshSeconds != 600 || cfg.Location.Name != "Ocean Beach" || cfg.Location.TideStation != "9414290" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Cache.TideSeconds != 120 || cfg.Cache.WeatherSeconds != 3600 {
+		t.Fatalf("unexpected cache config: %+v", cfg.Cache)
+	}
+	if cfg.APIs.NOAAURL != noaaAPIURLDefault {
+		t.Fatalf("noaa url = %q; want default", cfg.APIs.NOAAURL)
+	}
+
+	kids, ok := cfg.Dashboards["kids"]
+	if !ok {
+		t.Fatal("expected kids dashboard")
+	}
+	if kids.Location.Name != "Kids Room" || kids.Location.Timezone != "America/Los_Angeles" || kids.Location.Latitude != 37.76 {
+		t.Fatalf("kids dashboard should override the name and inherit the rest: %+v", kids.Location)
+	}
+	if surf := kids.Location.Surf; surf.MaxWaveHeightFeet != 3 || surf.MinSwellDirectionDegrees != 200 || surf.MinWavePeriodSeconds != defaultSurfProfile.MinWavePeriodSeconds {
+		t.Fatalf("kids surf profile should override the height and inherit the rest: %+v", surf)
+	}
+	if surf := cfg.Location.Surf; strings.Join(surf.TidePhases, ",") != "mid,rising" || surf.MinTideFeet == nil || *surf.MinTideFeet != 0.5 {
+		t.Fatalf("kids dashboard should not change the default tide preference: %+v", surf)
+	}
+	if surf := kids.Location.Surf; strings.Join(surf.TidePhases, ",") != "low" || *surf.MinTideFeet != -1 || surf.MaxTideFeet != nil {
+		t.Fatalf("unexpected kids tide preference: %+v", surf)
+	}
+	if strings.Join(kids.Panels, ",") != "forecast,moon" || kids.AutoRefreshSeconds != 600 {
+		t.Fatalf("unexpected kids dashboard: %+v", kids)
+	}
+	if kids.Forecast.Days != 3 || !slices.Equal(kids.Forecast.Hours, []int{1, 2, 3}) || cfg.Forecast.Days != 5 {
+		t.Fatalf("kids forecast should override the days and inherit the rest: %+v (default %+v)", kids.Forecast, cfg.Forecast)
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, `
+auto_refresh_seconds: 600
+location:
+  name: Ocean Beach
+forecast:
+  hours: [2, 4, 6, 8]
+dashboards:
+  kids:
+    auto_refresh_seconds: 900
+`)
+	t.Setenv("FORECAST_HOURS", "1, 3, 6")
+	t.Setenv("AUTO_REFRESH_SECONDS", "60")
+	t.Setenv("LOCATION_NAME", "From Env")
+	t.Setenv("DASHBOARD_KIDS_AUTO_REFRESH_SECONDS", "120")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.AutoRefreshSeconds != 60 || cfg.Location.Name != "From Env" {
+		t.Fatalf("environment should override the file: %+v", cfg)
+	}
+	if !slices.Equal(cfg.Forecast.Hours, []int{1, 3, 6}) {
+		t.Fatalf("forecast hours = %v; want the environment's", cfg.Forecast.Hours)
+	}
+	if kids := cfg.Dashboards["kids"]; kids.AutoRefreshSeconds != 120 || kids.Location.Name != "From Env" {
+		t.Fatalf("unexpected kids dashboard: %+v", kids)
+	}
+}
+
+func TestLoadConfig_ReportsEveryInvalidField(t *testing.T) {
+	path := writeConfigFile(t, `
+auto_refresh_seconds: 0
+panels: [radar]
+location:
+  latitude: 91
+  timezone: Mars/Olympus_Mons
+  nws_zone: St. Johns
+  surf:
+    min_wave_height_feet: 4
+    max_wave_height_feet: 2
+    tide_phases: [slack]
+    min_tide_feet: 3
+    max_tide_feet: 1
+apis:
+  weather_provider: darksky
+  noaa_url: not a url
+tide:
+  curve_interval: "1"
+surf:
+  outlook_days: 7
+forecast:
+  hours: [2, 1, 60]
+  hourly_details: [humidity]
+  days: 10
+dashboards:
+  kids:
+    location:
+      longitude: -200
+`)
+	t.Setenv("TIDE_CACHE_EXPIRATION", "soon")
+	t.Setenv("FORECAST_DAYS", "soon")
+	t.Setenv("DASHBOARD_KIDS_SURF_MAX_OFFSHORE_WIND_DEGREES", "west")
+
+	_, err := loadConfig(path)
+	if err == nil {
+		t.Fatal("loadConfig() expected error")
+	}
+	for _, field := range []string{
+		"auto_refresh_seconds",
+		"panels",
+		"location.latitude",
+		"location.timezone",
+		"location.nws_zone",
+		"location.surf.max_wave_height_feet",
+		"location.surf.tide_phases",
+		"location.surf.max_tide_feet",
+		"DASHBOARD_KIDS_SURF_MAX_OFFSHORE_WIND_DEGREES",
+		"apis.weather_provider",
+		"apis.noaa_url",
+		"tide.curve_interval",
+		"surf.outlook_days",
+		"forecast.hours",
+		"forecast.hourly_details",
+		"forecast.days",
+		"dashboards.kids.location.longitude",
+		"TIDE_CACHE_EXPIRATION",
+		"FORECAST_DAYS",
+	} {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("error does not mention %s:\n%v", field, err)
+		}
+	}
+}
+
+func TestLoadConfig_RejectsUnknownFields(t *testing.T) {
+	path := writeConfigFile(t, "location:\n  lattitude: 30\n")
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "lattitude") {
+		t.Fatalf("loadConfig() error = %v; want unknown field error", err)
+	}
+
+	path = writeConfigFile(t, "dashboards:\n  kids:\n    panel: [moon]\n")
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "dashboards.kids") {
+		t.Fatalf("loadConfig() error = %v; want unknown dashboard field error", err)
+	}
+}
+
+func TestRunCheckConfig(t *testing.T) {
+	valid := writeConfigFile(t, "auto_refresh_seconds: 300\n")
+	if code := runCheckConfig([]string{valid}); code != 0 {
+		t.Fatalf("runCheckConfig(valid) = %d; want 0", code)
+	}
+
+	invalid := writeConfigFile(t, "auto_refresh_seconds: -1\n")
+	if code := runCheckConfig([]string{invalid}); code == 0 {
+		t.Fatal("runCheckConfig(invalid) = 0; want non-zero")
+	}
+
+	if code := runCheckConfig([]string{filepath.Join(t.TempDir(), "missing.yaml")}); code == 0 {
+		t.Fatal("runCheckConfig(missing) = 0; want non-zero")
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "/etc/kindle-weather.yaml")
+	if got := configPath(nil); got != "/etc/kindle-weather.yaml" {
+		t.Fatalf("configPath() = %q; want CONFIG_FILE", got)
+	}
+	if got := configPath([]string{"--config", "local.yaml"}); got != "local.yaml" {
+		t.Fatalf("configPath(--config) = %q", got)
+	}
+	if got := configPath([]string{"--config=other.yaml"}); got != "other.yaml" {
+		t.Fatalf("configPath(--config=) = %q", got)
+	}
+}
+
+func TestConfigExampleIsValid(t *testing.T) {
+	cfg, err := loadConfig("config.example.yaml")
+	if err != nil {
+		t.Fatalf("config.example.yaml is invalid: %v", err)
+	}
+	if _, ok := cfg.Dashboards["grandma"]; !ok {
+		t.Fatal("expected the example grandma dashboard")
+	}
+}
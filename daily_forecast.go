@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// The daily forecast strip covers minForecastDays to maxForecastDays days,
+// today first. Every provider forecasts at least a week.
+const (
+	minForecastDays = 3
+	maxForecastDays = 7
+)
+
+// ForecastDay is one day of the daily forecast strip, formatted for the page.
+// High and Low are "" when the provider has no figure for the day, as for
+// today once the NWS has dropped the daytime period. Date is local midnight
+// and Day keeps the provider's figures for the API.
+type ForecastDay struct {
+	Date  time.Time
+	Day   DailyWeather
+	Label string
+	Icon  string
+	High  string
+	Low   string
+	Pop   int
+	Wind  string
+}
+
+// getForecastDays formats the first days of the daily forecast, starting
+// with today in tz. Days already over are skipped, which happens when a
+// cached forecast outlives midnight.
+func getForecastDays(daily []DailyWeather, tz *time.Location, now time.Time, days int) []ForecastDay {
+	today := now.In(tz)
+	startOfToday := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, tz)
+
+	var result []ForecastDay
+	for _, day := range daily {
+		if len(result) == days {
+			break
+		}
+		date := time.Unix(day.Dt, 0).In(tz)
+		if date.Before(startOfToday) {
+			continue
+		}
+
+		label := date.Format("Mon")
+		if date.Before(startOfToday.AddDate(0, 0, 1)) {
+			label = "Today"
+		}
+		forecast := ForecastDay{
+			Date:  date,
+			Day:   day,
+			Label: label,
+			High:  formatDailyTemp(day.Temp.Max),
+			Low:   formatDailyTemp(day.Temp.Min),
+			Pop:   int(day.Pop),
+			Wind:  "Calm",
+		}
+		if math.Round(day.WindSpeed) > 0 {
+			forecast.Wind = fmt.Sprintf("%s %.0f", compassPoint(float64(day.WindDeg)), day.WindSpeed)
+		}
+		if len(day.Weather) > 0 {
+			forecast.Icon = getIconClassName(day.Weather[0].Icon, day.Weather[0].ID)
+		}
+		result = append(result, forecast)
+	}
+	return result
+}
+
+func formatDailyTemp(temp *float64) string {
+	if temp == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.0f°", *temp)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestGetForecastDays(t *testing.T) {
+	tz := time.FixedZone("EDT", -4*3600)
+	temp := func(v float64) *float64 { return &v }
+	day := func(date int, high *float64) DailyWeather {
+		return DailyWeather{
+			Dt:        time.Date(2024, time.June, date, 12, 0, 0, 0, tz).Unix(),
+			Temp:      DailyTemp{Min: temp(74), Max: high},
+			Pop:       40,
+			WindSpeed: 12.4,
+			WindDeg:   225,
+			Weather:   []WeatherCondition{{Icon: "10d", ID: 500}},
+		}
+	}
+	daily := []DailyWeather{day(20, temp(87)), day(21, nil), day(22, temp(90)), day(23, temp(91)), day(24, temp(89))}
+	daily[2].WindSpeed = 0.4
+
+	days := getForecastDays(daily, tz, time.Date(2024, time.June, 21, 19, 0, 0, 0, tz), 3)
+	if len(days) != 3 {
+		t.Fatalf("got %d days; want 3 starting today: %+v", len(days), days)
+	}
+	today := days[0]
+	if today.Label != "Today" || today.High != "" || today.Low != "74°" || today.Pop != 40 || today.Wind != "SW 12" {
+		t.Fatalf("today = %+v", today)
+	}
+	if today.Icon != "wi wi-owm-day-500" {
+		t.Fatalf("icon = %q", today.Icon)
+	}
+	if days[1].Label != "Sat" || days[1].High != "90°" || days[1].Wind != "Calm" {
+		t.Fatalf("tomorrow = %+v", days[1])
+	}
+	if days[2].Label != "Sun" {
+		t.Fatalf("last day = %+v; want Sun", days[2])
+	}
+}
+
+func TestBuildDashboardPage_DailyStripLengthIsPerDashboard(t *testing.T) {
+	oldCache := weatherCache
+	defer func() { weatherCache = oldCache }()
+
+	weatherCache = cache.New(time.Hour, time.Hour)
+	tz := defaultLocation.timeLocation()
+	today := time.Now().In(tz)
+	weather := WeatherData{Timezone: defaultLocation.Timezone}
+	for i := range maxForecastDays + 1 {
+		noon := time.Date(today.Year(), today.Month(), today.Day()+i, 12, 0, 0, 0, tz)
+		weather.Daily = append(weather.Daily, DailyWeather{Dt: noon.Unix()})
+	}
+	weatherCache.Set(weatherCacheKey(defaultLocation), weather, cache.DefaultExpiration)
+
+	// Both dashboards read the one cached forecast for the coordinates.
+	for _, days := range []int{3, 7} {
+		dashboard := Dashboard{Location: defaultLocation, Panels: Panels{Daily: true}, AutoRefresh: time.Hour, ForecastDays: days}
+		page, err := buildDashboardPage(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil), dashboard)
+		if err != nil {
+			t.Fatalf("buildDashboardPage() error = %v", err)
+		}
+		if len(page.ForecastDays) != days {
+			t.Fatalf("got %d days; want the dashboard's %d", len(page.ForecastDays), days)
+		}
+	}
+}
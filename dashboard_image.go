@@ -0,0 +1,1237 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+const (
+	weatherIconFontPath     = "font/weathericons-regular-webfont.ttf"
+	weatherIconCSSPath      = "css/weather-icons.min.css"
+	defaultKindleImageSize  = "758x1024"
+	tideChartViewBoxWidth   = 600.0
+	tideChartViewBoxHeight  = 95.0
+	portraitLayoutBasisPx   = 758.0
+	horizontalLayoutBasisPx = 1024.0
+	// alertFlagIcon is the warning flag the page shows before an NWS
+	// beach hazard or weather alert.
+	alertFlagIcon = "wi wi-small-craft-advisory"
+	stormIcon     = "wi wi-hurricane"
+	rainIcon      = "wi wi-raindrop"
+)
+
+// kindleImageSizes are the portrait framebuffer sizes of the Kindles we serve.
+var kindleImageSizes = map[string]image.Point{
+	"600x800":   {X: 600, Y: 800},
+	"758x1024":  {X: 758, Y: 1024},
+	"1072x1448": {X: 1072, Y: 1448},
+}
+
+var weatherIconGlyphPattern = regexp.MustCompile(`([^{}]+)\{content:"\\([0-9a-fA-F]+)"\}`)
+
+type imageFonts struct {
+	regular *opentype.Font
+	bold    *opentype.Font
+	icons   *opentype.Font
+	glyphs  map[string]rune
+}
+
+type imageFaceKey struct {
+	font *opentype.Font
+	size float64
+}
+
+var (
+	dashboardImageFontsOnce sync.Once
+	dashboardImageFonts     *imageFonts
+	dashboardImageFontsErr  error
+)
+
+// dashboardImageLayout mirrors the positions in css/kindle.css. Sizes are CSS
+// pixels at the layout basis width; tops and heights are fractions of the page.
+type dashboardImageLayout struct {
+	basisWidth       float64
+	iconTop          float64
+	iconSize         float64
+	tempSize         float64
+	descriptionTop   float64
+	descriptionSize  float64
+	descriptionMaxH  float64
+	statusTop        float64
+	statusSize       float64
+	stormTop         float64
+	stormSize        float64
+	launchTop        float64
+	launchSize       float64
+	launchIconSize   float64
+	forecastTop      float64
+	forecastHeight   float64
+	forecastInset    float64
+	forecastBorder   float64
+	forecastBottom   float64
+	forecastIconSize float64
+	forecastTempSize float64
+	forecastTextSize float64
+	// forecastBeside is the hourly forecast's right inset when the daily
+	// strip sits beside it; 0 keeps forecastInset.
+	forecastBeside float64
+	dailyTop       float64
+	dailyHeight    float64
+	dailyLeft      float64
+	dailyRight     float64
+	dailyBorder    float64
+	dailyBottom    float64
+	// dailyRows lists the days top to bottom instead of across the page.
+	dailyRows     bool
+	dailySize     float64
+	dailyIconSize float64
+	outlookTop    float64
+	outlookHeight float64
+	outlookInset  float64
+	outlookRule   bool
+	outlookSize   float64
+	tideTop       float64
+	tideHeight    float64
+	tideWidth     float64
+	footerBottom  float64
+	footerInset   float64
+	footerSize    float64
+	sourceLeft    float64
+	updatedTop    float64
+	updatedSize   float64
+}
+
+var portraitImageLayout = dashboardImageLayout{
+	basisWidth:       portraitLayoutBasisPx,
+	iconTop:          0.01,
+	iconSize:         128,
+	tempSize:         112,
+	descriptionTop:   0.24,
+	descriptionSize:  32,
+	descriptionMaxH:  0.17,
+	statusTop:        0.40,
+	statusSize:       21.6,
+	stormTop:         0.185,
+	stormSize:        21.6,
+	launchTop:        145.0 / 1024.0,
+	launchSize:       23.2,
+	launchIconSize:   28,
+	forecastTop:      0.45,
+	forecastHeight:   0.35,
+	forecastBorder:   5,
+	forecastBottom:   1,
+	forecastIconSize: 64,
+	forecastTempSize: 48,
+	forecastTextSize: 16,
+	dailyTop:         0.655,
+	dailyHeight:      0.075,
+	dailyBorder:      1,
+	dailySize:        13.6,
+	dailyIconSize:    20.8,
+	outlookTop:       0.73,
+	outlookHeight:    0.07,
+	outlookRule:      true,
+	outlookSize:      16,
+	tideTop:          0.82,
+	tideHeight:       95,
+	tideWidth:        0.92,
+	footerBottom:     0.01,
+	footerInset:      0.033,
+	footerSize:       32,
+	sourceLeft:       0.12,
+	updatedTop:       0.02,
+	updatedSize:      20.8,
+}
+
+var horizontalImageLayout = dashboardImageLayout{
+	basisWidth:       horizontalLayoutBasisPx,
+	iconTop:          0.04,
+	iconSize:         96,
+	tempSize:         88,
+	descriptionTop:   0.22,
+	descriptionSize:  24,
+	descriptionMaxH:  0.15,
+	statusTop:        0.38,
+	statusSize:       17.6,
+	stormTop:         0.13,
+	stormSize:        17.6,
+	launchTop:        0.18,
+	launchSize:       19.2,
+	launchIconSize:   24,
+	forecastTop:      0.44,
+	forecastHeight:   0.28,
+	forecastInset:    0.03,
+	forecastBorder:   3,
+	forecastBottom:   3,
+	forecastIconSize: 48,
+	forecastTempSize: 36.8,
+	forecastTextSize: 15.2,
+	forecastBeside:   0.40,
+	dailyTop:         0.44,
+	dailyHeight:      0.28,
+	dailyLeft:        0.62,
+	dailyRight:       0.03,
+	dailyBorder:      3,
+	dailyBottom:      3,
+	dailyRows:        true,
+	dailySize:        15.2,
+	dailyIconSize:    20.8,
+	outlookTop:       0.08,
+	outlookHeight:    0.03,
+	outlookInset:     0.20,
+	outlookSize:      15.2,
+	tideTop:          0.73,
+	tideHeight:       125,
+	tideWidth:        0.90,
+	footerBottom:     0.03,
+	footerInset:      0.05,
+	footerSize:       25.6,
+	sourceLeft:       0.11,
+	updatedTop:       0.03,
+	updatedSize:      17.6,
+}
+
+type imagePoint struct {
+	X, Y float64
+}
+
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+// imageCanvas draws the dashboard onto an 8-bit grayscale image. All drawing
+// is black on white, which is what the e-ink panel shows best. Font faces
+// are not safe for concurrent use, so each canvas keeps its own.
+type imageCanvas struct {
+	img   *image.Gray
+	fonts *imageFonts
+	faces map[imageFaceKey]font.Face
+	scale float64
+}
+
+func imageHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	size, err := parseKindleImageSize(r.URL.Query().Get("size"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := buildDashboardPage(r.Context(), r, dashboard)
+	if err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Error getting weather data: %v", err),
+		})
+		http.Error(w, "Could not get weather data", http.StatusInternalServerError)
+		return
+	}
+
+	img, err := renderDashboardImage(page, size)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not render image: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		http.Error(w, fmt.Sprintf("Could not encode image: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, _ = w.Write(buf.Bytes())
+}
+
+func parseKindleImageSize(value string) (image.Point, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = defaultKindleImageSize
+	}
+	size, ok := kindleImageSizes[value]
+	if !ok {
+		return image.Point{}, fmt.Errorf("unsupported image size %q", value)
+	}
+	return size, nil
+}
+
+// renderDashboardImage draws page at the given portrait framebuffer size. A
+// horizontal page is laid out landscape and rotated a quarter turn clockwise
+// so the result can be written straight to the portrait framebuffer.
+func renderDashboardImage(page dashboardPage, size image.Point) (*image.Gray, error) {
+	fonts, err := loadImageFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	layout := portraitImageLayout
+	canvasSize := size
+	if page.Horizontal {
+		layout = horizontalImageLayout
+		canvasSize = image.Point{X: size.Y, Y: size.X}
+	}
+
+	canvas := &imageCanvas{
+		img:   image.NewGray(image.Rect(0, 0, canvasSize.X, canvasSize.Y)),
+		fonts: fonts,
+		faces: map[imageFaceKey]font.Face{},
+		scale: float64(canvasSize.X) / layout.basisWidth,
+	}
+	draw.Draw(canvas.img, canvas.img.Bounds(), image.White, image.Point{}, draw.Src)
+	canvas.drawDashboard(page, layout)
+
+	if page.Horizontal {
+		return rotateGrayClockwise(canvas.img), nil
+	}
+	return canvas.img, nil
+}
+
+func (c *imageCanvas) drawDashboard(page dashboardPage, layout dashboardImageLayout) {
+	width := float64(c.img.Bounds().Dx())
+	height := float64(c.img.Bounds().Dy())
+	px := func(v float64) float64 { return v * c.scale }
+
+	// Current conditions
+	if len(page.Weather.Current.Weather) > 0 {
+		condition := page.Weather.Current.Weather[0]
+		iconSize := px(layout.iconSize)
+		c.drawIcon(getIconClassName(condition.Icon, condition.ID), width*0.05+iconSize/2, height*layout.iconTop+iconSize/2, iconSize)
+	}
+	c.drawText(formatImageNumber(page.Weather.Current.Temp), width*0.95, height*layout.iconTop, px(layout.tempSize), false, alignRight)
+
+	if page.WeatherUpdated != "" {
+		c.drawText(page.WeatherUpdated, width/2, height*layout.updatedTop, px(layout.updatedSize), true, alignCenter)
+	}
+
+	if len(page.Weather.Daily) > 0 {
+		c.drawWrappedText(page.Weather.Daily[0].Summary, width/2, height*layout.descriptionTop, width*0.90, height*layout.descriptionMaxH, px(layout.descriptionSize))
+	}
+
+	if page.BeachStatus != nil {
+		c.drawBeachStatus(page.BeachStatus, width/2, height*layout.statusTop, px(layout.statusSize))
+	}
+
+	if water := page.Station.WaterTemperature; page.Panels.Water && water != nil {
+		middle := height*layout.launchTop + px(layout.launchIconSize)/2
+		c.drawTextMiddle(fmt.Sprintf("Water %.0f°F", water.Value), width*0.05, middle, px(layout.launchSize), true, alignLeft)
+	}
+
+	if page.Panels.Storm && page.Storm != nil {
+		c.drawStorm(page.Storm, width/2, height*layout.stormTop, px(layout.stormSize))
+	}
+
+	if page.KennedyLaunch != nil {
+		c.drawLaunch(page.KennedyLaunch, width*0.95, height*layout.launchTop, px(layout.launchSize), px(layout.launchIconSize))
+	}
+
+	showDaily := page.Panels.Daily && len(page.ForecastDays) > 0
+	if page.Panels.Forecast {
+		c.drawForecast(page.ForecastHours, page.HourlyDetails, layout, showDaily, width, height)
+	}
+	if showDaily {
+		c.drawDailyForecast(page.ForecastDays, layout, width, height)
+	}
+	if page.Panels.Surf && len(page.SurfOutlook) > 0 {
+		c.drawSurfOutlook(page.SurfOutlook, layout, width, height)
+	}
+	if page.Panels.Tide {
+		c.drawTideChart(page.TideChart, width/2, height*layout.tideTop, width*layout.tideWidth, px(layout.tideHeight))
+	}
+
+	// Moon phase and sunrise/sunset footer
+	footerSize := px(layout.footerSize)
+	footerMiddle := height - height*layout.footerBottom - footerSize/2
+	if page.Panels.Moon {
+		c.drawIcon("wi "+page.MoonPhaseIcon, width*layout.footerInset+footerSize/2, footerMiddle, footerSize)
+	}
+	if page.WeatherSource != "" {
+		c.drawTextMiddle(page.WeatherSource, width*layout.sourceLeft, footerMiddle, footerSize*0.45, false, alignLeft)
+	}
+	if !page.Panels.Sun {
+		return
+	}
+
+	sun := page.Weather.Current
+	x := width - width*layout.footerInset
+	x = c.drawTextMiddle(sun.SunsetFormatted, x, footerMiddle, footerSize, false, alignRight)
+	x = c.drawIconRightAligned("wi wi-sunset", x-footerSize*0.25, footerMiddle, footerSize)
+	x = c.drawTextMiddle(sun.SunriseFormatted, x-footerSize*0.5, footerMiddle, footerSize, false, alignRight)
+	c.drawIconRightAligned("wi wi-sunrise", x-footerSize*0.25, footerMiddle, footerSize)
+}
+
+func (c *imageCanvas) drawBeachStatus(status *BeachStatus, centerX, top, size float64) {
+	iconWidth, iconHeight, gap := 0.0, 0.0, 0.0
+	switch status.Kind {
+	case "surf":
+		iconWidth, iconHeight, gap = size*38/21.6, size*24/21.6, size*8/21.6
+	case "hazard", "warning", "alert":
+		if iconWidth = c.iconWidth(alertFlagIcon, size); iconWidth > 0 {
+			gap = size * 8 / 21.6
+		}
+	}
+	textWidth := c.measureText(status.Text, size, true)
+	left := centerX - (iconWidth+gap+textWidth)/2
+	middle := top + size/2
+
+	switch status.Kind {
+	case "surf":
+		c.drawSurfboard(left, middle-iconHeight/2, iconWidth, iconHeight)
+	case "hazard", "warning", "alert":
+		c.drawIconRightAligned(alertFlagIcon, left+iconWidth, middle, size)
+	}
+	c.drawTextMiddle(status.Text, left+iconWidth+gap, middle, size, true, alignLeft)
+
+	// A warning is a white-on-black banner, as on the page.
+	if status.Kind == "warning" {
+		pad := size * 0.4
+		c.invertRect(left-pad, top-pad, left+iconWidth+gap+textWidth+pad, top+size+pad)
+	}
+}
+
+func (c *imageCanvas) drawStorm(storm *NearbyStorm, centerX, top, size float64) {
+	text := storm.Text()
+	iconWidth, gap := c.iconWidth(stormIcon, size), size*8/21.6
+	left := centerX - (iconWidth+gap+c.measureText(text, size, true))/2
+	middle := top + size/2
+	c.drawIconRightAligned(stormIcon, left+iconWidth, middle, size)
+	c.drawTextMiddle(text, left+iconWidth+gap, middle, size, true, alignLeft)
+}
+
+func (c *imageCanvas) drawLaunch(launch *LaunchInfo, right, top, size, iconSize float64) {
+	middle := top + iconSize/2
+	x := right
+	if launch.Scheduled != "" {
+		x = c.drawTextMiddle(launch.Scheduled, right, middle, size, true, alignRight) - iconSize*8/28
+	}
+	c.drawRocket(x-iconSize, top, iconSize)
+}
+
+func (c *imageCanvas) drawForecast(hours []HourlyWeather, details HourlyDetails, layout dashboardImageLayout, besideDaily bool, width, height float64) {
+	left := width * layout.forecastInset
+	right := width - width*layout.forecastInset
+	if besideDaily && layout.forecastBeside > 0 {
+		right = width - width*layout.forecastBeside
+	}
+	top := height * layout.forecastTop
+	bottom := top + height*layout.forecastHeight
+	border := math.Max(1, math.Round(layout.forecastBorder*c.scale))
+	bottomBorder := math.Max(1, math.Round(layout.forecastBottom*c.scale))
+
+	c.fillRect(left, top, right, top+border)
+	c.fillRect(left, bottom-bottomBorder, right, bottom)
+
+	if len(hours) == 0 {
+		return
+	}
+	columnWidth := (right - left) / float64(len(hours))
+	for i := 1; i < len(hours); i++ {
+		x := math.Round(left + float64(i)*columnWidth)
+		c.fillRect(x-1, top+border, x, bottom-bottomBorder)
+	}
+
+	textSize := layout.forecastTextSize * c.scale
+	iconSize := layout.forecastIconSize * c.scale
+	tempSize := layout.forecastTempSize * c.scale
+	if details.Metrics() {
+		// Smaller icons make room for the metrics row, as .with-details does.
+		iconSize *= 0.75
+	}
+	for i, hour := range hours {
+		centerX := left + columnWidth*(float64(i)+0.5)
+		y := top + border + columnWidth*0.05
+		c.drawText(hour.DtFormatted, centerX, y, textSize, false, alignCenter)
+		y += textSize*1.2 + 5*c.scale
+		if len(hour.Weather) > 0 {
+			c.drawIcon(getIconClassName(hour.Weather[0].Icon, hour.Weather[0].ID), centerX, y+iconSize/2, iconSize)
+		}
+		y += iconSize + 2*c.scale
+		c.drawText(formatImageNumber(hour.Temp), centerX, y, tempSize, false, alignCenter)
+		y += tempSize * 1.2
+		if details.Description && len(hour.Weather) > 0 {
+			maxHeight := bottom - bottomBorder - y
+			if details.Metrics() {
+				maxHeight = textSize * 1.2
+			}
+			c.drawWrappedText(hour.Weather[0].Description, centerX, y, columnWidth*0.95, maxHeight, textSize)
+		}
+		if details.Description {
+			y += textSize * 1.2
+		}
+		if details.Metrics() {
+			c.drawHourlyMetrics(hour, details, centerX, y, columnWidth*0.95, textSize)
+		}
+	}
+}
+
+// drawHourlyMetrics lays out the rain, wind and UV items like the wrapping
+// flex row in the CSS: as many to a line as fit, at most two lines, each
+// line centred.
+func (c *imageCanvas) drawHourlyMetrics(hour HourlyWeather, details HourlyDetails, centerX, top, maxWidth, size float64) {
+	type metric struct {
+		width float64
+		draw  func(x, middle float64)
+	}
+	space := c.measureText(" ", size, false)
+	withIcon := func(iconWidth float64, drawIcon func(x, middle float64), text string) metric {
+		textWidth := c.measureText(text, size, false)
+		return metric{width: iconWidth + space + textWidth, draw: func(x, middle float64) {
+			drawIcon(x, middle)
+			c.drawTextMiddle(text, x+iconWidth+space, middle, size, false, alignLeft)
+		}}
+	}
+
+	var metrics []metric
+	if details.Rain {
+		drop := c.iconWidth(rainIcon, size)
+		metrics = append(metrics, withIcon(drop, func(x, middle float64) {
+			c.drawIconRightAligned(rainIcon, x+drop, middle, size)
+		}, fmt.Sprintf("%.0f%%", hour.Pop)))
+	}
+	if details.Wind {
+		arrow := size * 0.9
+		metrics = append(metrics, withIcon(arrow, func(x, middle float64) {
+			c.fillPolygons([][]imagePoint{rotatedWindArrow(hour.WindArrowDeg(), x+arrow/2, middle, arrow)})
+		}, fmt.Sprintf("%.0f", hour.WindSpeed)))
+	}
+	if details.UV {
+		text := fmt.Sprintf("UV %.0f", hour.Uvi)
+		metrics = append(metrics, metric{width: c.measureText(text, size, false), draw: func(x, middle float64) {
+			c.drawTextMiddle(text, x, middle, size, false, alignLeft)
+		}})
+	}
+
+	gap := 8 * c.scale
+	var lines [][]metric
+	lineWidth := 0.0
+	for _, m := range metrics {
+		if n := len(lines); n > 0 && lineWidth+gap+m.width <= maxWidth {
+			lines[n-1] = append(lines[n-1], m)
+			lineWidth += gap + m.width
+			continue
+		}
+		lines = append(lines, []metric{m})
+		lineWidth = m.width
+	}
+
+	lineHeight := size * 1.2
+	for i, line := range lines {
+		if i == 2 {
+			break
+		}
+		width := gap * float64(len(line)-1)
+		for _, m := range line {
+			width += m.width
+		}
+		x := centerX - width/2
+		middle := top + lineHeight*(float64(i)+0.5)
+		for _, m := range line {
+			m.draw(x, middle)
+			x += m.width + gap
+		}
+	}
+}
+
+// drawDailyForecast mirrors #daily-forecast: on a portrait page each day is
+// a centred stack of label and icon, temperatures and rain and wind; on a
+// horizontal page each day is a row with the same three parts in columns.
+func (c *imageCanvas) drawDailyForecast(days []ForecastDay, layout dashboardImageLayout, width, height float64) {
+	left := width * layout.dailyLeft
+	right := width - width*layout.dailyRight
+	top := height * layout.dailyTop
+	bottom := top + height*layout.dailyHeight
+	c.fillPolygonsColor([][]imagePoint{{{X: left, Y: top}, {X: right, Y: top}, {X: right, Y: bottom}, {X: left, Y: bottom}}}, color.White)
+	border := math.Max(1, math.Round(layout.dailyBorder*c.scale))
+	c.fillRect(left, top, right, top+border)
+	if layout.dailyBottom > 0 {
+		bottomBorder := math.Max(1, math.Round(layout.dailyBottom*c.scale))
+		c.fillRect(left, bottom-bottomBorder, right, bottom)
+		bottom -= bottomBorder
+	}
+	top += border
+
+	size := layout.dailySize * c.scale
+	iconSize := layout.dailyIconSize * c.scale
+	lineHeight := size * 1.15
+	headHeight := math.Max(lineHeight, iconSize)
+
+	// drawHead draws the bold label with the icon a space after it.
+	drawHead := func(day ForecastDay, x, middle float64, align textAlign) {
+		labelWidth := c.measureText(day.Label, size, true)
+		iconWidth, gap := 0.0, 0.0
+		if day.Icon != "" {
+			if iconWidth = c.iconWidth(day.Icon, iconSize); iconWidth > 0 {
+				gap = size * 0.3
+			}
+		}
+		if align == alignCenter {
+			x -= (labelWidth + gap + iconWidth) / 2
+		}
+		c.drawTextMiddle(day.Label, x, middle, size, true, alignLeft)
+		if iconWidth > 0 {
+			c.drawIconRightAligned(day.Icon, x+labelWidth+gap+iconWidth, middle, iconSize)
+		}
+	}
+
+	if layout.dailyRows {
+		slot := (bottom - top) / float64(len(days))
+		for i, day := range days {
+			middle := top + slot*(float64(i)+0.5)
+			drawHead(day, left, middle, alignLeft)
+			c.drawTextMiddle(dailyTemps(day), left+(right-left)*0.46, middle, size, false, alignCenter)
+			c.drawTextMiddle(dailyDetail(day), right, middle, size, false, alignRight)
+		}
+		return
+	}
+
+	slot := (right - left) / float64(len(days))
+	y := (top+bottom)/2 - (headHeight+2*lineHeight)/2
+	for i, day := range days {
+		centerX := left + slot*(float64(i)+0.5)
+		drawHead(day, centerX, y+headHeight/2, alignCenter)
+		c.drawTextMiddle(dailyTemps(day), centerX, y+headHeight+lineHeight/2, size, false, alignCenter)
+		c.drawTextMiddle(dailyDetail(day), centerX, y+headHeight+lineHeight*1.5, size, false, alignCenter)
+	}
+}
+
+func dailyTemps(day ForecastDay) string {
+	return strings.TrimSpace(day.High + " " + day.Low)
+}
+
+func dailyDetail(day ForecastDay) string {
+	return fmt.Sprintf("%d%% · %s", day.Pop, day.Wind)
+}
+
+// drawSurfOutlook lays the days out like the flex row in the CSS: each day
+// centred in an equal share of the row, its label followed by rating dots.
+func (c *imageCanvas) drawSurfOutlook(days []SurfDay, layout dashboardImageLayout, width, height float64) {
+	left := width * layout.outlookInset
+	right := width - width*layout.outlookInset
+	top := height * layout.outlookTop
+	bottom := top + height*layout.outlookHeight
+	if layout.outlookRule {
+		c.fillPolygonsColor([][]imagePoint{{{X: left, Y: top}, {X: right, Y: top}, {X: right, Y: bottom}, {X: left, Y: bottom}}}, color.White)
+		c.fillRect(left, top, right, top+math.Max(1, math.Round(c.scale)))
+	}
+
+	size := layout.outlookSize * c.scale
+	dot, dotGap, labelGap := 9*c.scale, 3*c.scale, 6*c.scale
+	middle := (top + bottom) / 2
+	slot := (right - left) / float64(len(days))
+	for i, day := range days {
+		labelWidth := c.measureText(day.Label, size, true)
+		dayWidth := labelWidth + labelGap + float64(surfMaxRating)*(dot+dotGap)
+		x := left + slot*(float64(i)+0.5) - dayWidth/2
+		c.drawTextMiddle(day.Label, x, middle, size, true, alignLeft)
+		x += labelWidth + labelGap
+		for _, on := range day.Dots() {
+			center := imagePoint{X: x + dotGap + dot/2, Y: middle}
+			if on {
+				c.fillPolygons([][]imagePoint{circlePolygon(center, dot/2)})
+			} else {
+				c.strokePolyline(circlePolygon(center, dot/2-c.scale), 2*c.scale, true)
+			}
+			x += dot + dotGap
+		}
+	}
+}
+
+// drawTideChart renders the same geometry as generateTideSVG, scaled into the
+// box the way a browser fits the SVG viewBox.
+func (c *imageCanvas) drawTideChart(chart tideChart, centerX, top, boxWidth, boxHeight float64) {
+	k := math.Min(boxWidth/tideChartViewBoxWidth, boxHeight/tideChartViewBoxHeight)
+	originX := centerX - tideChartViewBoxWidth*k/2
+	originY := top + (boxHeight-tideChartViewBoxHeight*k)/2
+	at := func(x, y float64) imagePoint {
+		return imagePoint{X: originX + x*k, Y: originY + y*k}
+	}
+
+	if len(chart.Curve) == 0 {
+		for x := tideChartLeft; x < tideChartRight; x += 12 {
+			start := at(x, tideChartAxisY-1)
+			end := at(math.Min(x+6, tideChartRight), tideChartAxisY+1)
+			c.fillRect(start.X, start.Y, end.X, end.Y)
+		}
+		center := at(300, tideChartAxisY)
+		c.drawTextMiddle("Tide data unavailable", center.X, center.Y, 18*k, true, alignCenter)
+		return
+	}
+
+	axisStart := at(tideChartLeft, tideChartAxisY-0.75)
+	axisEnd := at(tideChartRight, tideChartAxisY+0.75)
+	c.fillRect(axisStart.X, axisStart.Y, axisEnd.X, axisEnd.Y)
+
+	for _, day := range chart.Days {
+		for y := 16.0; y < 62; y += 6 {
+			start := at(day.X-0.5, y)
+			end := at(day.X+0.5, math.Min(y+3, 62))
+			c.fillRect(start.X, start.Y, end.X, end.Y)
+		}
+		if day.ShowLabel {
+			label := at(day.X+3, 13)
+			c.drawTextBaseline(day.Label, label.X, label.Y, 13*k, false, alignLeft)
+		}
+	}
+
+	curve := make([]imagePoint, 0, len(chart.Curve))
+	for _, p := range chart.Curve {
+		curve = append(curve, at(p.X, p.Y))
+	}
+	c.strokePolyline(curve, 3*k, false)
+
+	observed := make([]imagePoint, 0, len(chart.Observed))
+	for _, p := range chart.Observed {
+		observed = append(observed, at(p.X, p.Y))
+	}
+	for _, dash := range dashPolyline(observed, 5*k, 3*k) {
+		c.strokePolyline(dash, 2*k, false)
+	}
+
+	for _, p := range chart.Points {
+		center := at(p.X, p.Y)
+		c.fillPolygons([][]imagePoint{circlePolygon(center, 5*k)})
+		if !p.ShowLabel {
+			continue
+		}
+		label := at(p.X, 78)
+		c.drawTextBaseline(p.Type+" "+p.Height, label.X, label.Y, 16*k, true, alignCenter)
+		timeLabel := at(p.X, 91)
+		c.drawTextBaseline(p.Time, timeLabel.X, timeLabel.Y, 15*k, true, alignCenter)
+	}
+
+	if now := chart.Now; now != nil {
+		lineTop := at(now.X-1, 16)
+		lineBottom := at(now.X+1, 62)
+		c.fillRect(lineTop.X, lineTop.Y, lineBottom.X, lineBottom.Y)
+		center := at(now.X, now.Y)
+		c.fillPolygonsColor([][]imagePoint{circlePolygon(center, 6*k)}, color.White)
+		c.strokePolyline(circlePolygon(center, 6*k), 2.5*k, true)
+		align := alignCenter
+		switch now.Anchor {
+		case "start":
+			align = alignLeft
+		case "end":
+			align = alignRight
+		}
+		label := at(now.X, 13)
+		c.drawTextBaseline(now.Label, label.X, label.Y, 15*k, true, align)
+	}
+}
+
+// drawRocket fills the rocket icon from templates/index.html.
+func (c *imageCanvas) drawRocket(left, top, size float64) {
+	k := size / 32
+	at := func(x, y float64) imagePoint { return imagePoint{X: left + x*k, Y: top + y*k} }
+
+	nose := []imagePoint{at(16, 2)}
+	nose = appendCubic(nose, at(16, 2), at(13, 5), at(12, 9.5), at(12, 12.5))
+	nose = append(nose, at(16, 16.5), at(20, 12.5))
+	nose = appendCubic(nose, at(20, 12.5), at(20, 9.5), at(19, 5), at(16, 2))
+
+	c.fillPolygons([][]imagePoint{nose})
+	c.fillPolygons([][]imagePoint{{at(12, 15), at(6, 21), at(9.5, 20.5), at(11, 24), at(12, 27), at(15, 21)}})
+	c.fillPolygons([][]imagePoint{{at(20, 15), at(23, 21), at(24, 18), at(27.5, 18.5), at(21.5, 12.5)}})
+	c.fillPolygons([][]imagePoint{{at(16, 17), at(13, 20), at(16, 30), at(19, 20)}})
+}
+
+// drawSurfboard strokes the surfboard icon from templates/index.html, tilted
+// the same -8 degrees as the CSS.
+func (c *imageCanvas) drawSurfboard(left, top, width, height float64) {
+	kx := width / 44
+	ky := height / 24
+	angle := -8 * math.Pi / 180
+	centerX := left + width/2
+	centerY := top + height/2
+	at := func(x, y float64) imagePoint {
+		dx := (x - 22) * kx
+		dy := (y - 12) * ky
+		return imagePoint{
+			X: centerX + dx*math.Cos(angle) - dy*math.Sin(angle),
+			Y: centerY + dx*math.Sin(angle) + dy*math.Cos(angle),
+		}
+	}
+
+	outline := []imagePoint{at(2, 10)}
+	outline = appendCubic(outline, at(2, 10), at(7, 3), at(16, 1), at(22, 1))
+	outline = appendCubic(outline, at(22, 1), at(28, 1), at(37, 3), at(42, 10))
+	outline = appendCubic(outline, at(42, 10), at(37, 17), at(28, 19), at(22, 19))
+	outline = appendCubic(outline, at(22, 19), at(16, 19), at(7, 17), at(2, 10))
+
+	stroke := 2 * kx
+	c.strokePolyline(outline[:len(outline)-1], stroke, true)
+	c.strokePolyline([]imagePoint{at(4, 10), at(40, 10)}, stroke, false)
+	c.strokePolyline([]imagePoint{at(29, 18), at(34, 23), at(34, 17)}, stroke, false)
+}
+
+func (c *imageCanvas) face(f *opentype.Font, size float64) font.Face {
+	key := imageFaceKey{font: f, size: math.Round(size*4) / 4}
+	if face, ok := c.faces[key]; ok {
+		return face
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: key.size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// NewFace only fails for invalid options, which key.size cannot produce.
+		panic(fmt.Sprintf("create font face: %v", err))
+	}
+	c.faces[key] = face
+	return face
+}
+
+func (c *imageCanvas) textFace(size float64, bold bool) font.Face {
+	if bold {
+		return c.face(c.fonts.bold, size)
+	}
+	return c.face(c.fonts.regular, size)
+}
+
+func (c *imageCanvas) measureText(text string, size float64, bold bool) float64 {
+	return fixedToFloat(font.MeasureString(c.textFace(size, bold), text))
+}
+
+// drawText draws a single line whose CSS line box (line-height 1.2) starts at top.
+func (c *imageCanvas) drawText(text string, x, top, size float64, bold bool, align textAlign) float64 {
+	face := c.textFace(size, bold)
+	metrics := face.Metrics()
+	ascent := fixedToFloat(metrics.Ascent)
+	descent := fixedToFloat(metrics.Descent)
+	baseline := top + (size*1.2-(ascent+descent))/2 + ascent
+	return c.drawTextBaseline(text, x, baseline, size, bold, align)
+}
+
+// drawTextMiddle draws a single line vertically centred on middle.
+func (c *imageCanvas) drawTextMiddle(text string, x, middle, size float64, bold bool, align textAlign) float64 {
+	face := c.textFace(size, bold)
+	metrics := face.Metrics()
+	baseline := middle + (fixedToFloat(metrics.Ascent)-fixedToFloat(metrics.Descent))/2
+	return c.drawTextBaseline(text, x, baseline, size, bold, align)
+}
+
+// drawTextBaseline draws text and returns the x coordinate of its left edge.
+func (c *imageCanvas) drawTextBaseline(text string, x, baseline, size float64, bold bool, align textAlign) float64 {
+	face := c.textFace(size, bold)
+	width := fixedToFloat(font.MeasureString(face, text))
+	switch align {
+	case alignCenter:
+		x -= width / 2
+	case alignRight:
+		x -= width
+	}
+	drawer := font.Drawer{
+		Dst:  c.img,
+		Src:  image.Black,
+		Face: face,
+		Dot:  fixed.Point26_6{X: floatToFixed(x), Y: floatToFixed(baseline)},
+	}
+	drawer.DrawString(text)
+	return x
+}
+
+// drawWrappedText word-wraps centred text into maxWidth, dropping lines that
+// would overflow maxHeight like the CSS overflow: hidden boxes do.
+func (c *imageCanvas) drawWrappedText(text string, centerX, top, maxWidth, maxHeight, size float64) {
+	lineHeight := size * 1.2
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && c.measureText(candidate, size, false) > maxWidth {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	for i, line := range lines {
+		if float64(i+1)*lineHeight > maxHeight {
+			break
+		}
+		c.drawText(line, centerX, top+float64(i)*lineHeight, size, false, alignCenter)
+	}
+}
+
+// drawIcon draws a Weather Icons glyph centred on (centerX, centerY). The
+// class is anything getIconClassName or getMoonPhaseIcon returns.
+func (c *imageCanvas) drawIcon(class string, centerX, centerY, size float64) {
+	glyph, face, ok := c.iconGlyph(class, size)
+	if !ok {
+		return
+	}
+	bounds, _ := font.BoundString(face, string(glyph))
+	x := centerX - fixedToFloat(bounds.Min.X+bounds.Max.X)/2
+	baseline := centerY - fixedToFloat(bounds.Min.Y+bounds.Max.Y)/2
+	drawer := font.Drawer{
+		Dst:  c.img,
+		Src:  image.Black,
+		Face: face,
+		Dot:  fixed.Point26_6{X: floatToFixed(x), Y: floatToFixed(baseline)},
+	}
+	drawer.DrawString(string(glyph))
+}
+
+// drawIconRightAligned draws an icon ending at right and returns its left edge.
+func (c *imageCanvas) drawIconRightAligned(class string, right, centerY, size float64) float64 {
+	glyph, face, ok := c.iconGlyph(class, size)
+	if !ok {
+		return right
+	}
+	bounds, _ := font.BoundString(face, string(glyph))
+	width := fixedToFloat(bounds.Max.X - bounds.Min.X)
+	c.drawIcon(class, right-width/2, centerY, size)
+	return right - width
+}
+
+// iconWidth is the inked width of an icon, or 0 when it cannot be drawn.
+func (c *imageCanvas) iconWidth(class string, size float64) float64 {
+	glyph, face, ok := c.iconGlyph(class, size)
+	if !ok {
+		return 0
+	}
+	bounds, _ := font.BoundString(face, string(glyph))
+	return fixedToFloat(bounds.Max.X - bounds.Min.X)
+}
+
+func (c *imageCanvas) iconGlyph(class string, size float64) (rune, font.Face, bool) {
+	if c.fonts.icons == nil {
+		return 0, nil, false
+	}
+	fields := strings.Fields(class)
+	if len(fields) == 0 {
+		return 0, nil, false
+	}
+	glyph, ok := c.fonts.glyphs[fields[len(fields)-1]]
+	if !ok {
+		return 0, nil, false
+	}
+	return glyph, c.face(c.fonts.icons, size), true
+}
+
+func (c *imageCanvas) fillRect(x0, y0, x1, y1 float64) {
+	rect := image.Rect(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1)))
+	draw.Draw(c.img, rect.Intersect(c.img.Bounds()), image.Black, image.Point{}, draw.Src)
+}
+
+// invertRect swaps black and white within a rectangle, so anything already
+// drawn there turns white on black.
+func (c *imageCanvas) invertRect(x0, y0, x1, y1 float64) {
+	rect := image.Rect(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1))).Intersect(c.img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := c.img.Pix[c.img.PixOffset(rect.Min.X, y):c.img.PixOffset(rect.Max.X, y)]
+		for i := range row {
+			row[i] = 255 - row[i]
+		}
+	}
+}
+
+// fillPolygons fills closed subpaths in black with the non-zero winding rule.
+func (c *imageCanvas) fillPolygons(subpaths [][]imagePoint) {
+	c.fillPolygonsColor(subpaths, color.Black)
+}
+
+// fillPolygonsColor fills closed subpaths with the non-zero winding rule. The
+// rasterizer is sized to the shapes' bounding box to keep small shapes cheap.
+func (c *imageCanvas) fillPolygonsColor(subpaths [][]imagePoint, fill color.Color) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, subpath := range subpaths {
+		for _, p := range subpath {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 0) {
+		return
+	}
+
+	bounds := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+	bounds = bounds.Intersect(c.img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	rasterizer := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	for _, subpath := range subpaths {
+		if len(subpath) < 3 {
+			continue
+		}
+		ox, oy := float64(bounds.Min.X), float64(bounds.Min.Y)
+		rasterizer.MoveTo(float32(subpath[0].X-ox), float32(subpath[0].Y-oy))
+		for _, p := range subpath[1:] {
+			rasterizer.LineTo(float32(p.X-ox), float32(p.Y-oy))
+		}
+		rasterizer.ClosePath()
+	}
+	rasterizer.Draw(c.img, bounds, image.NewUniform(fill), image.Point{})
+}
+
+// strokePolyline outlines a polyline with mitred joins and fills the result.
+// A closed polyline becomes an outer and an inner ring of opposite winding.
+func (c *imageCanvas) strokePolyline(points []imagePoint, width float64, closed bool) {
+	if len(points) < 2 {
+		return
+	}
+	half := width / 2
+	left := make([]imagePoint, len(points))
+	right := make([]imagePoint, len(points))
+	for i, p := range points {
+		var normal imagePoint
+		switch {
+		case closed || (i > 0 && i < len(points)-1):
+			prev := points[(i-1+len(points))%len(points)]
+			next := points[(i+1)%len(points)]
+			n1 := segmentNormal(prev, p)
+			n2 := segmentNormal(p, next)
+			normal = imagePoint{X: n1.X + n2.X, Y: n1.Y + n2.Y}
+			length := math.Hypot(normal.X, normal.Y)
+			if length < 1e-9 {
+				normal = n1
+				break
+			}
+			normal = imagePoint{X: normal.X / length, Y: normal.Y / length}
+			miter := 1 / math.Max(0.5, normal.X*n1.X+normal.Y*n1.Y)
+			normal = imagePoint{X: normal.X * miter, Y: normal.Y * miter}
+		case i == 0:
+			normal = segmentNormal(p, points[1])
+		default:
+			normal = segmentNormal(points[i-1], p)
+		}
+		left[i] = imagePoint{X: p.X + normal.X*half, Y: p.Y + normal.Y*half}
+		right[i] = imagePoint{X: p.X - normal.X*half, Y: p.Y - normal.Y*half}
+	}
+
+	if closed {
+		inner := make([]imagePoint, len(right))
+		for i := range right {
+			inner[len(right)-1-i] = right[i]
+		}
+		c.fillPolygons([][]imagePoint{left, inner})
+		return
+	}
+
+	outline := append([]imagePoint{}, left...)
+	for i := len(right) - 1; i >= 0; i-- {
+		outline = append(outline, right[i])
+	}
+	c.fillPolygons([][]imagePoint{outline})
+}
+
+// dashPolyline cuts a polyline into dashes of length dash separated by gap,
+// like an SVG stroke-dasharray.
+func dashPolyline(points []imagePoint, dash, gap float64) [][]imagePoint {
+	var dashes [][]imagePoint
+	var current []imagePoint
+	drawing, remaining := true, dash
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		length := math.Hypot(to.X-from.X, to.Y-from.Y)
+		for pos := 0.0; pos < length; {
+			step := math.Min(remaining, length-pos)
+			start := imagePoint{X: from.X + (to.X-from.X)*pos/length, Y: from.Y + (to.Y-from.Y)*pos/length}
+			pos += step
+			end := imagePoint{X: from.X + (to.X-from.X)*pos/length, Y: from.Y + (to.Y-from.Y)*pos/length}
+			if drawing {
+				if len(current) == 0 {
+					current = append(current, start)
+				}
+				current = append(current, end)
+			}
+			remaining -= step
+			if remaining <= 0 {
+				if drawing && len(current) > 1 {
+					dashes = append(dashes, current)
+				}
+				current = nil
+				drawing = !drawing
+				remaining = gap
+				if drawing {
+					remaining = dash
+				}
+			}
+		}
+	}
+	if drawing && len(current) > 1 {
+		dashes = append(dashes, current)
+	}
+	return dashes
+}
+
+func segmentNormal(from, to imagePoint) imagePoint {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := math.Hypot(dx, dy)
+	if length < 1e-9 {
+		return imagePoint{}
+	}
+	return imagePoint{X: -dy / length, Y: dx / length}
+}
+
+// appendCubic flattens a cubic bezier from p0 and appends everything after p0.
+func appendCubic(points []imagePoint, p0, p1, p2, p3 imagePoint) []imagePoint {
+	const steps = 24
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / steps
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		points = append(points, imagePoint{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+	return points
+}
+
+// windArrow is the wind arrow pointing north in a 16 by 16 box, as in the
+// page's SVG.
+var windArrow = []imagePoint{{X: 8, Y: 1}, {X: 13, Y: 14}, {X: 8, Y: 11}, {X: 3, Y: 14}}
+
+// rotatedWindArrow scales windArrow to size and turns it deg clockwise
+// about its centre at (centerX, centerY).
+func rotatedWindArrow(deg int, centerX, centerY, size float64) []imagePoint {
+	sin, cos := math.Sincos(float64(deg) * math.Pi / 180)
+	points := make([]imagePoint, len(windArrow))
+	for i, p := range windArrow {
+		x, y := (p.X-8)*size/16, (p.Y-8)*size/16
+		points[i] = imagePoint{X: centerX + x*cos - y*sin, Y: centerY + x*sin + y*cos}
+	}
+	return points
+}
+
+func circlePolygon(center imagePoint, radius float64) []imagePoint {
+	const steps = 24
+	points := make([]imagePoint, 0, steps)
+	for i := 0; i < steps; i++ {
+		angle := 2 * math.Pi * float64(i) / steps
+		points = append(points, imagePoint{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)})
+	}
+	return points
+}
+
+func rotateGrayClockwise(src *image.Gray) *image.Gray {
+	bounds := src.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dst.Pix[x*dst.Stride+(bounds.Dy()-1-y)] = src.Pix[y*src.Stride+x]
+		}
+	}
+	return dst
+}
+
+func formatImageNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
+
+func floatToFixed(v float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(v * 64))
+}
+
+// loadImageFonts parses the Go fonts once. The weather icon font and its CSS
+// glyph map are read from disk next to the static assets; when they are
+// missing the image is still rendered, just without icons.
+func loadImageFonts() (*imageFonts, error) {
+	dashboardImageFontsOnce.Do(func() {
+		regular, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			dashboardImageFontsErr = fmt.Errorf("parse regular font: %w", err)
+			return
+		}
+		bold, err := opentype.Parse(gobold.TTF)
+		if err != nil {
+			dashboardImageFontsErr = fmt.Errorf("parse bold font: %w", err)
+			return
+		}
+
+		fonts := &imageFonts{regular: regular, bold: bold}
+		icons, glyphs, err := loadWeatherIconFont(weatherIconFontPath, weatherIconCSSPath)
+		if err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Rendering dashboard images without weather icons: %v", err),
+			})
+		} else {
+			fonts.icons = icons
+			fonts.glyphs = glyphs
+		}
+		dashboardImageFonts = fonts
+	})
+	return dashboardImageFonts, dashboardImageFontsErr
+}
+
+func loadWeatherIconFont(fontPath, cssPath string) (*opentype.Font, map[string]rune, error) {
+	fontData, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read weather icon font: %w", err)
+	}
+	icons, err := opentype.Parse(fontData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse weather icon font: %w", err)
+	}
+	css, err := os.ReadFile(cssPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read weather icon CSS: %w", err)
+	}
+	return icons, parseWeatherIconGlyphs(string(css)), nil
+}
+
+// parseWeatherIconGlyphs maps icon class names to code points using the
+// ".wi-name:before{content:"\f00d"}" rules in the Weather Icons stylesheet.
+func parseWeatherIconGlyphs(css string) map[string]rune {
+	glyphs := map[string]rune{}
+	for _, match := range weatherIconGlyphPattern.FindAllStringSubmatch(css, -1) {
+		codePoint, err := strconv.ParseUint(match[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		for _, selector := range strings.Split(match[1], ",") {
+			selector = strings.TrimSpace(selector)
+			selector = strings.TrimPrefix(selector, ".")
+			selector = strings.TrimSuffix(selector, ":before")
+			if strings.HasPrefix(selector, "wi-") {
+				glyphs[selector] = rune(codePoint)
+			}
+		}
+	}
+	return glyphs
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKindleImageSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    image.Point
+		wantErr bool
+	}{
+		{value: "", want: image.Point{X: 758, Y: 1024}},
+		{value: "600x800", want: image.Point{X: 600, Y: 800}},
+		{value: "1072x1448", want: image.Point{X: 1072, Y: 1448}},
+		{value: "800x600", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseKindleImageSize(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("parseKindleImageSize(%q) expected error", tt.value)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("parseKindleImageSize(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestRenderDashboardImage_PortraitAndHorizontal(t *testing.T) {
+	page := testDashboardPage()
+
+	portrait, err := renderDashboardImage(page, image.Point{X: 600, Y: 800})
+	if err != nil {
+		t.Fatalf("renderDashboardImage() error = %v", err)
+	}
+	if got := portrait.Bounds().Size(); got != (image.Point{X: 600, Y: 800}) {
+		t.Fatalf("portrait size = %v; want 600x800", got)
+	}
+	if dark := darkPixelCount(portrait); dark == 0 {
+		t.Fatal("portrait image is blank")
+	}
+
+	page.Horizontal = true
+	horizontal, err := renderDashboardImage(page, image.Point{X: 600, Y: 800})
+	if err != nil {
+		t.Fatalf("renderDashboardImage() error = %v", err)
+	}
+	if got := horizontal.Bounds().Size(); got != (image.Point{X: 600, Y: 800}) {
+		t.Fatalf("horizontal image should be rotated back to the framebuffer size, got %v", got)
+	}
+	if darkPixelCount(horizontal) == 0 {
+		t.Fatal("horizontal image is blank")
+	}
+}
+
+func TestRotateGrayClockwise(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	src.Pix[0] = 10 // (0,0)
+	src.Pix[5] = 20 // (2,1)
+
+	dst := rotateGrayClockwise(src)
+	if got := dst.Bounds().Size(); got != (image.Point{X: 2, Y: 3}) {
+		t.Fatalf("rotated size = %v; want 2x3", got)
+	}
+	if got := dst.GrayAt(1, 0).Y; got != 10 {
+		t.Fatalf("top-left pixel should move to top-right, got %d", got)
+	}
+	if got := dst.GrayAt(0, 2).Y; got != 20 {
+		t.Fatalf("bottom-right pixel should move to bottom-left, got %d", got)
+	}
+}
+
+func TestParseWeatherIconGlyphs(t *testing.T) {
+	css, err := os.ReadFile(weatherIconCSSPath)
+	if err != nil {
+		t.Fatalf("read weather icon CSS: %v", err)
+	}
+	glyphs := parseWeatherIconGlyphs(string(css))
+
+	for class, want := range map[string]rune{
+		"wi-owm-day-800": 0xf00d,
+		"wi-night-clear": 0xf02e,
+		"wi-moon-full":   0xf0a3,
+		"wi-sunrise":     0xf051,
+		"wi-wmo4680-00":  0xf055,
+	} {
+		if got := glyphs[class]; got != want {
+			t.Errorf("glyph for %s = %#x; want %#x", class, got, want)
+		}
+	}
+}
+
+func TestImageHandler_RejectsUnknownSize(t *testing.T) {
+	rec := httptest.NewRecorder()
+	imageHandler(rec, httptest.NewRequest(http.MethodGet, "/image.png?size=1x1", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rec.Body.String(), "unsupported image size") {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func testDashboardPage() dashboardPage {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, time.April, 8, hour, minute, 0, 0, time.UTC)
+	}
+	tide := TideData{Predictions: []TidePrediction{
+		{Time: day(3, 17), Type: "L", Height: 0.1},
+		{Time: day(9, 24), Type: "H", Height: 4.2},
+		{Time: day(15, 41), Type: "L", Height: 0.3},
+		{Time: day(21, 58), Type: "H", Height: 4.0},
+		{Time: day(28, 5), Type: "L", Height: 0.2},
+		{Time: day(34, 12), Type: "H", Height: 4.1},
+		{Time: day(40, 30), Type: "L", Height: 0.4},
+	}}
+	// Observations running a foot over the prediction up to now.
+	var observed []TideLevel
+	for at := day(3, 30); !at.After(day(8, 0)); at = at.Add(6 * time.Minute) {
+		height, _, _ := tideHeightAt(tide.Predictions, at)
+		observed = append(observed, TideLevel{At: at, Height: height + 1})
+	}
+	return dashboardPage{
+		Panels: everyPanel,
+		Weather: WeatherData{
+			Current: CurrentWeather{
+				Temp:             72,
+				SunriseFormatted: "6:25 AM",
+				SunsetFormatted:  "8:17 PM",
+				Weather:          []WeatherCondition{{Icon: "01d", ID: 800}},
+			},
+			Daily: []DailyWeather{{Summary: "Clear skies through the afternoon with a light breeze"}},
+		},
+		Tide:      tide,
+		TideChart: buildTideChart(tide.Predictions, TideCurve{}, observed, day(8, 0)),
+		ForecastHours: []HourlyWeather{
+			{DtFormatted: "2:00 PM", Temp: 75, Weather: []WeatherCondition{{Icon: "02d", ID: 801, Description: "few clouds"}}},
+			{DtFormatted: "4:00 PM", Temp: 74, Weather: []WeatherCondition{{Icon: "10d", ID: 500, Description: "light rain"}}},
+		},
+		HourlyDetails: HourlyDetails{Description: true},
+		MoonPhaseIcon: "wi-moon-full",
+		KennedyLaunch: &LaunchInfo{Scheduled: "4:30pm"},
+		Station:       StationSensors{WaterTemperature: &SensorReading{At: day(7, 54), Value: 72.4}},
+		BeachStatus:   &BeachStatus{Kind: "surf", Text: "Best 7–10 AM, 3 ft @ 9 s, offshore"},
+		SurfOutlook: []SurfDay{
+			{Date: day(0, 0), Label: "Today", Rating: 4},
+			{Date: day(24, 0), Label: "Tue", Rating: 2},
+			{Date: day(48, 0), Label: "Wed", Rating: 3},
+			{Date: day(72, 0), Label: "Thu", Rating: 0, WavesOnly: true},
+			{Date: day(96, 0), Label: "Fri", Rating: 5, WavesOnly: true},
+		},
+	}
+}
+
+func darkPixelCount(img *image.Gray) int {
+	dark := 0
+	for _, v := range img.Pix {
+		if v < 128 {
+			dark++
+		}
+	}
+	return dark
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dashboard is one Kindle screen. The default dashboard is served at "/" and
+// named dashboards at "/d/{name}"; all of them share the HTTP clients and
+// caches, so dashboards pointing at the same place share upstream calls.
+type Dashboard struct {
+	Name        string
+	Location    Location
+	Panels      Panels
+	AutoRefresh time.Duration
+	// ForecastHours are the hourly forecast columns as offsets from now, and
+	// HourlyDetails what each column shows under its temperature.
+	ForecastHours []int
+	HourlyDetails HourlyDetails
+	// ForecastDays is the length of the daily forecast strip.
+	ForecastDays int
+}
+
+// Panels selects which optional parts of the page are shown. Current
+// conditions are always shown.
+type Panels struct {
+	Forecast bool
+	Tide     bool
+	Launch   bool
+	Beach    bool
+	Moon     bool
+	Sun      bool
+	// Water shows the tide station's water temperature.
+	Water bool
+	// Surf shows the multi-day surf outlook row.
+	Surf bool
+	// Storm shows the nearest active tropical cyclone, when there is one.
+	Storm bool
+	// Daily shows the multi-day forecast strip.
+	Daily bool
+}
+
+// allPanels is what "all" selects: the panels every page has always had.
+// Water, surf, storm and daily are shown only when listed, so a new panel
+// never changes an existing dashboard.
+var allPanels = Panels{Forecast: true, Tide: true, Launch: true, Beach: true, Moon: true, Sun: true}
+
+var dashboardNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var (
+	defaultDashboard Dashboard
+	dashboards       = map[string]Dashboard{}
+)
+
+// configureDashboards builds the default dashboard from the top-level
+// settings and one named dashboard per entry in cfg.Dashboards, which
+// loadConfig has already resolved against those settings.
+func configureDashboards(cfg Config) error {
+	panels, err := parsePanels(cfg.Panels)
+	if err != nil {
+		return err
+	}
+	defaultDashboard = Dashboard{
+		Location:      cfg.Location,
+		Panels:        panels,
+		AutoRefresh:   secondsDuration(cfg.AutoRefreshSeconds),
+		ForecastHours: cfg.Forecast.Hours,
+		HourlyDetails: newHourlyDetails(cfg.Forecast.HourlyDetails),
+		ForecastDays:  cfg.Forecast.Days,
+	}
+
+	dashboards = map[string]Dashboard{}
+	for name, dc := range cfg.Dashboards {
+		panels, err := parsePanels(dc.Panels)
+		if err != nil {
+			return fmt.Errorf("dashboard %q: %w", name, err)
+		}
+		dashboards[name] = Dashboard{
+			Name:          name,
+			Location:      dc.Location,
+			Panels:        panels,
+			AutoRefresh:   secondsDuration(dc.AutoRefreshSeconds),
+			ForecastHours: dc.Forecast.Hours,
+			HourlyDetails: newHourlyDetails(dc.Forecast.HourlyDetails),
+			ForecastDays:  dc.Forecast.Days,
+		}
+	}
+	return nil
+}
+
+// parsePanels turns a panel list such as ["tide", "moon", "sun"] into Panels.
+func parsePanels(names []string) (Panels, error) {
+	var panels Panels
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "all":
+			panels = panels.union(allPanels)
+		case "forecast":
+			panels.Forecast = true
+		case "tide":
+			panels.Tide = true
+		case "launch":
+			panels.Launch = true
+		case "beach":
+			panels.Beach = true
+		case "moon":
+			panels.Moon = true
+		case "sun":
+			panels.Sun = true
+		case "water":
+			panels.Water = true
+		case "surf":
+			panels.Surf = true
+		case "storm":
+			panels.Storm = true
+		case "daily":
+			panels.Daily = true
+		default:
+			return Panels{}, fmt.Errorf("unknown panel %q", strings.TrimSpace(name))
+		}
+	}
+	return panels, nil
+}
+
+// union returns the panels shown by either p or other.
+func (p Panels) union(other Panels) Panels {
+	return Panels{
+		Forecast: p.Forecast || other.Forecast,
+		Tide:     p.Tide || other.Tide,
+		Launch:   p.Launch || other.Launch,
+		Beach:    p.Beach || other.Beach,
+		Moon:     p.Moon || other.Moon,
+		Sun:      p.Sun || other.Sun,
+		Water:    p.Water || other.Water,
+		Surf:     p.Surf || other.Surf,
+		Storm:    p.Storm || other.Storm,
+		Daily:    p.Daily || other.Daily,
+	}
+}
+
+// allDashboards returns the default dashboard followed by the named ones in
+// name order.
+func allDashboards() []Dashboard {
+	names := make([]string, 0, len(dashboards))
+	for name := range dashboards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []Dashboard{defaultDashboard}
+	for _, name := range names {
+		result = append(result, dashboards[name])
+	}
+	return result
+}
+
+// dashboardForRequest resolves the dashboard named in the /d/{name} path, or
+// the default dashboard for every other route.
+func dashboardForRequest(r *http.Request) (Dashboard, bool) {
+	name := r.PathValue("name")
+	if name == "" {
+		return defaultDashboard, true
+	}
+	dashboard, ok := dashboards[strings.ToLower(name)]
+	return dashboard, ok
+}
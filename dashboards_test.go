@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// everyPanel adds the opt-in panels to allPanels.
+var everyPanel = allPanels.union(Panels{Water: true, Surf: true, Storm: true, Daily: true})
+
+func TestParsePanels(t *testing.T) {
+	panels, err := parsePanels([]string{"tide", " Moon", "sun", "surf"})
+	if err != nil {
+		t.Fatalf("parsePanels() error = %v", err)
+	}
+	want := Panels{Tide: true, Moon: true, Sun: true, Surf: true}
+	if panels != want {
+		t.Fatalf("parsePanels() = %+v; want %+v", panels, want)
+	}
+
+	if panels, err := parsePanels([]string{"all"}); err != nil || panels != allPanels {
+		t.Fatalf("parsePanels(all) = %+v, %v; want all panels", panels, err)
+	}
+	want = allPanels
+	want.Surf, want.Storm = true, true
+	if panels, err := parsePanels([]string{"surf", "all", "storm"}); err != nil || panels != want {
+		t.Fatalf("parsePanels(surf, all, storm) = %+v, %v; want %+v", panels, err, want)
+	}
+	if _, err := parsePanels([]string{"tide", "radar"}); err == nil {
+		t.Fatal("parsePanels() expected error for unknown panel")
+	}
+}
+
+func TestConfigureDashboards(t *testing.T) {
+	oldDefault, oldDashboards := defaultDashboard, dashboards
+	defer func() { defaultDashboard, dashboards = oldDefault, oldDashboards }()
+
+	t.Setenv("DASHBOARDS", "grandma, beach-house")
+	t.Setenv("DASHBOARD_GRANDMA_LOCATION_NAME", "Asheville")
+	t.Setenv("DASHBOARD_GRANDMA_LOCATION_LATITUDE", "35.6")
+	t.Setenv("DASHBOARD_GRANDMA_LOCATION_LONGITUDE", "-82.55")
+	t.Setenv("DASHBOARD_GRANDMA_PANELS", "forecast,moon,sun")
+	t.Setenv("DASHBOARD_GRANDMA_AUTO_REFRESH_SECONDS", "3600")
+	t.Setenv("DASHBOARD_GRANDMA_FORECAST_HOURS", "1,3")
+	t.Setenv("DASHBOARD_GRANDMA_FORECAST_HOURLY_DETAILS", "rain,wind")
+	t.Setenv("DASHBOARD_GRANDMA_FORECAST_DAYS", "3")
+	t.Setenv("DASHBOARD_BEACH_HOUSE_NOAA_TIDE_STATION", "8720587")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if err := configureDashboards(cfg); err != nil {
+		t.Fatalf("configureDashboards() error = %v", err)
+	}
+
+	grandma, ok := dashboards["grandma"]
+	if !ok {
+		t.Fatal("expected grandma dashboard")
+	}
+	if grandma.Location.Name != "Asheville" || grandma.Location.Latitude != 35.6 || grandma.Location.Longitude != -82.55 {
+		t.Fatalf("unexpected grandma location: %+v", grandma.Location)
+	}
+	if grandma.Location.Timezone != defaultLocation.Timezone {
+		t.Fatalf("grandma should inherit the default timezone, got %q", grandma.Location.Timezone)
+	}
+	if grandma.Panels != (Panels{Forecast: true, Moon: true, Sun: true}) {
+		t.Fatalf("unexpected grandma panels: %+v", grandma.Panels)
+	}
+	if grandma.AutoRefresh != time.Hour {
+		t.Fatalf("grandma auto refresh = %v; want 1h", grandma.AutoRefresh)
+	}
+	if !slices.Equal(grandma.ForecastHours, []int{1, 3}) || grandma.HourlyDetails != (HourlyDetails{Rain: true, Wind: true}) || grandma.ForecastDays != 3 {
+		t.Fatalf("grandma forecast = %v, %+v, %d days; want 1,3 with rain and wind over 3 days", grandma.ForecastHours, grandma.HourlyDetails, grandma.ForecastDays)
+	}
+
+	beachHouse := dashboards["beach-house"]
+	if beachHouse.Location.TideStation != "8720587" || beachHouse.Panels != allPanels {
+		t.Fatalf("unexpected beach-house dashboard: %+v", beachHouse)
+	}
+	if !slices.Equal(beachHouse.ForecastHours, []int{2, 4, 6, 8}) || beachHouse.HourlyDetails != (HourlyDetails{Description: true}) || beachHouse.ForecastDays != 5 {
+		t.Fatalf("beach-house should inherit the default forecast: %v, %+v, %d days", beachHouse.ForecastHours, beachHouse.HourlyDetails, beachHouse.ForecastDays)
+	}
+}
+
+func TestLoadConfig_RejectsInvalidDashboards(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "bad name", env: map[string]string{"DASHBOARDS": "../etc"}},
+		{name: "duplicate", env: map[string]string{"DASHBOARDS": "kids,kids"}},
+		{name: "bad panel", env: map[string]string{"DASHBOARDS": "kids", "DASHBOARD_KIDS_PANELS": "radar"}},
+		{name: "bad latitude", env: map[string]string{"DASHBOARDS": "kids", "DASHBOARD_KIDS_LOCATION_LATITUDE": "100"}},
+		{name: "bad forecast days", env: map[string]string{"DASHBOARDS": "kids", "DASHBOARD_KIDS_FORECAST_DAYS": "9"}},
+		{name: "bad forecast hours", env: map[string]string{"DASHBOARDS": "kids", "DASHBOARD_KIDS_FORECAST_HOURS": "4,2"}},
+		{name: "bad hourly detail", env: map[string]string{"DASHBOARDS": "kids", "DASHBOARD_KIDS_FORECAST_HOURLY_DETAILS": "pollen"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := loadConfig(""); err == nil {
+				t.Fatal("loadConfig() expected error")
+			}
+		})
+	}
+}
+
+func TestDashboardForRequest(t *testing.T) {
+	oldDefault, oldDashboards := defaultDashboard, dashboards
+	defer func() { defaultDashboard, dashboards = oldDefault, oldDashboards }()
+
+	defaultDashboard = Dashboard{Location: defaultLocation}
+	dashboards = map[string]Dashboard{"kids": {Name: "kids"}}
+
+	var got Dashboard
+	var found bool
+	mux := http.NewServeMux()
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		got, found = dashboardForRequest(r)
+	}
+	mux.HandleFunc("/", capture)
+	mux.HandleFunc("/d/{name}", capture)
+
+	for _, tt := range []struct {
+		path      string
+		wantName  string
+		wantFound bool
+	}{
+		{path: "/", wantFound: true},
+		{path: "/d/kids", wantName: "kids", wantFound: true},
+		{path: "/d/KIDS", wantName: "kids", wantFound: true},
+		{path: "/d/unknown"},
+	} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if found != tt.wantFound || got.Name != tt.wantName {
+			t.Fatalf("dashboardForRequest(%s) = %q, %v; want %q, %v", tt.path, got.Name, found, tt.wantName, tt.wantFound)
+		}
+	}
+}
+
+func TestWeatherCacheIsSharedBetweenDashboardsAtSameCoordinates(t *testing.T) {
+	oldProviders, oldClient, oldCache := weatherProviders, httpClient, weatherCache
+	defer func() {
+		weatherProviders, httpClient, weatherCache = oldProviders, oldClient, oldCache
+	}()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"timezone":"America/New_York","current":{"temp":70}}`))
+	}))
+	defer server.Close()
+
+	weatherProviders = []WeatherProvider{openWeatherProvider{baseURL: server.URL}}
+	httpClient = server.Client()
+	weatherCache = cache.New(time.Hour, time.Hour)
+
+	home := defaultLocation
+	neighbour := defaultLocation
+	neighbour.Name = "Next door"
+	neighbour.TideStation = "8720587"
+	elsewhere := defaultLocation
+	elsewhere.Latitude = 30.33
+
+	for _, loc := range []Location{home, neighbour, elsewhere} {
+		if _, err := getWeatherWithCache(context.Background(), loc); err != nil {
+			t.Fatalf("getWeatherWithCache() error = %v", err)
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("weather API requests = %d; want 2", got)
+	}
+}
+
+func TestDefaultConfig_ShowsBaselinePanels(t *testing.T) {
+	oldDefault, oldDashboards := defaultDashboard, dashboards
+	defer func() { defaultDashboard, dashboards = oldDefault, oldDashboards }()
+
+	cfg := defaultConfig()
+	if err := configureDashboards(cfg); err != nil {
+		t.Fatalf("configureDashboards() error = %v", err)
+	}
+	baseline := Panels{Forecast: true, Tide: true, Launch: true, Beach: true, Moon: true, Sun: true}
+	if defaultDashboard.Panels != baseline {
+		t.Fatalf("default panels = %+v; want the baseline %+v", defaultDashboard.Panels, baseline)
+	}
+
+	data := dashboardPage{
+		Panels: defaultDashboard.Panels,
+		Weather: WeatherData{
+			Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+			Daily:   []DailyWeather{{Summary: "Clear skies"}},
+		},
+		MoonPhaseIcon: "wi-moon-full",
+		Station:       StationSensors{WaterTemperature: &SensorReading{Value: 72}},
+		SurfOutlook:   []SurfDay{{Label: "Today", Rating: 3}},
+		Storm:         &NearbyStorm{Storm: Storm{Name: "Ernesto", Classification: "HU", WindKnots: 85}},
+		ForecastDays:  []ForecastDay{{Label: "Today", High: "88°"}},
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("tmpl.Execute() error = %v", err)
+	}
+	rendered := buf.String()
+	for _, want := range []string{`class="forecast"`, `class="tide-section"`, `id="moon"`, `id="sun"`} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("expected baseline panel %q: %s", want, rendered)
+		}
+	}
+	for _, unwanted := range []string{`id="station"`, `id="surf-outlook"`, `id="storm"`, `id="daily-forecast"`} {
+		if strings.Contains(rendered, unwanted) {
+			t.Errorf("expected opt-in panel %q to be hidden by default", unwanted)
+		}
+	}
+}
+
+func TestIndexTemplate_HidesDisabledPanels(t *testing.T) {
+	data := dashboardPage{
+		Panels: Panels{Moon: true},
+		Weather: WeatherData{
+			Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+			Daily:   []DailyWeather{{Summary: "Clear skies"}},
+		},
+		ForecastDays:  []ForecastDay{{Label: "Today", High: "88°"}},
+		MoonPhaseIcon: "wi-moon-full",
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("tmpl.Execute() error = %v", err)
+	}
+	rendered := buf.String()
+	for _, unwanted := range []string{`class="forecast"`, `id="daily-forecast"`, `class="tide-section"`, `id="sun"`} {
+		if strings.Contains(rendered, unwanted) {
+			t.Fatalf("expected %q to be hidden: %s", unwanted, rendered)
+		}
+	}
+	if !strings.Contains(rendered, `id="moon"`) {
+		t.Fatalf("expected moon panel to be shown: %s", rendered)
+	}
+}
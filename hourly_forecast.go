@@ -0,0 +1,51 @@
+package main
+
+// The hourly forecast has a column for each configured offset, up to
+// maxForecastColumns. Offsets stop at maxForecastHourOffset, the end of
+// OpenWeather's hourly forecast.
+const (
+	maxForecastColumns    = 6
+	maxForecastHourOffset = 48
+)
+
+// hourlyDetailNames are what a forecast column can show under its
+// temperature.
+var hourlyDetailNames = []string{"description", "rain", "wind", "uv"}
+
+// HourlyDetails picks what each hourly forecast column shows under its
+// temperature: the condition description, then a row with the chance of
+// rain, a wind arrow with the speed and the UV index.
+type HourlyDetails struct {
+	Description bool
+	Rain        bool
+	Wind        bool
+	UV          bool
+}
+
+func newHourlyDetails(names []string) HourlyDetails {
+	var details HourlyDetails
+	for _, name := range names {
+		switch name {
+		case "description":
+			details.Description = true
+		case "rain":
+			details.Rain = true
+		case "wind":
+			details.Wind = true
+		case "uv":
+			details.UV = true
+		}
+	}
+	return details
+}
+
+// Metrics reports whether the row of rain, wind and UV is shown.
+func (d HourlyDetails) Metrics() bool {
+	return d.Rain || d.Wind || d.UV
+}
+
+// WindArrowDeg is the clockwise rotation from north of an arrow pointing
+// the way the wind blows, opposite the direction it comes from.
+func (h HourlyWeather) WindArrowDeg() int {
+	return (h.WindDeg + 180) % 360
+}
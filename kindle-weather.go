@@ -1,10 +1,10 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -14,8 +14,11 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -25,36 +28,48 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	secretMountPath        = "/etc/secrets"
-	weatherAPIURLTemplate  = "https://api.openweathermap.org/data/3.0/onecall?lat=29.65&lon=-81.20&exclude=minutely&appid=%s&units=imperial"
-	noaaAPIURLTemplate     = "https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?product=predictions&application=NOS.COOPS.TAC.WL&datum=MLLW&station=8720218&time_zone=lst_ldt&units=english&interval=hilo&format=json&date=today"
-	spacedevsAPIURLDefault = "https://ll.thespacedevs.com/2.3.0/launches/upcoming/?location__ids=27&format=json"
+	weatherAPIURLDefault   = "https://api.openweathermap.org/data/3.0/onecall"
+	openMeteoAPIURLDefault = "https://api.open-meteo.com/v1/forecast"
+	nwsAPIURLDefault       = "https://api.weather.gov"
+	noaaAPIURLDefault      = "https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?application=NOS.COOPS.TAC.WL"
+	spacedevsAPIURLDefault = "https://ll.thespacedevs.com/2.3.0/launches/upcoming/?format=json"
+	weatherCacheKeyLatest  = "latest-successful"
 	tideCacheKeyLatest     = "latest-successful"
 	tideAPIMaxAttempts     = 3
+	tracerName             = "kindle-weather"
 )
 
 //go:embed templates/*.html
 var templatesFS embed.FS
 
+// dashboardFetchTimeout is the shared deadline for a page's upstream
+// fetches. It leaves room to render inside the server's WriteTimeout.
+var dashboardFetchTimeout = 8 * time.Second
+
 var (
-	weatherAPIURL       string
-	noaaAPIURL          string
-	spacedevsAPIURL     string
-	weatherCache        *cache.Cache
-	tideCache           *cache.Cache
-	launchCache         *cache.Cache
-	httpClient          *http.Client
-	launchHTTPClient    *http.Client
-	tmpl                *template.Template
-	autoRefresh         time.Duration
-	enableRocketPreview bool
+	noaaAPIURL      string
+	spacedevsAPIURL string
+	weatherCache    *cache.Cache
+	// weatherCacheExpiration is how old weather can be before the page
+	// marks it as stale.
+	weatherCacheExpiration time.Duration
+	tideCache              *cache.Cache
+	launchCache            *cache.Cache
+	httpClient             *http.Client
+	launchHTTPClient       *http.Client
+	tmpl                   *template.Template
+	upstreamFlights        singleflight.Group
+	enableRocketPreview    bool
 
 	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "http_requests_total",
@@ -77,8 +92,11 @@ type WeatherData struct {
 	Current        CurrentWeather  `json:"current"`
 	Hourly         []HourlyWeather `json:"hourly"`
 	Daily          []DailyWeather  `json:"daily"`
+	Alerts         []WeatherAlert  `json:"alerts"`
 	Timezone       string          `json:"timezone"`
 	TimezoneOffset int             `json:"timezone_offset"`
+	Source         string          `json:"source,omitempty"`
+	FetchedAt      time.Time       `json:"-"`
 }
 
 type CurrentWeather struct {
@@ -121,10 +139,24 @@ type HourlyWeather struct {
 }
 
 type DailyWeather struct {
-	Moonrise  int64   `json:"moonrise"`
-	Moonset   int64   `json:"moonset"`
-	MoonPhase float64 `json:"moon_phase"`
-	Summary   string  `json:"summary"`
+	Dt        int64              `json:"dt"`
+	Moonrise  int64              `json:"moonrise"`
+	Moonset   int64              `json:"moonset"`
+	MoonPhase float64            `json:"moon_phase"`
+	Summary   string             `json:"summary"`
+	Temp      DailyTemp          `json:"temp"`
+	Pop       float64            `json:"pop"`
+	WindSpeed float64            `json:"wind_speed"`
+	WindGust  float64            `json:"wind_gust"`
+	WindDeg   int                `json:"wind_deg"`
+	Weather   []WeatherCondition `json:"weather"`
+}
+
+// DailyTemp is a day's high and low. Either is nil when the provider has
+// none, as the NWS does for the high of a day whose forecast starts at night.
+type DailyTemp struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
 }
 
 type WeatherCondition struct {
@@ -142,10 +174,34 @@ type TideData struct {
 	Predictions []TidePrediction `json:"predictions"`
 }
 
+// TidePrediction is a high or low tide. Time is in the station's zone and
+// is only formatted for display when the page is rendered.
 type TidePrediction struct {
-	Time   string  `json:"t"`
-	Type   string  `json:"type"`
-	Height float64 `json:"v"`
+	Time   time.Time `json:"t"`
+	Type   string    `json:"type"`
+	Height float64   `json:"v"`
+}
+
+// TideCurve is NOAA's evenly spaced prediction series for a station, used to
+// draw the tide curve with the same shape as NOAA's own plots.
+type TideCurve struct {
+	Levels []TideLevel
+}
+
+// TideLevel is the predicted water level at one moment, in feet above MLLW.
+type TideLevel struct {
+	At     time.Time
+	Height float64
+}
+
+// noaaPredictions is the predictions product as NOAA returns it. Hi/lo
+// entries carry a type; interval series leave it empty.
+type noaaPredictions struct {
+	Predictions []struct {
+		Time   string `json:"t"`
+		Type   string `json:"type"`
+		Height string `json:"v"`
+	} `json:"predictions"`
 }
 
 type APIError struct {
@@ -191,20 +247,22 @@ type logEntry struct {
 }
 
 func init() {
+	// Requests are bounded by their callers' contexts; the client timeout
+	// only caps the longest of them, a background refresh.
 	httpClient = &http.Client{
-		Timeout:   8 * time.Second,
+		Timeout:   prefetchTimeout,
 		Transport: otelhttp.NewTransport(http.DefaultTransport),
 	}
 	launchHTTPClient = &http.Client{
 		Timeout:   2 * time.Second,
 		Transport: otelhttp.NewTransport(http.DefaultTransport),
 	}
-	noaaAPIURL = noaaAPIURLTemplate
+	noaaAPIURL = noaaAPIURLDefault
 	spacedevsAPIURL = spacedevsAPIURLDefault
-	weatherCache = cache.New(time.Hour, 2*time.Hour)
+	weatherCacheExpiration = time.Hour
+	weatherCache = cache.New(weatherCacheExpiration, 2*time.Hour)
 	tideCache = cache.New(30*time.Minute, time.Hour)
 	launchCache = cache.New(15*time.Minute, time.Hour)
-	autoRefresh = 30 * time.Minute
 	enableRocketPreview = false
 
 	var err error
@@ -216,39 +274,46 @@ func init() {
 	}
 }
 
-func configureRuntime() error {
-	weatherAPIURL = strings.TrimSpace(os.Getenv("WEATHER_API_URL"))
-	if weatherAPIURL == "" {
-		openWeatherAPIKey := strings.TrimSpace(os.Getenv("OPENWEATHER_API_KEY"))
-		if openWeatherAPIKey == "" {
-			var err error
-			openWeatherAPIKey, err = readSecret("openweather-api-key")
-			if err != nil {
-				return fmt.Errorf("failed to read OpenWeather API key: %w", err)
-			}
+// configureRuntime applies a loaded Config to the package globals used by the
+// handlers. It runs once at startup, before the server accepts requests.
+func configureRuntime(cfg Config) error {
+	apis := cfg.APIs
+	if slices.Contains(configuredWeatherProviderNames(apis), weatherProviderOpenWeather) &&
+		apis.OpenWeatherAPIKey == "" && apis.WeatherURL == weatherAPIURLDefault {
+		var err error
+		apis.OpenWeatherAPIKey, err = readSecret("openweather-api-key")
+		if err != nil {
+			return fmt.Errorf("failed to read OpenWeather API key: %w", err)
 		}
-		weatherAPIURL = fmt.Sprintf(weatherAPIURLTemplate, openWeatherAPIKey)
 	}
-
-	noaaAPIURL = strings.TrimSpace(os.Getenv("NOAA_API_URL"))
-	if noaaAPIURL == "" {
-		noaaAPIURL = noaaAPIURLTemplate
+	providers, err := newWeatherProviders(apis)
+	if err != nil {
+		return err
 	}
+	weatherProviders = providers
 
-	spacedevsAPIURL = strings.TrimSpace(os.Getenv("SPACEDEVS_API_URL"))
-	if spacedevsAPIURL == "" {
-		spacedevsAPIURL = spacedevsAPIURLDefault
-	}
+	noaaAPIURL = cfg.APIs.NOAAURL
+	nwsAPIURL = cfg.APIs.NWSURL
+	nhcAPIURL = cfg.APIs.NHCURL
+	spacedevsAPIURL = cfg.APIs.SpacedevsURL
+
+	cleanup := secondsDuration(cfg.Cache.CleanupIntervalSeconds)
+	weatherCacheExpiration = secondsDuration(cfg.Cache.WeatherSeconds)
+	weatherCache = cache.New(weatherCacheExpiration, cleanup)
+	tideCache = cache.New(secondsDuration(cfg.Cache.TideSeconds), cleanup)
+	launchCache = cache.New(secondsDuration(cfg.Cache.LaunchSeconds), cleanup)
+	configureSurfRuntime(cfg, cleanup)
+	launchHTTPClient.Timeout = secondsDuration(cfg.APIs.LaunchTimeoutSeconds)
+	tideWindowPast = time.Duration(cfg.Tide.WindowPastHours) * time.Hour
+	tideWindowFuture = time.Duration(cfg.Tide.WindowFutureHours) * time.Hour
+	tideCurveInterval = cfg.Tide.CurveInterval
+	showObservedWaterLevel = cfg.Tide.Observed
+	tideAnomalyThreshold = cfg.Tide.AnomalyThresholdFeet
+	enableRocketPreview = cfg.EnableRocketPreview
 
-	exp := parseEnvDurationSeconds("CACHE_EXPIRATION", time.Hour)
-	cleanup := parseEnvDurationSeconds("CACHE_CLEANUP_INTERVAL", 2*time.Hour)
-	weatherCache = cache.New(exp, cleanup)
-	tideCache = cache.New(parseEnvDurationSeconds("TIDE_CACHE_EXPIRATION", 30*time.Minute), cleanup)
-	launchCache = cache.New(parseEnvDurationSeconds("LAUNCH_CACHE_EXPIRATION", 15*time.Minute), cleanup)
-	configureSurfRuntime(cleanup)
-	autoRefresh = parseEnvDurationSeconds("AUTO_REFRESH_SECONDS", 30*time.Minute)
-	launchHTTPClient.Timeout = parseEnvDurationSeconds("LAUNCH_API_TIMEOUT_SECONDS", 2*time.Second)
-	enableRocketPreview = parseEnvBool("ENABLE_ROCKET_PREVIEW")
+	if err := configureDashboards(cfg); err != nil {
+		return fmt.Errorf("invalid dashboards: %w", err)
+	}
 
 	return nil
 }
@@ -262,48 +327,25 @@ func readSecret(secretName string) (string, error) {
 	return strings.TrimSpace(string(secretValue)), nil
 }
 
-func parseEnvDurationSeconds(key string, def time.Duration) time.Duration {
-	v := strings.TrimSpace(os.Getenv(key))
-	if v == "" {
-		return def
-	}
-	// Interpret as integer seconds
-	secs, err := strconv.Atoi(v)
-	if err != nil || secs <= 0 {
-		return def
-	}
-	return time.Duration(secs) * time.Second
-}
-
-func parseEnvBool(key string) bool {
-	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
-	case "1", "true", "yes", "on":
-		return true
+func setupOpenTelemetry(ctx context.Context, cfg TelemetryConfig) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	switch {
+	case cfg.OTLPTracesEndpoint != "":
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPTracesEndpoint))
+	case cfg.OTLPEndpoint != "":
+		opts = append(opts, otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.OTLPEndpoint, "/")+"/v1/traces"))
 	default:
-		return false
-	}
-}
-
-func setupOpenTelemetry(ctx context.Context) (func(context.Context) error, error) {
-	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
-	tracesEndpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
-	if endpoint == "" && tracesEndpoint == "" {
 		return nil, nil
 	}
 
-	exporter, err := otlptracehttp.New(ctx)
+	exporter, err := otlptracehttp.New(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
 	}
 
-	serviceName := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
-	if serviceName == "" {
-		serviceName = "kindle-weather"
-	}
-
 	res := resource.NewWithAttributes(
 		"",
-		attribute.String("service.name", serviceName),
+		attribute.String("service.name", cfg.ServiceName),
 	)
 
 	tp := sdktrace.NewTracerProvider(
@@ -318,6 +360,19 @@ func setupOpenTelemetry(ctx context.Context) (func(context.Context) error, error
 	return tp.Shutdown, nil
 }
 
+// traceFetch runs one upstream fetch in its own span under the request
+// trace, so a slow or failing source stands out next to the others.
+func traceFetch(ctx context.Context, source string, fetch func(ctx context.Context) error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "fetch "+source)
+	defer span.End()
+	span.SetAttributes(attribute.String("dashboard.source", source))
+
+	if err := fetch(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
 func buildAutoRefreshURL(r *http.Request, refreshToken int64) string {
 	q := r.URL.Query()
 	q.Set("refresh", strconv.FormatInt(refreshToken, 10))
@@ -328,89 +383,157 @@ func buildAutoRefreshURL(r *http.Request, refreshToken int64) string {
 	}).String()
 }
 
-func getWeatherWithCache(ctx context.Context) (WeatherData, error) {
+func getWeatherWithCache(ctx context.Context, loc Location) (WeatherData, error) {
+	cacheKey := weatherCacheKey(loc)
+
 	// Check if weather data is in cache
-	if cachedData, found := weatherCache.Get("weather"); found {
+	if cachedData, found := weatherCache.Get(cacheKey); found {
 		return cachedData.(WeatherData), nil
 	}
 
-	// If not in cache, fetch from API
-	data, err := fetchWeatherFromAPI(ctx)
+	data, err := refreshWeather(ctx, loc)
 	if err != nil {
+		if cachedData, found := weatherCache.Get(cacheKey + ":" + weatherCacheKeyLatest); found {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Using cached weather data after refresh failure: %v", err),
+			})
+			return cachedData.(WeatherData), nil
+		}
 		return WeatherData{}, err
 	}
 
-	// Store in cache
-	weatherCache.Set("weather", data, cache.DefaultExpiration)
-
 	return data, nil
 }
 
-func fetchWeatherFromAPI(ctx context.Context) (WeatherData, error) {
-	apiRequestsTotal.WithLabelValues("weather").Inc()
-	if strings.TrimSpace(weatherAPIURL) == "" {
-		return WeatherData{}, fmt.Errorf("weather API URL is not configured")
+// shareUpstream runs fetch once for every concurrent caller with the same
+// key. The fetch is detached from the caller that started it, while each
+// caller waits only as long as its own ctx allows; one giving up does not
+// fail the others, and the fetch still finishes and fills the cache. The
+// fetch gets the time left on the starting caller's deadline, and at least
+// dashboardFetchTimeout so a page joining late is not cut short.
+func shareUpstream(ctx context.Context, key string, fetch func(context.Context) (any, error)) (any, error) {
+	results := upstreamFlights.DoChan(key, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), upstreamBudget(ctx))
+		defer cancel()
+		return fetch(fetchCtx)
+	})
+	select {
+	case result := <-results:
+		return result.Val, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+}
 
-	req, err := http.NewRequest(http.MethodGet, weatherAPIURL, nil)
-	if err != nil {
-		return WeatherData{}, &APIError{URL: weatherAPIURL, Operation: "build weather request", Err: err}
+// upstreamBudget is how long a shared fetch started by ctx may run.
+func upstreamBudget(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return max(time.Until(deadline), dashboardFetchTimeout)
 	}
-	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", "kindle-weather/1.0")
+	return dashboardFetchTimeout
+}
 
-	resp, err := httpClient.Do(req)
+func weatherCacheKey(loc Location) string {
+	return "weather:" + loc.coordinatesKey()
+}
+
+// refreshWeather fetches weather and stores it in the cache, ignoring any
+// cached entry. Dashboards at the same coordinates share a single in-flight
+// request.
+func refreshWeather(ctx context.Context, loc Location) (WeatherData, error) {
+	cacheKey := weatherCacheKey(loc)
+	result, err := shareUpstream(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		data, err := fetchWeatherFromAPI(ctx, loc)
+		if err != nil {
+			return WeatherData{}, err
+		}
+
+		// Store in cache
+		weatherCache.Set(cacheKey, data, cache.DefaultExpiration)
+		weatherCache.Set(cacheKey+":"+weatherCacheKeyLatest, data, cache.NoExpiration)
+		return data, nil
+	})
 	if err != nil {
-		return WeatherData{}, &APIError{URL: weatherAPIURL, Operation: "GET weather data", Err: err}
+		return WeatherData{}, err
 	}
-	defer resp.Body.Close()
+	return result.(WeatherData), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return WeatherData{}, &APIError{URL: weatherAPIURL, Operation: "GET weather data", Err: fmt.Errorf("status code %d", resp.StatusCode)}
-	}
+// fetchWeatherFromAPI tries each configured provider in turn and normalises
+// the first successful result for display. Source records which provider
+// answered so the footer can show it. Each attempt gets a fair share of the
+// time left, so a provider that hangs cannot use up the deadline before the
+// fallbacks are tried.
+func fetchWeatherFromAPI(ctx context.Context, loc Location) (WeatherData, error) {
+	var errs []error
+	for i, provider := range weatherProviders {
+		apiRequestsTotal.WithLabelValues("weather").Inc()
+
+		attemptCtx, cancel := providerAttemptContext(ctx, len(weatherProviders)-i)
+		data, err := provider.FetchWeather(attemptCtx, loc)
+		cancel()
+		if err == nil {
+			weatherProviderRequestsTotal.WithLabelValues(provider.Name(), "success").Inc()
+			data.Source = provider.Name()
+			data.FetchedAt = time.Now()
+			roundWeatherData(&data)
+			formatWeatherTimes(&data)
+			return data, nil
+		}
 
-	var data WeatherData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return WeatherData{}, &APIError{URL: weatherAPIURL, Operation: "decode weather data", Err: err}
+		weatherProviderRequestsTotal.WithLabelValues(provider.Name(), "error").Inc()
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		if ctx.Err() != nil || i == len(weatherProviders)-1 {
+			break
+		}
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Weather provider %s failed, trying %s: %v", provider.Name(), weatherProviders[i+1].Name(), err),
+		})
 	}
 
-	roundWeatherData(&data)
-	formatWeatherTimes(&data)
+	if len(errs) == 0 {
+		return WeatherData{}, fmt.Errorf("no weather provider is configured")
+	}
+	return WeatherData{}, errors.Join(errs...)
+}
 
-	return data, nil
+// providerAttemptContext splits the time left on ctx evenly between the
+// remaining provider attempts. A provider that fails fast leaves its unused
+// share to the ones after it.
+func providerAttemptContext(ctx context.Context, remaining int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || remaining <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(remaining))
 }
 
-func formatLaunchTime(timestamp string) (string, error) {
+func formatLaunchTime(timestamp string, tz *time.Location) (string, error) {
 	parsedTime, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse time: %w", err)
 	}
 
-	etLocation, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		return "", fmt.Errorf("failed to load location: %w", err)
-	}
-
-	etTime := parsedTime.In(etLocation)
-	return etTime.Format("3:04pm"), nil
+	return parsedTime.In(tz).Format("3:04pm"), nil
 }
 
-func buildTodayKennedyLaunchURL(now time.Time) (string, error) {
+func buildTodayKennedyLaunchURL(now time.Time, loc Location) (string, error) {
 	baseURL, err := url.Parse(spacedevsAPIURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse base URL: %w", err)
 	}
 
-	etLocation, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		return "", fmt.Errorf("failed to load location: %w", err)
-	}
-
-	etNow := now.In(etLocation)
-	startOfDay := time.Date(etNow.Year(), etNow.Month(), etNow.Day(), 0, 0, 0, 0, etLocation)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	tz := loc.timeLocation()
+	localNow := now.In(tz)
+	startOfDay := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, tz)
+	endOfDay := startOfDay.AddDate(0, 0, 1)
 
 	query := baseURL.Query()
+	query.Set("location__ids", strconv.Itoa(loc.LaunchLocationID))
 	query.Set("limit", "5")
 	query.Set("ordering", "net")
 	query.Set("net__gte", startOfDay.UTC().Format(time.RFC3339))
@@ -420,33 +543,42 @@ func buildTodayKennedyLaunchURL(now time.Time) (string, error) {
 	return baseURL.String(), nil
 }
 
-func getTodayKennedyLaunch(ctx context.Context) (*LaunchInfo, error) {
-	cacheKey := todayLaunchCacheKey(time.Now())
+func getTodayKennedyLaunch(ctx context.Context, loc Location) (*LaunchInfo, error) {
+	cacheKey := todayLaunchCacheKey(time.Now(), loc)
 	if cachedData, found := launchCache.Get(cacheKey); found {
 		return cachedData.(launchCacheEntry).Launch, nil
 	}
 
-	launch, err := fetchTodayKennedyLaunch(ctx)
+	return refreshTodayLaunch(ctx, loc)
+}
+
+// refreshTodayLaunch fetches today's launch and caches it, ignoring any
+// cached entry.
+func refreshTodayLaunch(ctx context.Context, loc Location) (*LaunchInfo, error) {
+	cacheKey := todayLaunchCacheKey(time.Now(), loc)
+	result, err := shareUpstream(ctx, "launch:"+cacheKey, func(ctx context.Context) (any, error) {
+		launch, err := fetchTodayKennedyLaunch(ctx, loc)
+		if err != nil {
+			return nil, err
+		}
+		launchCache.Set(cacheKey, launchCacheEntry{Launch: launch}, cache.DefaultExpiration)
+		return launch, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	launchCache.Set(cacheKey, launchCacheEntry{Launch: launch}, cache.DefaultExpiration)
 
-	return launch, nil
+	return result.(*LaunchInfo), nil
 }
 
-func todayLaunchCacheKey(now time.Time) string {
-	etLocation, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		return now.UTC().Format("2006-01-02")
-	}
-	return now.In(etLocation).Format("2006-01-02")
+func todayLaunchCacheKey(now time.Time, loc Location) string {
+	return fmt.Sprintf("%d:%s", loc.LaunchLocationID, now.In(loc.timeLocation()).Format("2006-01-02"))
 }
 
-func fetchTodayKennedyLaunch(ctx context.Context) (*LaunchInfo, error) {
+func fetchTodayKennedyLaunch(ctx context.Context, loc Location) (*LaunchInfo, error) {
 	apiRequestsTotal.WithLabelValues("launches").Inc()
 
-	apiURL, err := buildTodayKennedyLaunchURL(time.Now())
+	apiURL, err := buildTodayKennedyLaunchURL(time.Now(), loc)
 	if err != nil {
 		return nil, fmt.Errorf("error building API URL: %w", err)
 	}
@@ -477,11 +609,11 @@ func fetchTodayKennedyLaunch(ctx context.Context) (*LaunchInfo, error) {
 	}
 
 	for _, launch := range data.Results {
-		if !isKennedyLaunch(launch) || launch.WindowStart == "" {
+		if !matchesLaunchSite(launch, loc) || launch.WindowStart == "" {
 			continue
 		}
 
-		formatted, err := formatLaunchTime(launch.WindowStart)
+		formatted, err := formatLaunchTime(launch.WindowStart, loc.timeLocation())
 		if err != nil {
 			log.Printf("Failed to format window_start for launch: %s", launch.Name)
 			continue
@@ -496,6 +628,15 @@ func fetchTodayKennedyLaunch(ctx context.Context) (*LaunchInfo, error) {
 	return nil, nil
 }
 
+// matchesLaunchSite keeps only Kennedy pads for the default Kennedy location
+// id; other sites are already filtered by the location__ids query.
+func matchesLaunchSite(launch LaunchData, loc Location) bool {
+	if loc.LaunchLocationID != kennedyLaunchLocationID {
+		return true
+	}
+	return isKennedyLaunch(launch)
+}
+
 func isKennedyLaunch(launch LaunchData) bool {
 	locationName := strings.ToLower(launch.Pad.Location.Name)
 	padName := strings.ToLower(launch.Pad.Name)
@@ -566,9 +707,9 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("error during %s: %v (url: %s)", e.Operation, e.Err, e.URL)
 }
 
-func getWeather(ctx context.Context) (WeatherData, error) {
+func getWeather(ctx context.Context, loc Location) (WeatherData, error) {
 	// Deprecated: use getWeatherWithCache/fetchWeatherFromAPI
-	return fetchWeatherFromAPI(ctx)
+	return fetchWeatherFromAPI(ctx, loc)
 }
 
 func roundWeatherData(data *WeatherData) {
@@ -590,6 +731,19 @@ func roundWeatherData(data *WeatherData) {
 		data.Hourly[i].Pop = math.Round(data.Hourly[i].Pop * 100) // Convert probability to percentage and round
 		data.Hourly[i].Rain.OneH = math.Round(data.Hourly[i].Rain.OneH)
 	}
+
+	// Round daily data
+	for i := range data.Daily {
+		day := &data.Daily[i]
+		for _, temp := range []*float64{day.Temp.Min, day.Temp.Max} {
+			if temp != nil {
+				*temp = math.Round(*temp)
+			}
+		}
+		day.WindSpeed = math.Round(day.WindSpeed)
+		day.WindGust = math.Round(day.WindGust)
+		day.Pop = math.Round(day.Pop * 100)
+	}
 }
 
 func (w *WeatherData) convertTime(unixTime int64) string {
@@ -610,12 +764,11 @@ func formatWeatherTimes(data *WeatherData) {
 	}
 }
 
-func getForecastHours(hourly []HourlyWeather) []HourlyWeather {
+// getForecastHours picks the forecast hour closest to each offset from now.
+func getForecastHours(hourly []HourlyWeather, now time.Time, offsets []int) []HourlyWeather {
 	var result []HourlyWeather
-	now := time.Now()
-	targetHours := []int{2, 4, 6, 8}
 
-	for _, targetHour := range targetHours {
+	for _, targetHour := range offsets {
 		targetTime := now.Add(time.Duration(targetHour) * time.Hour)
 		var closestHour HourlyWeather
 		smallestDiff := time.Duration(math.MaxInt64)
@@ -636,15 +789,14 @@ func getForecastHours(hourly []HourlyWeather) []HourlyWeather {
 	return result
 }
 
-func getTide(ctx context.Context) (TideData, error) {
-	cacheKey := time.Now().Format("2006-01-02")
-	if cachedData, found := tideCache.Get(cacheKey); found {
+func getTide(ctx context.Context, loc Location) (TideData, error) {
+	if cachedData, found := tideCache.Get(tideCacheKey(time.Now(), loc)); found {
 		return cachedData.(TideData), nil
 	}
 
-	tide, err := fetchTideFromAPI(ctx)
+	tide, err := refreshTide(ctx, loc)
 	if err != nil {
-		if cachedData, found := tideCache.Get(tideCacheKeyLatest); found {
+		if cachedData, found := tideCache.Get(loc.TideStation + ":" + tideCacheKeyLatest); found {
 			logJSON(logEntry{
 				Timestamp: time.Now().Format(time.RFC3339),
 				Level:     "WARN",
@@ -654,23 +806,73 @@ func getTide(ctx context.Context) (TideData, error) {
 		}
 		return TideData{}, err
 	}
-	tideCache.Set(cacheKey, tide, cache.DefaultExpiration)
-	tideCache.Set(tideCacheKeyLatest, tide, cache.NoExpiration)
 
 	return tide, nil
 }
 
-func fetchTideFromAPI(ctx context.Context) (TideData, error) {
-	tideURL, err := buildTideURL(noaaAPIURL)
+func tideCacheKey(now time.Time, loc Location) string {
+	return loc.TideStation + ":" + now.In(loc.timeLocation()).Format("2006-01-02")
+}
+
+// refreshTide fetches today's tides and caches them, ignoring any cached
+// entry.
+func refreshTide(ctx context.Context, loc Location) (TideData, error) {
+	cacheKey := tideCacheKey(time.Now(), loc)
+	result, err := shareUpstream(ctx, "tide:"+cacheKey, func(ctx context.Context) (any, error) {
+		tide, err := fetchTideFromAPI(ctx, loc)
+		if err != nil {
+			return TideData{}, err
+		}
+		tideCache.Set(cacheKey, tide, cache.DefaultExpiration)
+		tideCache.Set(loc.TideStation+":"+tideCacheKeyLatest, tide, cache.NoExpiration)
+		return tide, nil
+	})
+	if err != nil {
+		return TideData{}, err
+	}
+	return result.(TideData), nil
+}
+
+// fetchTideFromAPI fetches the highs and lows covering the chart window for
+// every moment of today, plus a day either side so the curve can be drawn
+// all the way to the window edges.
+func fetchTideFromAPI(ctx context.Context, loc Location) (TideData, error) {
+	tz := loc.timeLocation()
+	begin, end := tideFetchRange(time.Now().In(tz))
+	tideURL, err := buildTideURL(noaaAPIURL, loc.TideStation, "hilo", begin, end)
 	if err != nil {
 		return TideData{}, err
 	}
 
+	var rawData noaaPredictions
+	if err := fetchNOAAJSON(ctx, "tide", tideURL, &rawData); err != nil {
+		return TideData{}, err
+	}
+	return processTideData(rawData, tz)
+}
+
+// tideFetchRange is the span of local dates fetched for the chart on now's
+// date. It also reaches the end of the surf outlook, so tide preferences can
+// be checked on every day of it.
+func tideFetchRange(now time.Time) (begin, end time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	begin = today.Add(-tideWindowPast).AddDate(0, 0, -1)
+	end = today.AddDate(0, 0, 1).Add(tideWindowFuture).AddDate(0, 0, 1)
+	if outlookEnd := today.AddDate(0, 0, surfOutlookDays+1); outlookEnd.After(end) {
+		end = outlookEnd
+	}
+	return begin, end
+}
+
+// fetchNOAAJSON GETs a CO-OPS data API URL and decodes the JSON response
+// into out, retrying timeouts, throttling and server errors with a short
+// backoff. source labels the request metric and error messages.
+func fetchNOAAJSON(ctx context.Context, source, requestURL string, out any) error {
 	var lastErr error
 	for attempt := 1; attempt <= tideAPIMaxAttempts; attempt++ {
-		tideData, retry, err := fetchTideAttempt(ctx, tideURL)
+		retry, err := fetchNOAAAttempt(ctx, source, requestURL, out)
 		if err == nil {
-			return tideData, nil
+			return nil
 		}
 		lastErr = err
 		if !retry || attempt == tideAPIMaxAttempts || ctx.Err() != nil {
@@ -681,80 +883,65 @@ func fetchTideFromAPI(ctx context.Context) (TideData, error) {
 		select {
 		case <-ctx.Done():
 			timer.Stop()
-			return TideData{}, &APIError{URL: tideURL, Operation: "GET tide data", Err: ctx.Err()}
+			return &APIError{URL: requestURL, Operation: "GET " + source + " data", Err: ctx.Err()}
 		case <-timer.C:
 		}
 	}
 
-	return TideData{}, lastErr
+	return lastErr
 }
 
-func buildTideURL(baseURL string) (string, error) {
+// buildTideURL asks NOAA for predictions at interval ("hilo", "h" or a
+// number of minutes) on the local dates from begin to end inclusive, unless
+// the base URL already names a date.
+func buildTideURL(baseURL, station, interval string, begin, end time.Time) (string, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return "", &APIError{URL: baseURL, Operation: "build tide request", Err: err}
 	}
 	q := u.Query()
+	q.Set("station", station)
 	q.Set("product", "predictions")
 	q.Set("datum", "MLLW")
 	q.Set("units", "english")
 	q.Set("time_zone", "lst_ldt")
-	q.Set("interval", "hilo")
+	q.Set("interval", interval)
 	q.Set("format", "json")
-	if q.Get("date") == "" {
-		q.Set("date", "today")
+	if q.Get("date") == "" && q.Get("begin_date") == "" {
+		q.Set("begin_date", begin.Format("20060102"))
+		q.Set("end_date", end.Format("20060102"))
 	}
 	u.RawQuery = q.Encode()
 	return u.String(), nil
 }
 
-func fetchTideAttempt(ctx context.Context, tideURL string) (TideData, bool, error) {
-	apiRequestsTotal.WithLabelValues("tide").Inc()
+func fetchNOAAAttempt(ctx context.Context, source, requestURL string, out any) (bool, error) {
+	apiRequestsTotal.WithLabelValues(strings.ReplaceAll(source, " ", "_")).Inc()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tideURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
-		return TideData{}, false, &APIError{URL: tideURL, Operation: "build tide request", Err: err}
+		return false, &APIError{URL: requestURL, Operation: "build " + source + " request", Err: err}
 	}
 	req.Header.Set("User-Agent", "kindle-weather/1.0")
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return TideData{}, true, &APIError{URL: tideURL, Operation: "GET tide data", Err: err}
+		return true, &APIError{URL: requestURL, Operation: "GET " + source + " data", Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= http.StatusInternalServerError
-		return TideData{}, retry, &APIError{URL: tideURL, Operation: "GET tide data", Err: fmt.Errorf("status code %d", resp.StatusCode)}
-	}
-
-	var rawData struct {
-		Predictions []struct {
-			Time   string `json:"t"`
-			Type   string `json:"type"`
-			Height string `json:"v"`
-		} `json:"predictions"`
+		return retry, &APIError{URL: requestURL, Operation: "GET " + source + " data", Err: fmt.Errorf("status code %d", resp.StatusCode)}
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&rawData); err != nil {
-		return TideData{}, true, &APIError{URL: tideURL, Operation: "decode tide data", Err: err}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return true, &APIError{URL: requestURL, Operation: "decode " + source + " data", Err: err}
 	}
-
-	tideData, err := processTideData(rawData)
-	if err != nil {
-		return TideData{}, false, err
-	}
-
-	return tideData, false, nil
+	return false, nil
 }
 
-func processTideData(rawData struct {
-	Predictions []struct {
-		Time   string `json:"t"`
-		Type   string `json:"type"`
-		Height string `json:"v"`
-	} `json:"predictions"`
-}) (TideData, error) {
+func processTideData(rawData noaaPredictions, tz *time.Location) (TideData, error) {
 	var tideData TideData
 	if len(rawData.Predictions) == 0 {
 		return TideData{}, &APIError{URL: noaaAPIURL, Operation: "process tide data", Err: fmt.Errorf("no predictions found")}
@@ -767,7 +954,8 @@ func processTideData(rawData struct {
 			continue
 		}
 
-		itemTime, err := time.Parse("2006-01-02 15:04", p.Time)
+		// NOAA reports local standard/daylight time (lst_ldt) for the station
+		itemTime, err := time.ParseInLocation("2006-01-02 15:04", p.Time, tz)
 		if err != nil {
 			skipped = append(skipped, fmt.Sprintf("invalid tide time %q", p.Time))
 			continue
@@ -779,7 +967,7 @@ func processTideData(rawData struct {
 			continue
 		}
 		tideData.Predictions = append(tideData.Predictions, TidePrediction{
-			Time:   itemTime.Format("3:04 PM"),
+			Time:   itemTime,
 			Type:   p.Type,
 			Height: height,
 		})
@@ -823,15 +1011,43 @@ func getMoonPhaseIcon(moonPhase float64) string {
 	}
 }
 
+type dashboardPage struct {
+	Location           Location
+	Panels             Panels
+	Weather            WeatherData
+	Tide               TideData
+	TideSVG            template.HTML
+	TideChart          tideChart
+	ForecastHours      []HourlyWeather
+	HourlyDetails      HourlyDetails
+	ForecastDays       []ForecastDay
+	MoonPhaseIcon      string
+	Horizontal         bool
+	KennedyLaunch      *LaunchInfo
+	BeachStatus        *BeachStatus
+	BeachHazards       BeachHazards
+	Storm              *NearbyStorm
+	SurfOutlook        []SurfDay
+	Station            StationSensors
+	AutoRefreshSeconds int
+	AutoRefreshURL     string
+	WeatherSource      string
+	WeatherUpdated     string
+}
+
 func handler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	dashboard, ok := dashboardForRequest(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
 
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	weather, err := getWeatherWithCache(ctx)
+	page, err := buildDashboardPage(r.Context(), r, dashboard)
 	if err != nil {
 		logJSON(logEntry{
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -842,45 +1058,196 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tide, err := getTide(ctx)
+	err = tmpl.Execute(w, page)
 	if err != nil {
-		logJSON(logEntry{
-			Timestamp: time.Now().Format(time.RFC3339),
-			Level:     "WARN",
-			Message:   fmt.Sprintf("Error getting tide data: %v", err),
-		})
+		http.Error(w, fmt.Sprintf("Could not render template: %v", err), http.StatusInternalServerError)
 	}
+}
 
-	kennedyLaunch, err := getTodayKennedyLaunch(ctx)
-	if err != nil {
-		logJSON(logEntry{
-			Timestamp: time.Now().Format(time.RFC3339),
-			Level:     "ERROR",
-			Message:   fmt.Sprintf("Error getting launch data: %v", err),
+// buildDashboardPage gathers everything the dashboard shows. The upstream
+// fetches run concurrently under one shared deadline. Only a weather failure
+// is fatal; the other panels degrade to their empty states independently,
+// and disabled panels are not fetched at all.
+func buildDashboardPage(ctx context.Context, r *http.Request, dashboard Dashboard) (dashboardPage, error) {
+	loc := dashboard.Location
+	panels := dashboard.Panels
+	now := time.Now().In(loc.timeLocation())
+
+	fetchCtx, cancel := context.WithTimeout(ctx, dashboardFetchTimeout)
+	defer cancel()
+
+	var (
+		wg            sync.WaitGroup
+		weather       WeatherData
+		weatherErr    error
+		tide          TideData
+		tideCurve     TideCurve
+		waterLevel    WaterLevelData
+		station       StationSensors
+		kennedyLaunch *LaunchInfo
+		surfForecast  SurfForecast
+		surfErr       error
+		beachHazards  BeachHazards
+		storms        []Storm
+	)
+	fetch := func(source string, f func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			traceFetch(fetchCtx, source, f)
+		}()
+	}
+
+	fetch("weather", func(ctx context.Context) error {
+		weather, weatherErr = getWeatherWithCache(ctx, loc)
+		return weatherErr
+	})
+	if panels.Tide || panels.Beach || (panels.Surf && loc.Surf.usesTide()) {
+		fetch("tide", func(ctx context.Context) error {
+			var err error
+			tide, err = getTide(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting tide data: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	if needsTideCurve(panels, loc) {
+		fetch("tide curve", func(ctx context.Context) error {
+			var err error
+			tideCurve, err = getTideCurve(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting tide curve, drawing it from highs and lows: %v", err),
+				})
+			}
+			return err
 		})
 	}
-	if enableRocketPreview && r.URL.Query().Has("rocketPreview") {
+	if (panels.Tide || panels.Beach) && showObservedWaterLevel {
+		fetch("water level", func(ctx context.Context) error {
+			var err error
+			waterLevel, err = getWaterLevel(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting observed water level: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	if panels.Water {
+		fetch("station sensors", func(ctx context.Context) error {
+			var err error
+			station, err = getStationSensors(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting station sensors: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	if panels.Launch {
+		fetch("launch", func(ctx context.Context) error {
+			var err error
+			kennedyLaunch, err = getTodayKennedyLaunch(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "ERROR",
+					Message:   fmt.Sprintf("Error getting launch data: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	if panels.Beach || panels.Surf {
+		fetch("surf", func(ctx context.Context) error {
+			surfForecast, surfErr = getSurfForecast(ctx, loc)
+			if surfErr != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting surf data: %v", surfErr),
+				})
+			}
+			return surfErr
+		})
+	}
+	if panels.Beach {
+		fetch("beach hazards", func(ctx context.Context) error {
+			var err error
+			beachHazards, err = getBeachHazards(ctx, loc)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting beach hazards: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	if panels.Storm {
+		fetch("storms", func(ctx context.Context) error {
+			var err error
+			storms, err = getStorms(ctx)
+			if err != nil {
+				logJSON(logEntry{
+					Timestamp: time.Now().Format(time.RFC3339),
+					Level:     "WARN",
+					Message:   fmt.Sprintf("Error getting active storms: %v", err),
+				})
+			}
+			return err
+		})
+	}
+	wg.Wait()
+
+	if weatherErr != nil {
+		return dashboardPage{}, weatherErr
+	}
+
+	if panels.Launch && enableRocketPreview && r.URL.Query().Has("rocketPreview") {
 		kennedyLaunch = &LaunchInfo{Scheduled: "4:30pm"}
 	}
 
-	goodSurfToday := false
-	surfForecast, err := getSurfForecast(ctx)
-	if err != nil {
-		logJSON(logEntry{
-			Timestamp: time.Now().Format(time.RFC3339),
-			Level:     "WARN",
-			Message:   fmt.Sprintf("Error getting surf data: %v", err),
-		})
-	} else {
-		goodSurfToday = isGoodSurfToday(surfForecast, weather, time.Now())
+	var surfHours []SurfHour
+	if surfErr == nil {
+		surfHours = rateSurfHours(surfForecast, weather, tide, tideCurve, loc.Surf, surfLocation(weather, now.Location()))
+	}
+	var beachStatus *BeachStatus
+	if panels.Beach {
+		anomaly, _ := tideAnomaly(waterLevel, tide, tideCurve, now)
+		beachStatus = getBeachStatus(beachHazards, tide.Predictions, anomaly, bestSurfWindow(surfHours, weather, now), now)
+	}
+	beachStatus = getPageNotice(weather.Alerts, beachStatus, now)
+	var outlook []SurfDay
+	if panels.Surf {
+		outlook = surfOutlook(surfHours, loc.Surf, weather, now, surfOutlookDays)
 	}
-	beachStatus := getBeachStatus(tide.Predictions, goodSurfToday, time.Now())
 
-	forecastHours := getForecastHours(weather.Hourly)
+	forecastHours := getForecastHours(weather.Hourly, now, dashboard.ForecastHours)
+	var days []ForecastDay
+	if panels.Daily {
+		days = getForecastDays(weather.Daily, loc.timeLocation(), now, dashboard.ForecastDays)
+	}
 	moonPhaseIcon := getMoonPhaseIcon(weather.Daily[0].MoonPhase)
 
 	// Generate SVG from tide data
-	tideSVG, err := generateTideSVG(tide.Predictions)
+	tideChart := buildTideChart(tide.Predictions, tideCurve, waterLevel.Observed, now)
+	tideSVG, err := generateTideSVG(tideChart)
 	if err != nil {
 		logJSON(logEntry{
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -890,153 +1257,50 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		tideSVG = tideUnavailableSVG()
 	}
 
-	horizontal := r.URL.Query().Has("h")
-	refreshURL := buildAutoRefreshURL(r, time.Now().Unix())
-	autoRefreshSeconds := int(autoRefresh.Seconds())
-
-	data := struct {
-		Weather            WeatherData
-		Tide               TideData
-		TideSVG            template.HTML
-		ForecastHours      []HourlyWeather
-		MoonPhaseIcon      string
-		Horizontal         bool
-		KennedyLaunch      *LaunchInfo
-		BeachStatus        *BeachStatus
-		AutoRefreshSeconds int
-		AutoRefreshURL     string
-	}{
+	return dashboardPage{
+		Location:           loc,
+		Panels:             panels,
 		Weather:            weather,
 		Tide:               tide,
 		TideSVG:            tideSVG,
+		TideChart:          tideChart,
 		ForecastHours:      forecastHours,
+		HourlyDetails:      dashboard.HourlyDetails,
+		ForecastDays:       days,
 		MoonPhaseIcon:      moonPhaseIcon,
-		Horizontal:         horizontal,
+		Horizontal:         r.URL.Query().Has("h"),
 		KennedyLaunch:      kennedyLaunch,
 		BeachStatus:        beachStatus,
-		AutoRefreshSeconds: autoRefreshSeconds,
-		AutoRefreshURL:     refreshURL,
-	}
-
-	err = tmpl.Execute(w, data)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Could not render template: %v", err), http.StatusInternalServerError)
-	}
+		BeachHazards:       beachHazards,
+		Storm:              nearestStorm(storms, loc),
+		SurfOutlook:        outlook,
+		Station:            station.current(now),
+		AutoRefreshSeconds: int(dashboard.AutoRefresh.Seconds()),
+		AutoRefreshURL:     buildAutoRefreshURL(r, time.Now().Unix()),
+		WeatherSource:      weatherProviderTitles[weather.Source],
+		WeatherUpdated:     weatherStaleness(weather.FetchedAt, time.Now()),
+	}, nil
 }
 
-func generateTideSVG(predictions []TidePrediction) (template.HTML, error) {
-	if len(predictions) == 0 {
-		return tideUnavailableSVG(), nil
-	}
-	const svgTemplate = `
-    <svg width="600" height="95" viewBox="0 0 600 95">
-        <line x1="35" y1="44" x2="565" y2="44" stroke="black" stroke-width="1.5" />
-        <path
-            fill="none" 
-            stroke="black" 
-            stroke-width="3"
-            d="{{.Path}}"
-        />
-        {{range .Points}}
-        <circle cx="{{.X}}" cy="{{.Y}}" r="5" fill="black" />
-        {{end}}
-        {{range .Labels}}
-        <text x="{{.X}}" y="{{.Y}}" font-size="17" text-anchor="middle" font-weight="bold">{{.Type}}</text>
-        <text x="{{.X}}" y="{{.TimeY}}" font-size="16" text-anchor="middle" font-weight="bold">{{.Time}}</text>
-        {{end}}
-    </svg>`
-
-	type Point struct {
-		X, Y float64
-	}
-
-	type Label struct {
-		X, Y, TimeY float64
-		Type        string
-		Time        string
-	}
-
-	var points []Point
-	var labels []Label
-	buildPath := func(points []Point) string {
-		if len(points) == 0 {
-			return ""
-		}
-		if len(points) == 1 {
-			return fmt.Sprintf("M %.1f %.1f", points[0].X, points[0].Y)
-		}
-
-		var path strings.Builder
-		path.WriteString(fmt.Sprintf("M %.1f %.1f", points[0].X, points[0].Y))
-		for i := 1; i < len(points); i++ {
-			prev := points[i-1]
-			curr := points[i]
-			midX := prev.X + ((curr.X - prev.X) / 2)
-			path.WriteString(fmt.Sprintf(" C %.1f %.1f, %.1f %.1f, %.1f %.1f", midX, prev.Y, midX, curr.Y, curr.X, curr.Y))
-		}
-		return path.String()
+// weatherStaleness returns "updated 2h ago" when the weather being shown is
+// older than the weather cache expiry, which only happens when refreshes have
+// been failing and the last good forecast is being reused.
+func weatherStaleness(fetchedAt, now time.Time) string {
+	if fetchedAt.IsZero() {
+		return ""
 	}
-
-	// Find min/max heights for scaling
-	minHeight := predictions[0].Height
-	maxHeight := predictions[0].Height
-	for _, p := range predictions {
-		if p.Height < minHeight {
-			minHeight = p.Height
-		}
-		if p.Height > maxHeight {
-			maxHeight = p.Height
-		}
+	age := now.Sub(fetchedAt)
+	if age <= weatherCacheExpiration {
+		return ""
 	}
-
-	// Generate points and labels
-	for i, p := range predictions {
-		denom := float64(len(predictions) - 1)
-		x := 300.0
-		if denom > 0 {
-			x = 35 + float64(i)*(530.0/denom)
-		}
-		scale := 1.0
-		if maxHeight != minHeight {
-			scale = (p.Height - minHeight) / (maxHeight - minHeight)
-		}
-		y := 58 - (scale * 28)
-
-		points = append(points, Point{X: x, Y: y})
-		labels = append(labels, Label{
-			X:     x,
-			Y:     78,
-			TimeY: 91,
-			Type:  p.Type,
-			Time:  p.Time,
-		})
-	}
-
-	// Render SVG
-	tmpl := template.Must(template.New("svg").Parse(svgTemplate))
-	var buf bytes.Buffer
-	err := tmpl.Execute(&buf, struct {
-		Points []Point
-		Labels []Label
-		Path   string
-	}{
-		Points: points,
-		Labels: labels,
-		Path:   buildPath(points),
-	})
-	if err != nil {
-		return "", fmt.Errorf("error rendering SVG: %w", err)
+	switch {
+	case age >= 48*time.Hour:
+		return fmt.Sprintf("updated %dd ago", int(age.Hours()/24))
+	case age >= time.Hour:
+		return fmt.Sprintf("updated %dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("updated %dm ago", int(age.Minutes()))
 	}
-
-	return template.HTML(buf.String()), nil
-}
-
-func tideUnavailableSVG() template.HTML {
-	return template.HTML(`
-    <svg width="600" height="95" viewBox="0 0 600 95">
-        <line x1="35" y1="44" x2="565" y2="44" stroke="black" stroke-width="2" stroke-dasharray="6 6" />
-        <text x="300" y="49" font-size="18" text-anchor="middle" font-weight="bold">Tide data unavailable</text>
-    </svg>`)
 }
 
 func main() {
@@ -1044,7 +1308,15 @@ func main() {
 		os.Exit(runHealthcheck())
 	}
 
-	if err := configureRuntime(); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "--check-config" {
+		os.Exit(runCheckConfig(os.Args[2:]))
+	}
+
+	cfg, err := loadConfig(configPath(os.Args[1:]))
+	if err == nil {
+		err = configureRuntime(cfg)
+	}
+	if err != nil {
 		logJSON(logEntry{
 			Timestamp: time.Now().Format(time.RFC3339),
 			Level:     "FATAL",
@@ -1053,7 +1325,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	otelShutdown, err := setupOpenTelemetry(context.Background())
+	if cfg.Cache.Dir != "" {
+		if err := os.MkdirAll(cfg.Cache.Dir, 0o755); err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "FATAL",
+				Message:   fmt.Sprintf("Failed to create cache directory: %v", err),
+			})
+			os.Exit(1)
+		}
+		// A bad snapshot only costs a cold start, so it is not fatal
+		restored, err := loadCacheSnapshot(cfg.Cache.Dir)
+		if err != nil {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Ignoring cache snapshot: %v", err),
+			})
+		} else {
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "INFO",
+				Message:   fmt.Sprintf("Restored %d cache entries from %s", restored, cfg.Cache.Dir),
+			})
+		}
+	}
+
+	otelShutdown, err := setupOpenTelemetry(context.Background(), cfg.Telemetry)
 	if err != nil {
 		logJSON(logEntry{
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -1064,6 +1362,18 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.Handle("/", loggingMiddleware(otelhttp.NewHandler(http.HandlerFunc(handler), "GET /")))
+	mux.Handle("/image.png", loggingMiddleware(otelhttp.NewHandler(http.HandlerFunc(imageHandler), "GET /image.png")))
+	mux.Handle("/d/{name}", loggingMiddleware(otelhttp.NewHandler(http.HandlerFunc(handler), "GET /d/{name}")))
+	mux.Handle("/d/{name}/image.png", loggingMiddleware(otelhttp.NewHandler(http.HandlerFunc(imageHandler), "GET /d/{name}/image.png")))
+	for path, h := range map[string]http.HandlerFunc{
+		"dashboard": apiDashboardHandler,
+		"tide":      apiTideHandler,
+		"surf":      apiSurfHandler,
+		"launches":  apiLaunchesHandler,
+	} {
+		mux.Handle("GET /api/v1/"+path, loggingMiddleware(otelhttp.NewHandler(h, "GET /api/v1/"+path)))
+		mux.Handle("GET /api/v1/d/{name}/"+path, loggingMiddleware(otelhttp.NewHandler(h, "GET /api/v1/d/{name}/"+path)))
+	}
 	mux.Handle("/css/", loggingMiddleware(otelhttp.NewHandler(http.StripPrefix("/css/", http.FileServer(http.Dir("css"))), "GET /css")))
 	mux.Handle("/font/", loggingMiddleware(otelhttp.NewHandler(http.StripPrefix("/font/", http.FileServer(http.Dir("font"))), "GET /font")))
 	mux.Handle("/metrics", otelhttp.NewHandler(promhttp.Handler(), "GET /metrics"))
@@ -1094,14 +1404,36 @@ func main() {
 		}
 	}()
 
+	// Keep the caches warm so page requests rarely wait on upstream APIs
+	prefetchCtx, stopPrefetch := context.WithCancel(context.Background())
+	var prefetchers *sync.WaitGroup
+	if cfg.Cache.Prefetch {
+		prefetchers = startPrefetcher(prefetchCtx, prefetchJobs(allDashboards(), cfg.Cache))
+	}
+
+	snapshotCtx, stopSnapshots := context.WithCancel(context.Background())
+	var snapshots *sync.WaitGroup
+	if cfg.Cache.Dir != "" {
+		snapshots = startCacheSnapshots(snapshotCtx, cfg.Cache.Dir, secondsDuration(cfg.Cache.SnapshotSeconds))
+	}
+
 	// Graceful shutdown on SIGINT/SIGTERM
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	<-stop
+	stopPrefetch()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = server.Shutdown(ctx)
+	if prefetchers != nil {
+		prefetchers.Wait()
+	}
+	// Save once more after the last refresh has landed
+	stopSnapshots()
+	if snapshots != nil {
+		snapshots.Wait()
+	}
 
 	if otelShutdown != nil {
 		if err := otelShutdown(ctx); err != nil {
@@ -3,16 +3,22 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"html/template"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/patrickmn/go-cache"
 )
 
 func TestGetMoonPhaseIcon(t *testing.T) {
@@ -78,13 +84,57 @@ func TestGetForecastHours(t *testing.T) {
 		{Dt: now.Add(8 * time.Hour).Unix()},
 	}
 
-	result := getForecastHours(hourly)
+	result := getForecastHours(hourly, now, []int{2, 4, 6, 8})
 
 	if len(result) != 4 {
 		t.Errorf("getForecastHours() returned %d items; want 4", len(result))
 	}
 }
 
+func TestGetForecastHours_UsesConfiguredOffsets(t *testing.T) {
+	now := time.Date(2024, time.June, 21, 14, 10, 0, 0, time.UTC)
+	var hourly []HourlyWeather
+	for hour := 0; hour < 12; hour++ {
+		hourly = append(hourly, HourlyWeather{Dt: now.Truncate(time.Hour).Add(time.Duration(hour) * time.Hour).Unix(), Temp: float64(70 + hour)})
+	}
+
+	result := getForecastHours(hourly, now, []int{1, 3, 6})
+
+	var temps []float64
+	for _, hour := range result {
+		temps = append(temps, hour.Temp)
+	}
+	if !slices.Equal(temps, []float64{71, 73, 76}) {
+		t.Fatalf("temps = %v; want the hours closest to 1, 3 and 6 hours out", temps)
+	}
+}
+
+func TestBuildDashboardPage_HourlyForecastIsPerDashboard(t *testing.T) {
+	oldCache := weatherCache
+	defer func() { weatherCache = oldCache }()
+
+	weatherCache = cache.New(time.Hour, time.Hour)
+	now := time.Now()
+	weather := WeatherData{Timezone: defaultLocation.Timezone, Daily: []DailyWeather{{}}}
+	for hour := 0; hour < 12; hour++ {
+		weather.Hourly = append(weather.Hourly, HourlyWeather{Dt: now.Truncate(time.Hour).Add(time.Duration(hour) * time.Hour).Unix(), Temp: float64(70 + hour)})
+	}
+	weatherCache.Set(weatherCacheKey(defaultLocation), weather, cache.DefaultExpiration)
+
+	for _, dashboard := range []Dashboard{
+		{Location: defaultLocation, Panels: Panels{Forecast: true}, ForecastHours: []int{2, 4, 6, 8}, HourlyDetails: HourlyDetails{Description: true}},
+		{Name: "kids", Location: defaultLocation, Panels: Panels{Forecast: true}, ForecastHours: []int{1, 3}, HourlyDetails: HourlyDetails{Rain: true, UV: true}},
+	} {
+		page, err := buildDashboardPage(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil), dashboard)
+		if err != nil {
+			t.Fatalf("buildDashboardPage() error = %v", err)
+		}
+		if len(page.ForecastHours) != len(dashboard.ForecastHours) || page.HourlyDetails != dashboard.HourlyDetails {
+			t.Fatalf("%q dashboard: %d columns with %+v; want %d with %+v", dashboard.Name, len(page.ForecastHours), page.HourlyDetails, len(dashboard.ForecastHours), dashboard.HourlyDetails)
+		}
+	}
+}
+
 func TestGetIconClassName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -127,7 +177,7 @@ func TestProcessTideData(t *testing.T) {
 		},
 	}
 
-	data, err := processTideData(rawData)
+	data, err := processTideData(rawData, time.UTC)
 	if err != nil {
 		t.Fatalf("processTideData() error = %v", err)
 	}
@@ -146,9 +196,9 @@ func TestProcessTideData(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		if data.Predictions[tt.idx].Time != tt.want {
+		if got := data.Predictions[tt.idx].Time.Format("3:04 PM"); got != tt.want {
 			t.Errorf("Prediction[%d].Time = %q; want %q",
-				tt.idx, data.Predictions[tt.idx].Time, tt.want)
+				tt.idx, got, tt.want)
 		}
 		if data.Predictions[tt.idx].Type != tt.typ {
 			t.Errorf("Prediction[%d].Type = %q; want %q",
@@ -157,6 +207,38 @@ func TestProcessTideData(t *testing.T) {
 	}
 }
 
+func TestProcessTideData_KeepsFullTimestamps(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	var raw noaaPredictions
+	for _, clock := range []string{"2026-03-07 23:50", "2026-03-08 01:40", "2026-03-08 08:05", "2026-03-09 08:50"} {
+		raw.Predictions = append(raw.Predictions, struct {
+			Time   string `json:"t"`
+			Type   string `json:"type"`
+			Height string `json:"v"`
+		}{Time: clock, Type: "L", Height: "0.1"})
+	}
+
+	data, err := processTideData(raw, ny)
+	if err != nil {
+		t.Fatalf("processTideData() error = %v", err)
+	}
+	got := data.Predictions
+	if got[1].Time.Sub(got[0].Time) != 110*time.Minute {
+		t.Errorf("tides either side of midnight are %v apart; want 1h50m", got[1].Time.Sub(got[0].Time))
+	}
+	// Clocks spring forward at 2 AM, so 1:40 to 8:05 is one hour shorter
+	// than the wall clocks suggest.
+	if got[2].Time.Sub(got[1].Time) != 5*time.Hour+25*time.Minute {
+		t.Errorf("tides across the DST change are %v apart; want 5h25m", got[2].Time.Sub(got[1].Time))
+	}
+	if got[3].Time.Day() != 9 || got[3].Time.Location() != ny {
+		t.Errorf("last tide = %v; want March 9 in the station zone", got[3].Time)
+	}
+}
+
 func TestProcessTideData_InvalidHeight(t *testing.T) {
 	rawData := struct {
 		Predictions []struct {
@@ -174,7 +256,7 @@ func TestProcessTideData_InvalidHeight(t *testing.T) {
 		},
 	}
 
-	_, err := processTideData(rawData)
+	_, err := processTideData(rawData, time.UTC)
 	if err == nil {
 		t.Fatal("processTideData() expected error for invalid height")
 	}
@@ -199,23 +281,27 @@ func TestProcessTideData_SkipsInvalidPredictions(t *testing.T) {
 		},
 	}
 
-	data, err := processTideData(rawData)
+	data, err := processTideData(rawData, time.UTC)
 	if err != nil {
 		t.Fatalf("processTideData() error = %v", err)
 	}
 	if len(data.Predictions) != 1 {
 		t.Fatalf("got %d valid predictions; want 1", len(data.Predictions))
 	}
-	if data.Predictions[0].Time != "1:45 PM" || data.Predictions[0].Type != "H" {
+	if data.Predictions[0].Time.Format("3:04 PM") != "1:45 PM" || data.Predictions[0].Type != "H" {
 		t.Fatalf("unexpected prediction: %+v", data.Predictions[0])
 	}
 }
 
 func TestGenerateTideSVG_CompactLayout(t *testing.T) {
-	svg, err := generateTideSVG([]TidePrediction{
-		{Time: "3:17 AM", Type: "L", Height: 0.1},
-		{Time: "9:24 AM", Type: "H", Height: 4.2},
-	})
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 1, hour, minute, 0, 0, time.UTC)
+	}
+	svg, err := generateTideSVG(buildTideChart([]TidePrediction{
+		{Time: day(3, 17), Type: "L", Height: 0.1},
+		{Time: day(9, 24), Type: "H", Height: 4.2},
+		{Time: day(15, 41), Type: "L", Height: 0.3},
+	}, TideCurve{}, nil, day(8, 0)))
 	if err != nil {
 		t.Fatalf("generateTideSVG() error = %v", err)
 	}
@@ -224,13 +310,15 @@ func TestGenerateTideSVG_CompactLayout(t *testing.T) {
 	for _, want := range []string{
 		`viewBox="0 0 600 95"`,
 		`<path`,
-		` C `,
-		`x="35"`,
-		`x="565"`,
-		`font-size="17" text-anchor="middle" font-weight="bold">L</text>`,
-		`font-size="17" text-anchor="middle" font-weight="bold">H</text>`,
-		`font-size="16" text-anchor="middle" font-weight="bold">3:17 AM</text>`,
-		`font-size="16" text-anchor="middle" font-weight="bold">9:24 AM</text>`,
+		` L `,
+		`x1="35"`,
+		`x2="565"`,
+		`font-size="16" text-anchor="middle" font-weight="bold">L 0.1 ft</text>`,
+		`font-size="16" text-anchor="middle" font-weight="bold">H 4.2 ft</text>`,
+		`font-size="15" text-anchor="middle" font-weight="bold">3:17 AM</text>`,
+		`font-size="15" text-anchor="middle" font-weight="bold">9:24 AM</text>`,
+		`class="tide-now"`,
+		`ft ↑</text>`,
 	} {
 		if !strings.Contains(rendered, want) {
 			t.Fatalf("generated tide SVG missing %q: %s", want, rendered)
@@ -241,6 +329,171 @@ func TestGenerateTideSVG_CompactLayout(t *testing.T) {
 	}
 }
 
+func TestBuildTideChart_TimeAxisAndNowMarker(t *testing.T) {
+	oldPast, oldFuture := tideWindowPast, tideWindowFuture
+	defer func() { tideWindowPast, tideWindowFuture = oldPast, oldFuture }()
+	tideWindowPast, tideWindowFuture = 6*time.Hour, 30*time.Hour
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.April, day, hour, minute, 0, 0, ny)
+	}
+	predictions := []TidePrediction{
+		{Time: at(7, 21, 0), Type: "H", Height: 4.0},
+		{Time: at(8, 3, 0), Type: "L", Height: 0.0},
+		{Time: at(8, 9, 0), Type: "H", Height: 4.0},
+		{Time: at(8, 15, 0), Type: "L", Height: 0.0},
+		{Time: at(8, 21, 0), Type: "H", Height: 4.0},
+		{Time: at(9, 3, 0), Type: "L", Height: 0.0},
+		{Time: at(9, 9, 0), Type: "H", Height: 4.0},
+		{Time: at(9, 15, 0), Type: "L", Height: 0.0},
+		{Time: at(9, 21, 0), Type: "H", Height: 4.0},
+	}
+	now := at(8, 6, 0)
+	chart := buildTideChart(predictions, TideCurve{}, nil, now)
+
+	// Window 00:00 Apr 8 to 12:00 Apr 9: 36 hours over 530 px.
+	if len(chart.Points) != 6 {
+		t.Fatalf("got %d highs and lows in the window; want 6", len(chart.Points))
+	}
+	xAt := func(t time.Time) float64 {
+		return tideChartLeft + t.Sub(at(8, 0, 0)).Hours()/36*(tideChartRight-tideChartLeft)
+	}
+	for _, p := range chart.Points {
+		if p.Type == "H" && p.Height != "4.0 ft" {
+			t.Errorf("high label = %q; want 4.0 ft", p.Height)
+		}
+	}
+	if got, want := chart.Points[1].X, xAt(at(8, 9, 0)); math.Abs(got-want) > 0.01 {
+		t.Errorf("9 AM high at x=%.2f; want %.2f", got, want)
+	}
+
+	// Halfway between the 3 AM low and 9 AM high the tide is at mid height
+	// and rising.
+	if chart.Now == nil {
+		t.Fatal("expected a now marker")
+	}
+	if chart.Now.Label != "2.0 ft ↑" {
+		t.Errorf("now label = %q; want %q", chart.Now.Label, "2.0 ft ↑")
+	}
+	if math.Abs(chart.Now.X-xAt(now)) > 0.01 || math.Abs(chart.Now.Y-(tideChartCurveBase+tideChartCurveTop)/2) > 0.01 {
+		t.Errorf("now marker at (%.2f, %.2f)", chart.Now.X, chart.Now.Y)
+	}
+
+	if len(chart.Days) != 1 || chart.Days[0].Label != "Tue" || math.Abs(chart.Days[0].X-xAt(at(9, 0, 0))) > 0.01 {
+		t.Errorf("day markers = %+v; want Tue at midnight", chart.Days)
+	}
+	if first, last := chart.Curve[0], chart.Curve[len(chart.Curve)-1]; first.X != tideChartLeft || math.Abs(last.X-tideChartRight) > 0.01 {
+		t.Errorf("curve spans x=%.2f..%.2f; want the whole axis", first.X, last.X)
+	}
+
+	if _, rising, _ := tideHeightAt(sortedTidePredictions(predictions), at(8, 12, 0)); rising {
+		t.Error("tide after the 9 AM high should be falling")
+	}
+}
+
+func TestBuildTideChart_FollowsDenseCurve(t *testing.T) {
+	oldPast, oldFuture := tideWindowPast, tideWindowFuture
+	defer func() { tideWindowPast, tideWindowFuture = oldPast, oldFuture }()
+	tideWindowPast, tideWindowFuture = 6*time.Hour, 6*time.Hour
+
+	now := time.Date(2024, time.April, 8, 12, 0, 0, 0, time.UTC)
+	predictions := []TidePrediction{
+		{Time: now.Add(-6 * time.Hour), Type: "L", Height: 0.0},
+		{Time: now.Add(6 * time.Hour), Type: "H", Height: 4.0},
+	}
+	// A flood that rises steadily for eight hours and then stands, which
+	// the cosine between the low and high cannot show.
+	var curve TideCurve
+	for at := now.Add(-6 * time.Hour); !at.After(now.Add(6 * time.Hour)); at = at.Add(6 * time.Minute) {
+		height := math.Min(4, math.Max(0, at.Sub(now.Add(-6*time.Hour)).Hours()/2))
+		curve.Levels = append(curve.Levels, TideLevel{At: at, Height: height})
+	}
+
+	chart := buildTideChart(predictions, curve, nil, now)
+	if len(chart.Curve) != len(curve.Levels)+len(predictions) {
+		t.Fatalf("curve has %d points; want the %d dense levels plus the highs and lows", len(chart.Curve), len(curve.Levels))
+	}
+	if chart.Now == nil || chart.Now.Label != "3.0 ft ↑" {
+		t.Fatalf("now marker = %+v; want the dense height 3.0 ft", chart.Now)
+	}
+	if want := tideChartCurveBase - 0.75*(tideChartCurveBase-tideChartCurveTop); math.Abs(chart.Now.Y-want) > 0.01 {
+		t.Errorf("now marker y = %.2f; want %.2f", chart.Now.Y, want)
+	}
+
+	fallback := buildTideChart(predictions, TideCurve{}, nil, now)
+	if fallback.Now == nil || fallback.Now.Label != "2.0 ft ↑" {
+		t.Fatalf("fallback now marker = %+v; want the cosine height 2.0 ft", fallback.Now)
+	}
+}
+
+func TestGetTideCurve_CachesPerDayAndReportsFailures(t *testing.T) {
+	oldCache, oldNOAAURL, oldHTTPClient, oldInterval := tideCache, noaaAPIURL, httpClient, tideCurveInterval
+	defer func() {
+		tideCache, noaaAPIURL, httpClient, tideCurveInterval = oldCache, oldNOAAURL, oldHTTPClient, oldInterval
+	}()
+
+	var intervals []string
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		intervals = append(intervals, r.URL.Query().Get("interval"))
+		if failing {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"predictions":[{"t":"2024-04-08 12:00","v":"1.50"},{"t":"2024-04-08 12:06","v":"1.62"},{"t":"bad","v":"1"}]}`))
+	}))
+	defer server.Close()
+
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+	tideCurveInterval = "6"
+
+	for range 2 {
+		curve, err := getTideCurve(context.Background(), defaultLocation)
+		if err != nil {
+			t.Fatalf("getTideCurve() error = %v", err)
+		}
+		if len(curve.Levels) != 2 || curve.Levels[1].Height != 1.62 {
+			t.Fatalf("levels = %+v; want the two valid predictions", curve.Levels)
+		}
+	}
+	if len(intervals) != 1 || intervals[0] != "6" {
+		t.Fatalf("NOAA requests = %v; want one for interval 6", intervals)
+	}
+
+	tideCache.Flush()
+	failing = true
+	if _, err := getTideCurve(context.Background(), defaultLocation); err == nil {
+		t.Fatal("getTideCurve() expected an error when NOAA rejects the request")
+	}
+
+	tideCurveInterval = tideCurveIntervalNone
+	if curve, err := getTideCurve(context.Background(), defaultLocation); err != nil || len(curve.Levels) != 0 || len(intervals) != 2 {
+		t.Fatalf("disabled curve = %+v, %v after %d requests; want no request", curve, err, len(intervals))
+	}
+}
+
+func TestBuildTideChart_PredictionsOutsideWindowAreUnavailable(t *testing.T) {
+	old := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	chart := buildTideChart([]TidePrediction{
+		{Time: old, Type: "H", Height: 4.0},
+		{Time: old.Add(6 * time.Hour), Type: "L", Height: 0.0},
+	}, TideCurve{}, nil, old.AddDate(0, 0, 7))
+	svg, err := generateTideSVG(chart)
+	if err != nil {
+		t.Fatalf("generateTideSVG() error = %v", err)
+	}
+	if !strings.Contains(string(svg), "Tide data unavailable") {
+		t.Fatalf("stale predictions should render the fallback: %s", svg)
+	}
+}
+
 func TestPortraitTideChartHeightMatchesViewBox(t *testing.T) {
 	css, err := os.ReadFile("css/kindle.css")
 	if err != nil {
@@ -258,7 +511,7 @@ func TestPortraitTideChartHeightMatchesViewBox(t *testing.T) {
 }
 
 func TestGenerateTideSVG_NoPredictionsRendersFallback(t *testing.T) {
-	svg, err := generateTideSVG(nil)
+	svg, err := generateTideSVG(tideChart{})
 	if err != nil {
 		t.Fatalf("generateTideSVG() error = %v", err)
 	}
@@ -288,10 +541,10 @@ func TestFetchTideFromAPI_RetriesTransientFailure(t *testing.T) {
 	}))
 	defer server.Close()
 
-	noaaAPIURL = server.URL + "?station=8720218"
+	noaaAPIURL = server.URL
 	httpClient = server.Client()
 
-	data, err := fetchTideFromAPI(context.Background())
+	data, err := fetchTideFromAPI(context.Background(), defaultLocation)
 	if err != nil {
 		t.Fatalf("fetchTideFromAPI() error = %v", err)
 	}
@@ -303,8 +556,193 @@ func TestFetchTideFromAPI_RetriesTransientFailure(t *testing.T) {
 	}
 }
 
+func TestGetWeatherWithCache_FallsBackToLastGoodData(t *testing.T) {
+	oldProviders, oldCache := weatherProviders, weatherCache
+	defer func() { weatherProviders, weatherCache = oldProviders, oldCache }()
+
+	var hits int
+	weatherProviders = []WeatherProvider{stubWeatherProvider{name: weatherProviderOpenMeteo, hits: &hits}}
+	weatherCache = cache.New(time.Hour, time.Hour)
+
+	first, err := getWeatherWithCache(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("getWeatherWithCache() error = %v", err)
+	}
+
+	// Expire the normal entry and make the provider fail.
+	weatherCache.Delete("weather:" + defaultLocation.coordinatesKey())
+	weatherProviders = []WeatherProvider{stubWeatherProvider{name: weatherProviderOpenMeteo, err: fmt.Errorf("status code 503"), hits: &hits}}
+
+	stale, err := getWeatherWithCache(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("getWeatherWithCache() error = %v; want last good data", err)
+	}
+	if !stale.FetchedAt.Equal(first.FetchedAt) || stale.Current.Temp != first.Current.Temp {
+		t.Fatalf("stale data = %+v; want %+v", stale, first)
+	}
+	if hits != 2 {
+		t.Fatalf("provider hits = %d; want 2", hits)
+	}
+}
+
+func TestBuildDashboardPage_SlowSourceDegradesAlone(t *testing.T) {
+	oldProviders, oldWeatherCache, oldTideCache := weatherProviders, weatherCache, tideCache
+	oldNOAAURL, oldHTTPClient := noaaAPIURL, httpClient
+	defer func() {
+		weatherProviders, weatherCache, tideCache = oldProviders, oldWeatherCache, oldTideCache
+		noaaAPIURL, httpClient = oldNOAAURL, oldHTTPClient
+	}()
+
+	// NOAA never answers, so the tide fetches can only end at the deadline.
+	// They outlive the page, so release them and wait for them to finish
+	// before the server closes and the globals are restored.
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+			http.Error(w, "released", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	now := time.Now()
+	defer waitForUpstream("tide:"+tideCacheKey(now, defaultLocation), "tide:"+tideCurveCacheKey(now, defaultLocation), "tide:"+waterLevelCacheKey(defaultLocation))
+	defer close(release)
+
+	var hits int
+	weatherProviders = []WeatherProvider{stubWeatherProvider{name: weatherProviderOpenMeteo, hits: &hits}}
+	weatherCache = cache.New(time.Hour, time.Hour)
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	dashboard := Dashboard{Location: defaultLocation, Panels: Panels{Forecast: true, Tide: true}, AutoRefresh: time.Hour}
+
+	start := time.Now()
+	page, err := buildDashboardPage(ctx, r, dashboard)
+	if err != nil {
+		t.Fatalf("buildDashboardPage() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("buildDashboardPage() took %v; want it bounded by the request deadline", elapsed)
+	}
+	if page.Weather.Current.Temp != 72 {
+		t.Fatalf("weather temp = %v; want 72", page.Weather.Current.Temp)
+	}
+	if len(page.Tide.Predictions) != 0 {
+		t.Fatalf("tide predictions = %v; want none after the deadline", page.Tide.Predictions)
+	}
+	if !strings.Contains(string(page.TideSVG), "unavailable") {
+		t.Fatalf("tide SVG = %s; want the unavailable fallback", page.TideSVG)
+	}
+}
+
+// waitForUpstream waits for any shared fetch in flight under keys.
+func waitForUpstream(keys ...string) {
+	for _, key := range keys {
+		_, _, _ = upstreamFlights.Do(key, func() (any, error) { return nil, nil })
+	}
+}
+
+func TestShareUpstream_CallersKeepTheirOwnDeadlines(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	fetches := 0
+	fetch := func(ctx context.Context) (any, error) {
+		fetches++
+		close(started)
+		select {
+		case <-release:
+			return "tides", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The first caller starts the fetch and then gives up; the fetch must
+	// carry on for the second caller, whose deadline is longer.
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := shareUpstream(firstCtx, "test:shared", fetch)
+		firstErr <- err
+	}()
+	<-started
+
+	type outcome struct {
+		val any
+		err error
+	}
+	second := make(chan outcome, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		val, err := shareUpstream(ctx, "test:shared", fetch)
+		second <- outcome{val, err}
+	}()
+
+	// A third caller with a short deadline returns at its deadline rather
+	// than waiting on the shared fetch.
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	start := time.Now()
+	if _, err := shareUpstream(shortCtx, "test:shared", fetch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("short caller error = %v; want its own deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("short caller waited %v; want it bounded by its deadline", elapsed)
+	}
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller error = %v; want its own cancellation", err)
+	}
+
+	close(release)
+	got := <-second
+	if got.err != nil || got.val != "tides" {
+		t.Fatalf("second caller = %v, %v; want the shared result", got.val, got.err)
+	}
+	if fetches != 1 {
+		t.Fatalf("fetches = %d; want one shared fetch", fetches)
+	}
+}
+
+func TestWeatherStaleness(t *testing.T) {
+	oldExpiration := weatherCacheExpiration
+	defer func() { weatherCacheExpiration = oldExpiration }()
+	weatherCacheExpiration = time.Hour
+
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		age  time.Duration
+		want string
+	}{
+		{0, ""},
+		{59 * time.Minute, ""},
+		{61 * time.Minute, "updated 1h ago"},
+		{2*time.Hour + 40*time.Minute, "updated 2h ago"},
+		{50 * time.Hour, "updated 2d ago"},
+	} {
+		if got := weatherStaleness(now.Add(-tt.age), now); got != tt.want {
+			t.Errorf("weatherStaleness(%v old) = %q; want %q", tt.age, got, tt.want)
+		}
+	}
+	if got := weatherStaleness(time.Time{}, now); got != "" {
+		t.Errorf("weatherStaleness(zero) = %q; want empty", got)
+	}
+
+	weatherCacheExpiration = 10 * time.Minute
+	if got := weatherStaleness(now.Add(-30*time.Minute), now); got != "updated 30m ago" {
+		t.Errorf("weatherStaleness(30m old) = %q; want updated 30m ago", got)
+	}
+}
+
 func TestFormatLaunchTime(t *testing.T) {
-	got, err := formatLaunchTime("2024-04-18T20:30:00Z")
+	tz := defaultLocation.timeLocation()
+	got, err := formatLaunchTime("2024-04-18T20:30:00Z", tz)
 	if err != nil {
 		t.Fatalf("formatLaunchTime() unexpected error: %v", err)
 	}
@@ -313,14 +751,14 @@ func TestFormatLaunchTime(t *testing.T) {
 		t.Fatalf("formatLaunchTime() = %q, want %q", got, "4:30pm")
 	}
 
-	if _, err := formatLaunchTime("not-a-time"); err == nil {
+	if _, err := formatLaunchTime("not-a-time", tz); err == nil {
 		t.Fatal("formatLaunchTime() expected error for invalid timestamp")
 	}
 }
 
 func TestBuildTodayKennedyLaunchURL(t *testing.T) {
 	now := time.Date(2024, time.April, 18, 15, 0, 0, 0, time.UTC)
-	launchURL, err := buildTodayKennedyLaunchURL(now)
+	launchURL, err := buildTodayKennedyLaunchURL(now, defaultLocation)
 	if err != nil {
 		t.Fatalf("buildTodayKennedyLaunchURL() error = %v", err)
 	}
@@ -342,6 +780,74 @@ func TestBuildTodayKennedyLaunchURL(t *testing.T) {
 	}
 }
 
+func TestBuildTodayKennedyLaunchURL_UsesLocationZoneAndSite(t *testing.T) {
+	loc := defaultLocation
+	loc.Timezone = "America/Los_Angeles"
+	loc.LaunchLocationID = 11
+
+	now := time.Date(2024, time.April, 18, 15, 0, 0, 0, time.UTC)
+	launchURL, err := buildTodayKennedyLaunchURL(now, loc)
+	if err != nil {
+		t.Fatalf("buildTodayKennedyLaunchURL() error = %v", err)
+	}
+
+	parsedURL, err := url.Parse(launchURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	q := parsedURL.Query()
+	if got := q.Get("net__gte"); got != "2024-04-18T07:00:00Z" {
+		t.Fatalf("net__gte = %q; want %q", got, "2024-04-18T07:00:00Z")
+	}
+	if got := q.Get("location__ids"); got != "11" {
+		t.Fatalf("location__ids = %q; want %q", got, "11")
+	}
+}
+
+func TestBuildWeatherURL(t *testing.T) {
+	weatherURL, err := buildWeatherURL(weatherAPIURLDefault, "secret", Location{Latitude: 41.5, Longitude: -70.25})
+	if err != nil {
+		t.Fatalf("buildWeatherURL() error = %v", err)
+	}
+
+	parsedURL, err := url.Parse(weatherURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	q := parsedURL.Query()
+	for key, want := range map[string]string{
+		"lat":     "41.5",
+		"lon":     "-70.25",
+		"appid":   "secret",
+		"units":   "imperial",
+		"exclude": "minutely",
+	} {
+		if got := q.Get(key); got != want {
+			t.Fatalf("%s = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestBuildTideURL_UsesStation(t *testing.T) {
+	begin := time.Date(2024, time.April, 7, 0, 0, 0, 0, time.UTC)
+	tideURL, err := buildTideURL(noaaAPIURLDefault, "8665530", "hilo", begin, begin.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("buildTideURL() error = %v", err)
+	}
+
+	parsedURL, err := url.Parse(tideURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	query := parsedURL.Query()
+	if got := query.Get("station"); got != "8665530" {
+		t.Fatalf("station = %q; want %q", got, "8665530")
+	}
+	if query.Get("begin_date") != "20240407" || query.Get("end_date") != "20240410" || query.Has("date") {
+		t.Fatalf("date range = %q..%q (date %q); want 20240407..20240410", query.Get("begin_date"), query.Get("end_date"), query.Get("date"))
+	}
+}
+
 func TestBuildAutoRefreshURL_PreservesQuery(t *testing.T) {
 	req := httptest.NewRequest("GET", "/?h&foo=bar", nil)
 	refreshURL := buildAutoRefreshURL(req, 12345)
@@ -381,6 +887,176 @@ func TestIndexTemplate_NoLaunchHasNoLaunchMarkupAndMoonUsesIconFont(t *testing.T
 	}
 }
 
+func TestIndexTemplate_ShowsWeatherSource(t *testing.T) {
+	rendered := renderIndexTemplate(t, nil)
+
+	if !strings.Contains(rendered, `<div id="source">Open-Meteo</div>`) {
+		t.Fatalf("expected weather source in footer: %s", rendered)
+	}
+}
+
+func TestIndexTemplate_StalenessIndicatorIsConditional(t *testing.T) {
+	if rendered := renderIndexTemplate(t, nil); strings.Contains(rendered, `id="updated"`) {
+		t.Fatalf("expected no staleness indicator for fresh data: %s", rendered)
+	}
+
+	var buf bytes.Buffer
+	data := dashboardPage{
+		Panels: Panels{},
+		Weather: WeatherData{
+			Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+			Daily:   []DailyWeather{{Summary: "Clear skies"}},
+		},
+		WeatherUpdated: "updated 2h ago",
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("tmpl.Execute() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `<div id="updated">updated 2h ago</div>`) {
+		t.Fatalf("expected staleness indicator: %s", buf.String())
+	}
+}
+
+func TestIndexTemplate_SurfOutlook(t *testing.T) {
+	render := func(panels Panels, outlook []SurfDay) string {
+		t.Helper()
+		var buf bytes.Buffer
+		data := dashboardPage{
+			Panels: panels,
+			Weather: WeatherData{
+				Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+				Daily:   []DailyWeather{{Summary: "Clear skies"}},
+			},
+			SurfOutlook: outlook,
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatalf("tmpl.Execute() error = %v", err)
+		}
+		return buf.String()
+	}
+	outlook := []SurfDay{{Label: "Today", Rating: 2}, {Label: "Sat", Rating: 4}}
+
+	rendered := render(Panels{Surf: true}, outlook)
+	if !strings.Contains(rendered, `id="surf-outlook"`) || !strings.Contains(rendered, `aria-label="Sat 4 of 5"`) {
+		t.Fatalf("expected the surf outlook row: %s", rendered)
+	}
+	if got := strings.Count(rendered, `class="surf-dot on"`); got != 6 {
+		t.Fatalf("filled dots = %d; want 6", got)
+	}
+	if got := strings.Count(rendered, `class="surf-dot"`); got != 4 {
+		t.Fatalf("empty dots = %d; want 4", got)
+	}
+
+	if rendered := render(Panels{}, outlook); strings.Contains(rendered, `id="surf-outlook"`) {
+		t.Fatalf("expected no outlook with the surf panel off: %s", rendered)
+	}
+	if rendered := render(Panels{Surf: true}, nil); strings.Contains(rendered, `id="surf-outlook"`) {
+		t.Fatalf("expected no outlook without surf data: %s", rendered)
+	}
+}
+
+func TestIndexTemplate_StormIsConditional(t *testing.T) {
+	render := func(storm *NearbyStorm) string {
+		t.Helper()
+		var buf bytes.Buffer
+		data := dashboardPage{
+			Panels: Panels{Storm: true},
+			Weather: WeatherData{
+				Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+				Daily:   []DailyWeather{{Summary: "Clear skies"}},
+			},
+			Storm: storm,
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatalf("tmpl.Execute() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	if rendered := render(nil); strings.Contains(rendered, `id="storm"`) {
+		t.Fatalf("expected no storm markup without active storms: %s", rendered)
+	}
+	storm := &NearbyStorm{Storm: Storm{Name: "Ernesto", Classification: "HU", WindKnots: 85}, DistanceMiles: 412, BearingDeg: 140}
+	rendered := render(storm)
+	for _, want := range []string{`id="storm"`, `class="wi wi-hurricane"`, "Hurricane Ernesto, Cat 2, 412 mi SE"} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected rendered storm to contain %q: %s", want, rendered)
+		}
+	}
+}
+
+func TestIndexTemplate_DailyForecast(t *testing.T) {
+	var buf bytes.Buffer
+	data := dashboardPage{
+		Panels: Panels{Forecast: true, Daily: true},
+		Weather: WeatherData{
+			Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+			Daily:   []DailyWeather{{Summary: "Clear skies"}},
+		},
+		ForecastDays: []ForecastDay{
+			{Label: "Today", Icon: "wi wi-owm-day-500", Low: "74°", Pop: 40, Wind: "SW 12"},
+			{Label: "Sat", Icon: "wi wi-owm-day-800", High: "90°", Low: "75°", Pop: 0, Wind: "Calm"},
+		},
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("tmpl.Execute() error = %v", err)
+	}
+	rendered := buf.String()
+	for _, want := range []string{
+		`class="forecast with-daily"`,
+		`id="daily-forecast"`,
+		`<div class="daily-head">Today <i class="wi wi-owm-day-500" aria-hidden="true"></i></div>`,
+		`<div class="daily-temps">90° 75°</div>`,
+		`<div class="daily-detail">40% · SW 12</div>`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected rendered daily forecast to contain %q: %s", want, rendered)
+		}
+	}
+}
+
+func TestIndexTemplate_HourlyDetails(t *testing.T) {
+	render := func(details HourlyDetails) string {
+		t.Helper()
+		var buf bytes.Buffer
+		data := dashboardPage{
+			Panels: Panels{Forecast: true},
+			Weather: WeatherData{
+				Current: CurrentWeather{Weather: []WeatherCondition{{Icon: "01d", ID: 800}}},
+				Daily:   []DailyWeather{{Summary: "Clear skies"}},
+			},
+			ForecastHours: []HourlyWeather{
+				{DtFormatted: "4:00 PM", Temp: 84, Pop: 30, WindSpeed: 12, WindDeg: 225, Uvi: 6, Weather: []WeatherCondition{{Icon: "10d", ID: 500, Description: "light rain"}}},
+			},
+			HourlyDetails: details,
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatalf("tmpl.Execute() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	rendered := render(HourlyDetails{Description: true})
+	if !strings.Contains(rendered, `<div class="colDesc">light rain</div>`) || strings.Contains(rendered, "colDetails") {
+		t.Fatalf("expected only the description by default: %s", rendered)
+	}
+
+	rendered = render(HourlyDetails{Rain: true, Wind: true, UV: true})
+	for _, want := range []string{
+		`class="forecast with-details"`,
+		`class="wi wi-raindrop" aria-hidden="true"></i> 30%`,
+		`transform="rotate(45 8 8)"></path></svg> 12`,
+		"UV 6",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected rendered hourly details to contain %q: %s", want, rendered)
+		}
+	}
+	if strings.Contains(rendered, "colDesc") {
+		t.Fatalf("expected the description to be hidden: %s", rendered)
+	}
+}
+
 func TestIndexTemplate_LaunchPreviewRendersIconAndTime(t *testing.T) {
 	rendered := renderIndexTemplate(t, &LaunchInfo{Scheduled: "4:30pm"})
 
@@ -400,8 +1076,8 @@ func TestIndexTemplate_BeachStatusIsConditional(t *testing.T) {
 		t.Fatalf("expected no beach status without a message: %s", rendered)
 	}
 
-	surf := renderIndexTemplateWithBeachStatus(t, &BeachStatus{Kind: "surf", Text: "Good surf today"})
-	for _, want := range []string{`id="beach-status"`, `class="surfboard-icon"`, `viewBox="0 0 44 24"`, "Good surf today"} {
+	surf := renderIndexTemplateWithBeachStatus(t, &BeachStatus{Kind: "surf", Text: "Best 7–10 AM, 3 ft @ 9 s, offshore"})
+	for _, want := range []string{`id="beach-status"`, `class="surfboard-icon"`, `viewBox="0 0 44 24"`, "Best 7–10 AM, 3 ft @ 9 s, offshore"} {
 		if !strings.Contains(surf, want) {
 			t.Fatalf("expected rendered surf status to contain %q: %s", want, surf)
 		}
@@ -411,8 +1087,18 @@ func TestIndexTemplate_BeachStatusIsConditional(t *testing.T) {
 	if !strings.Contains(tide, "Super low tide at 1:45 PM") {
 		t.Fatalf("expected rendered tide status: %s", tide)
 	}
-	if strings.Contains(tide, `class="surfboard-icon"`) {
-		t.Fatalf("expected tide status not to use the surf icon: %s", tide)
+	if strings.Contains(tide, `class="surfboard-icon"`) || strings.Contains(tide, "wi-small-craft-advisory") {
+		t.Fatalf("expected tide status not to use an icon: %s", tide)
+	}
+
+	hazard := renderIndexTemplateWithBeachStatus(t, &BeachStatus{Kind: "hazard", Text: "High rip current risk"})
+	if !strings.Contains(hazard, `class="hazard"`) || !strings.Contains(hazard, `wi wi-small-craft-advisory`) {
+		t.Fatalf("expected rendered hazard status with the warning flag: %s", hazard)
+	}
+
+	warning := renderIndexTemplateWithBeachStatus(t, &BeachStatus{Kind: "warning", Text: "Tropical Storm Warning until Thu 8 PM"})
+	if !strings.Contains(warning, `class="warning"`) || !strings.Contains(warning, `wi wi-small-craft-advisory`) {
+		t.Fatalf("expected rendered warning banner with the warning flag: %s", warning)
 	}
 }
 
@@ -427,18 +1113,9 @@ func renderIndexTemplateWithBeachStatus(t *testing.T, status *BeachStatus) strin
 func renderIndexTemplateData(t *testing.T, launch *LaunchInfo, status *BeachStatus) string {
 	t.Helper()
 
-	data := struct {
-		Weather            WeatherData
-		Tide               TideData
-		TideSVG            template.HTML
-		ForecastHours      []HourlyWeather
-		MoonPhaseIcon      string
-		Horizontal         bool
-		KennedyLaunch      *LaunchInfo
-		BeachStatus        *BeachStatus
-		AutoRefreshSeconds int
-		AutoRefreshURL     string
-	}{
+	data := dashboardPage{
+		Location: defaultLocation,
+		Panels:   allPanels,
 		Weather: WeatherData{
 			Current: CurrentWeather{
 				Temp:             72,
@@ -454,6 +1131,7 @@ func renderIndexTemplateData(t *testing.T, launch *LaunchInfo, status *BeachStat
 		BeachStatus:        status,
 		AutoRefreshSeconds: 1800,
 		AutoRefreshURL:     "/",
+		WeatherSource:      "Open-Meteo",
 	}
 
 	var buf bytes.Buffer
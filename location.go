@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+const kennedyLaunchLocationID = 27
+
+// Location describes the place a dashboard reports on. Every upstream fetch
+// is parameterised by it, so nothing else should hard-code coordinates.
+// NWSZone is the NWS forecast zone beach hazards are read for, such as
+// FLZ133; empty uses the zone the NWS assigns to the coordinates.
+type Location struct {
+	Name             string      `yaml:"name"`
+	Latitude         float64     `yaml:"latitude"`
+	Longitude        float64     `yaml:"longitude"`
+	Timezone         string      `yaml:"timezone"`
+	TideStation      string      `yaml:"tide_station"`
+	LaunchLocationID int         `yaml:"launch_location_id"`
+	NWSZone          string      `yaml:"nws_zone"`
+	Surf             SurfProfile `yaml:"surf"`
+}
+
+var defaultLocation = Location{
+	Name:             "Crescent Beach",
+	Latitude:         29.65,
+	Longitude:        -81.20,
+	Timezone:         "America/New_York",
+	TideStation:      "8720218",
+	LaunchLocationID: kennedyLaunchLocationID,
+	Surf:             defaultSurfProfile,
+}
+
+// timeLocation returns the location's zone. Timezones are validated at
+// startup, so the UTC fallback only guards against a missing tzdata.
+func (l Location) timeLocation() *time.Location {
+	if loc, err := time.LoadLocation(l.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func (l Location) latitudeParam() string {
+	return strconv.FormatFloat(l.Latitude, 'f', -1, 64)
+}
+
+func (l Location) longitudeParam() string {
+	return strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+}
+
+// coordinatesKey identifies the location for coordinate-based caches, so
+// dashboards at the same place share cached forecasts.
+func (l Location) coordinatesKey() string {
+	return l.latitudeParam() + "," + l.longitudeParam()
+}
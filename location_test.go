@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig_Location(t *testing.T) {
+	t.Setenv("LOCATION_NAME", "Ocean Beach")
+	t.Setenv("LOCATION_LATITUDE", "37.76")
+	t.Setenv("LOCATION_LONGITUDE", "-122.51")
+	t.Setenv("LOCATION_TIMEZONE", "America/Los_Angeles")
+	t.Setenv("NOAA_TIDE_STATION", "9414290")
+	t.Setenv("LAUNCH_LOCATION_ID", "11")
+	t.Setenv("NWS_ZONE", "CAZ509")
+	t.Setenv("SURF_MIN_SWELL_DIRECTION_DEGREES", "200")
+	t.Setenv("SURF_MAX_SWELL_DIRECTION_DEGREES", "330")
+	t.Setenv("SURF_TIDE_PHASES", "mid,rising")
+	t.Setenv("SURF_MAX_TIDE_FEET", "2.5")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	loc := cfg.Location
+	surf := defaultSurfProfile
+	surf.MinSwellDirectionDegrees = 200
+	surf.MaxSwellDirectionDegrees = 330
+	surf.TidePhases = []string{"mid", "rising"}
+	maxTide := 2.5
+	surf.MaxTideFeet = &maxTide
+	want := Location{
+		Name:             "Ocean Beach",
+		Latitude:         37.76,
+		Longitude:        -122.51,
+		Timezone:         "America/Los_Angeles",
+		TideStation:      "9414290",
+		LaunchLocationID: 11,
+		NWSZone:          "CAZ509",
+		Surf:             surf,
+	}
+	if !reflect.DeepEqual(loc, want) {
+		t.Fatalf("location = %+v; want %+v", loc, want)
+	}
+}
+
+func TestLoadConfig_Location_DefaultsToCrescentBeach(t *testing.T) {
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	loc := cfg.Location
+	if !reflect.DeepEqual(loc, defaultLocation) {
+		t.Fatalf("location = %+v; want %+v", loc, defaultLocation)
+	}
+}
+
+func TestLoadConfig_Location_RejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"LOCATION_LATITUDE", "north"},
+		{"LOCATION_LATITUDE", "91"},
+		{"LOCATION_LONGITUDE", "-181"},
+		{"LOCATION_TIMEZONE", "Mars/Olympus_Mons"},
+		{"LAUNCH_LOCATION_ID", "kennedy"},
+		{"NWS_ZONE", "flz133"},
+		{"SURF_MIN_TIDE_FEET", "low"},
+		{"SURF_TIDE_PHASES", "slack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := loadConfig(""); err == nil {
+				t.Fatalf("loadConfig() expected error for %s=%s", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestMatchesLaunchSite(t *testing.T) {
+	kennedy := LaunchData{Pad: LaunchPad{Location: LaunchLocation{Name: "Kennedy Space Center, FL, USA"}}}
+	vandenberg := LaunchData{Pad: LaunchPad{Location: LaunchLocation{Name: "Vandenberg SFB, CA, USA"}}}
+
+	if !matchesLaunchSite(kennedy, defaultLocation) {
+		t.Fatal("expected Kennedy launch to match the default site")
+	}
+	if matchesLaunchSite(vandenberg, defaultLocation) {
+		t.Fatal("expected non-Kennedy launch to be filtered for the default site")
+	}
+
+	other := defaultLocation
+	other.LaunchLocationID = 11
+	if !matchesLaunchSite(vandenberg, other) {
+		t.Fatal("expected launches at a configured site to match")
+	}
+}
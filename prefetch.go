@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// prefetchTimeout bounds a single background refresh. It is longer than
+	// a page request can afford because nobody is waiting on it.
+	prefetchTimeout = 30 * time.Second
+	// prefetchRetryBase is the first retry delay after a failed refresh; it
+	// doubles per consecutive failure up to the normal refresh interval.
+	prefetchRetryBase = 30 * time.Second
+	// prefetchMinInterval stops very short cache expiries from turning the
+	// prefetcher into a busy loop.
+	prefetchMinInterval = 10 * time.Second
+	prefetchJitter      = 0.1
+)
+
+var (
+	prefetchRefreshTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "prefetch_refresh_total",
+		Help: "Background cache refreshes by source and result",
+	}, []string{"source", "result"})
+
+	prefetchRefreshLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prefetch_refresh_lag_seconds",
+		Help:    "Time from when a background refresh was due until it finished",
+		Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	}, []string{"source"})
+
+	prefetchLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prefetch_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful background refresh by source",
+	}, []string{"source"})
+)
+
+// prefetchJob keeps one cache entry warm: the weather for one set of
+// coordinates, the tides for one station, and so on. Dashboards that share
+// an upstream call share a job.
+type prefetchJob struct {
+	source   string
+	key      string
+	interval time.Duration
+	refresh  func(ctx context.Context) error
+}
+
+// prefetchJobs lists the refreshes needed to keep every dashboard's enabled
+// panels warm. Each job runs ahead of its cache expiry by the longest
+// auto-refresh period of the dashboards using it, so a Kindle reloading on
+// schedule always finds a live entry rather than triggering a fetch.
+func prefetchJobs(boards []Dashboard, cacheCfg CacheConfig) []prefetchJob {
+	jobs := map[string]*prefetchJob{}
+	add := func(source, key string, expiration, autoRefresh time.Duration, refresh func(ctx context.Context) error) {
+		id := source + ":" + key
+		interval := prefetchInterval(expiration, autoRefresh)
+		if job, ok := jobs[id]; ok {
+			job.interval = min(job.interval, interval)
+			return
+		}
+		jobs[id] = &prefetchJob{source: source, key: key, interval: interval, refresh: refresh}
+	}
+
+	for _, dashboard := range boards {
+		loc := dashboard.Location
+		panels := dashboard.Panels
+		autoRefresh := dashboard.AutoRefresh
+
+		add("weather", loc.coordinatesKey(), secondsDuration(cacheCfg.WeatherSeconds), autoRefresh, func(ctx context.Context) error {
+			_, err := refreshWeather(ctx, loc)
+			return err
+		})
+		if panels.Tide || panels.Beach || (panels.Surf && loc.Surf.usesTide()) {
+			add("tide", loc.TideStation+":"+loc.Timezone, secondsDuration(cacheCfg.TideSeconds), autoRefresh, func(ctx context.Context) error {
+				_, err := refreshTide(ctx, loc)
+				return err
+			})
+		}
+		if needsTideCurve(panels, loc) && tideCurveInterval != tideCurveIntervalNone {
+			add("tide curve", loc.TideStation+":"+loc.Timezone, secondsDuration(cacheCfg.TideSeconds), autoRefresh, func(ctx context.Context) error {
+				_, err := refreshTideCurve(ctx, loc)
+				return err
+			})
+		}
+		if (panels.Tide || panels.Beach) && showObservedWaterLevel {
+			add("water level", loc.TideStation, waterLevelCacheExpiration, autoRefresh, func(ctx context.Context) error {
+				_, err := refreshWaterLevel(ctx, loc)
+				return err
+			})
+		}
+		if panels.Water {
+			add("station sensors", loc.TideStation, stationSensorCacheExpiration, autoRefresh, func(ctx context.Context) error {
+				_, err := refreshStationSensors(ctx, loc)
+				return err
+			})
+		}
+		if panels.Launch {
+			add("launch", fmt.Sprintf("%d:%s", loc.LaunchLocationID, loc.Timezone), secondsDuration(cacheCfg.LaunchSeconds), autoRefresh, func(ctx context.Context) error {
+				_, err := refreshTodayLaunch(ctx, loc)
+				return err
+			})
+		}
+		if panels.Beach || panels.Surf {
+			add("surf", loc.coordinatesKey(), secondsDuration(cacheCfg.SurfSeconds), autoRefresh, func(ctx context.Context) error {
+				_, err := refreshSurfForecast(ctx, loc)
+				return err
+			})
+		}
+		if panels.Storm {
+			add("storms", "nhc", stormCacheExpiration, autoRefresh, func(ctx context.Context) error {
+				_, err := refreshStorms(ctx)
+				return err
+			})
+		}
+		if panels.Beach {
+			add("beach hazards", beachHazardsKey(loc), beachHazardCacheExpiration, autoRefresh, func(ctx context.Context) error {
+				_, err := refreshBeachHazards(ctx, loc)
+				return err
+			})
+		}
+	}
+
+	result := make([]prefetchJob, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, *job)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].source != result[j].source {
+			return result[i].source < result[j].source
+		}
+		return result[i].key < result[j].key
+	})
+	return result
+}
+
+// prefetchInterval refreshes one auto-refresh period before the entry would
+// expire, but never later than halfway through its lifetime.
+func prefetchInterval(expiration, autoRefresh time.Duration) time.Duration {
+	lead := min(autoRefresh, expiration/2)
+	return max(expiration-lead, prefetchMinInterval)
+}
+
+// startPrefetcher runs every job in the background until ctx is cancelled.
+// The returned WaitGroup finishes once all of them have stopped.
+func startPrefetcher(ctx context.Context, jobs []prefetchJob) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.run(ctx)
+		}()
+	}
+	return &wg
+}
+
+func (j prefetchJob) run(ctx context.Context) {
+	// Spread the first refreshes out a little so startup does not hit
+	// every upstream at the same instant.
+	due := time.Now().Add(jitter(2*time.Second, 1))
+	failures := 0
+	for {
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		refreshCtx, cancel := context.WithTimeout(ctx, prefetchTimeout)
+		err := j.refresh(refreshCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		prefetchRefreshLag.WithLabelValues(j.source).Observe(time.Since(due).Seconds())
+
+		if err != nil {
+			failures++
+			prefetchRefreshTotal.WithLabelValues(j.source, "error").Inc()
+			wait := prefetchBackoff(failures, j.interval)
+			logJSON(logEntry{
+				Timestamp: time.Now().Format(time.RFC3339),
+				Level:     "WARN",
+				Message:   fmt.Sprintf("Background %s refresh for %s failed, retrying in %s: %v", j.source, j.key, wait.Round(time.Second), err),
+			})
+			due = time.Now().Add(wait)
+			continue
+		}
+
+		failures = 0
+		prefetchRefreshTotal.WithLabelValues(j.source, "success").Inc()
+		prefetchLastSuccess.WithLabelValues(j.source).SetToCurrentTime()
+		due = time.Now().Add(jitter(j.interval, prefetchJitter))
+	}
+}
+
+// prefetchBackoff doubles the retry delay per consecutive failure, capped at
+// the normal interval. The cache keeps serving the last good data meanwhile.
+func prefetchBackoff(failures int, interval time.Duration) time.Duration {
+	wait := prefetchRetryBase
+	for i := 1; i < failures && wait < interval; i++ {
+		wait *= 2
+	}
+	return jitter(min(wait, interval), prefetchJitter)
+}
+
+// jitter returns d adjusted by up to ±fraction of itself.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestPrefetchJobs_SharesJobsAndSkipsDisabledPanels(t *testing.T) {
+	home := Dashboard{Location: defaultLocation, Panels: everyPanel, AutoRefresh: 30 * time.Minute}
+	kids := Dashboard{Name: "kids", Location: defaultLocation, Panels: Panels{Forecast: true, Moon: true}, AutoRefresh: 10 * time.Minute}
+	away := defaultLocation
+	away.Latitude = 35.6
+	away.TideStation = "8720587"
+	grandma := Dashboard{Name: "grandma", Location: away, Panels: Panels{Tide: true, Storm: true}, AutoRefresh: time.Hour}
+
+	cacheCfg := defaultConfig().Cache
+	jobs := prefetchJobs([]Dashboard{home, kids, grandma}, cacheCfg)
+
+	var got []string
+	intervals := map[string]time.Duration{}
+	for _, job := range jobs {
+		id := job.source + " " + job.key
+		got = append(got, id)
+		intervals[id] = job.interval
+	}
+	want := []string{
+		"beach hazards 29.65,-81.2",
+		"launch 27:America/New_York",
+		"station sensors 8720218",
+		"storms nhc",
+		"surf 29.65,-81.2",
+		"tide 8720218:America/New_York",
+		"tide 8720587:America/New_York",
+		"tide curve 8720218:America/New_York",
+		"tide curve 8720587:America/New_York",
+		"water level 8720218",
+		"water level 8720587",
+		"weather 29.65,-81.2",
+		"weather 35.6,-81.2",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("jobs = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("jobs = %v; want %v", got, want)
+		}
+	}
+
+	// A shared job refreshes early enough for the dashboard that reloads
+	// least often.
+	if got := intervals["weather 29.65,-81.2"]; got != 30*time.Minute {
+		t.Errorf("shared weather interval = %v; want 30m", got)
+	}
+	if got := intervals["tide 8720587:America/New_York"]; got != 15*time.Minute {
+		t.Errorf("grandma tide interval = %v; want 15m", got)
+	}
+}
+
+func TestPrefetchJobs_SurfTidePreferenceFetchesCurve(t *testing.T) {
+	loc := defaultLocation
+	loc.Surf = defaultSurfProfile.clone()
+	loc.Surf.TidePhases = []string{"mid"}
+	surf := Dashboard{Location: loc, Panels: Panels{Surf: true}, AutoRefresh: time.Hour}
+
+	var curve bool
+	for _, job := range prefetchJobs([]Dashboard{surf}, defaultConfig().Cache) {
+		curve = curve || job.source == "tide curve"
+	}
+	if !curve {
+		t.Fatal("no tide curve job; surf ratings with tide preferences read heights from it")
+	}
+}
+
+func TestPrefetchJobs_BeachSurgeFetchesCurve(t *testing.T) {
+	oldObserved := showObservedWaterLevel
+	defer func() { showObservedWaterLevel = oldObserved }()
+
+	beach := Dashboard{Location: defaultLocation, Panels: Panels{Beach: true}, AutoRefresh: time.Hour}
+	for _, observed := range []bool{true, false} {
+		showObservedWaterLevel = observed
+		var curve bool
+		for _, job := range prefetchJobs([]Dashboard{beach}, defaultConfig().Cache) {
+			curve = curve || job.source == "tide curve"
+		}
+		if curve != observed {
+			t.Fatalf("observed level %v: tide curve job = %v; want it only when the surge is measured", observed, curve)
+		}
+	}
+}
+
+func TestPrefetchJob_RefreshOutlastsThePageBudget(t *testing.T) {
+	oldTimeout, oldCache, oldURL, oldClient := dashboardFetchTimeout, tideCache, noaaAPIURL, httpClient
+	defer func() {
+		dashboardFetchTimeout, tideCache, noaaAPIURL, httpClient = oldTimeout, oldCache, oldURL, oldClient
+	}()
+
+	// NOAA answers after a page would have given up.
+	dashboardFetchTimeout = 50 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(4 * dashboardFetchTimeout)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"predictions":[{"t":"2024-01-01 13:45","type":"H","v":"4.2"}]}`))
+	}))
+	defer server.Close()
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+
+	dashboard := Dashboard{Location: defaultLocation, Panels: Panels{Tide: true}, AutoRefresh: time.Hour}
+	for _, job := range prefetchJobs([]Dashboard{dashboard}, defaultConfig().Cache) {
+		if job.source != "tide" {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+		defer cancel()
+		if err := job.refresh(ctx); err != nil {
+			t.Fatalf("tide refresh error = %v; want it to run past the page budget", err)
+		}
+		if _, found := tideCache.Get(tideCacheKey(time.Now(), defaultLocation)); !found {
+			t.Fatal("tide refresh did not fill the cache")
+		}
+		return
+	}
+	t.Fatal("no tide job")
+}
+
+func TestPrefetchInterval(t *testing.T) {
+	for _, tt := range []struct {
+		expiration, autoRefresh, want time.Duration
+	}{
+		{time.Hour, 30 * time.Minute, 30 * time.Minute},
+		{time.Hour, 5 * time.Minute, 55 * time.Minute},
+		{30 * time.Minute, time.Hour, 15 * time.Minute},
+		{5 * time.Second, time.Minute, prefetchMinInterval},
+	} {
+		if got := prefetchInterval(tt.expiration, tt.autoRefresh); got != tt.want {
+			t.Errorf("prefetchInterval(%v, %v) = %v; want %v", tt.expiration, tt.autoRefresh, got, tt.want)
+		}
+	}
+}
+
+func TestPrefetchBackoff(t *testing.T) {
+	within := func(got, want time.Duration) bool {
+		spread := time.Duration(float64(want) * prefetchJitter)
+		return got >= want-spread && got <= want+spread
+	}
+	for _, tt := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, prefetchRetryBase},
+		{2, 2 * prefetchRetryBase},
+		{3, 4 * prefetchRetryBase},
+		{10, 10 * time.Minute},
+	} {
+		if got := prefetchBackoff(tt.failures, 10*time.Minute); !within(got, tt.want) {
+			t.Errorf("prefetchBackoff(%d) = %v; want about %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// stationSensorCacheExpiration matches the six-minute cadence the
+	// station sensors report at.
+	stationSensorCacheExpiration = 6 * time.Minute
+	// stationSensorMaxAge hides readings from a sensor that has stopped
+	// reporting; NOAA's "latest" is the last reading however old it is.
+	stationSensorMaxAge = 3 * time.Hour
+)
+
+// stationSensorProducts are the CO-OPS products read from the tide station
+// besides the tides themselves.
+var stationSensorProducts = []string{"water_temperature", "air_pressure", "wind"}
+
+// StationSensors holds the latest readings from the tide station's
+// meteorological sensors. A field is nil when the station has no such
+// sensor or it did not answer.
+type StationSensors struct {
+	WaterTemperature *SensorReading // °F
+	AirPressure      *SensorReading // mb
+	Wind             *WindReading
+}
+
+type SensorReading struct {
+	At    time.Time
+	Value float64
+}
+
+// WindReading is in knots, the unit NOAA reports wind in for english units.
+type WindReading struct {
+	At        time.Time
+	Speed     float64
+	Gust      float64
+	Direction float64
+	Compass   string
+}
+
+// noaaSensorData covers the CO-OPS meteorological products: v for a single
+// value, s/d/dr/g for wind.
+type noaaSensorData struct {
+	Data []struct {
+		Time      string `json:"t"`
+		Value     string `json:"v"`
+		Speed     string `json:"s"`
+		Direction string `json:"d"`
+		Compass   string `json:"dr"`
+		Gust      string `json:"g"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// getStationSensors returns the station readings from the cache or NOAA.
+func getStationSensors(ctx context.Context, loc Location) (StationSensors, error) {
+	if cachedData, found := tideCache.Get(stationSensorCacheKey(loc)); found {
+		return cachedData.(StationSensors), nil
+	}
+	return refreshStationSensors(ctx, loc)
+}
+
+func stationSensorCacheKey(loc Location) string {
+	return loc.TideStation + ":sensors"
+}
+
+// refreshStationSensors fetches the station readings and caches them,
+// ignoring any cached entry.
+func refreshStationSensors(ctx context.Context, loc Location) (StationSensors, error) {
+	cacheKey := stationSensorCacheKey(loc)
+	result, err := shareUpstream(ctx, "tide:"+cacheKey, func(ctx context.Context) (any, error) {
+		sensors, err := fetchStationSensorsFromAPI(ctx, loc)
+		if err != nil {
+			return StationSensors{}, err
+		}
+		tideCache.Set(cacheKey, sensors, stationSensorCacheExpiration)
+		return sensors, nil
+	})
+	if err != nil {
+		return StationSensors{}, err
+	}
+	return result.(StationSensors), nil
+}
+
+// fetchStationSensorsFromAPI reads every sensor product at once. Few
+// stations carry all of them, so a product that fails only leaves its field
+// empty; the fetch fails when none of them answers.
+func fetchStationSensorsFromAPI(ctx context.Context, loc Location) (StationSensors, error) {
+	tz := loc.timeLocation()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sensors StationSensors
+		errs    []error
+	)
+	for _, product := range stationSensorProducts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			raw, err := fetchStationSensor(ctx, loc.TideStation, product)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				err = sensors.apply(product, raw, tz)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == len(stationSensorProducts) {
+		return StationSensors{}, errors.Join(errs...)
+	}
+	return sensors, nil
+}
+
+func fetchStationSensor(ctx context.Context, station, product string) (noaaSensorData, error) {
+	sensorURL, err := buildStationSensorURL(noaaAPIURL, station, product)
+	if err != nil {
+		return noaaSensorData{}, err
+	}
+	var rawData noaaSensorData
+	if err := fetchNOAAJSON(ctx, "station sensor", sensorURL, &rawData); err != nil {
+		return noaaSensorData{}, err
+	}
+	if rawData.Error != nil {
+		return noaaSensorData{}, &APIError{URL: sensorURL, Operation: "process " + product, Err: fmt.Errorf("%s", rawData.Error.Message)}
+	}
+	return rawData, nil
+}
+
+// buildStationSensorURL asks NOAA for the latest reading of product.
+func buildStationSensorURL(baseURL, station, product string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build station sensor request", Err: err}
+	}
+	q := u.Query()
+	q.Set("station", station)
+	q.Set("product", product)
+	q.Set("units", "english")
+	q.Set("time_zone", "lst_ldt")
+	q.Set("format", "json")
+	q.Set("date", "latest")
+	for _, key := range []string{"datum", "interval", "begin_date", "end_date", "range"} {
+		q.Del(key)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// apply stores the latest valid reading in raw as product's field.
+func (s *StationSensors) apply(product string, raw noaaSensorData, tz *time.Location) error {
+	for i := len(raw.Data) - 1; i >= 0; i-- {
+		d := raw.Data[i]
+		at, err := time.ParseInLocation("2006-01-02 15:04", d.Time, tz)
+		if err != nil {
+			continue
+		}
+		if product == "wind" {
+			speed, err := strconv.ParseFloat(d.Speed, 64)
+			if err != nil {
+				continue
+			}
+			// Direction and gust are missing in calm or gauge gaps; zero
+			// is a fair reading for both.
+			direction, _ := strconv.ParseFloat(d.Direction, 64)
+			gust, _ := strconv.ParseFloat(d.Gust, 64)
+			s.Wind = &WindReading{At: at, Speed: speed, Gust: gust, Direction: direction, Compass: d.Compass}
+			return nil
+		}
+		value, err := strconv.ParseFloat(d.Value, 64)
+		if err != nil {
+			continue
+		}
+		reading := &SensorReading{At: at, Value: value}
+		switch product {
+		case "water_temperature":
+			s.WaterTemperature = reading
+		case "air_pressure":
+			s.AirPressure = reading
+		}
+		return nil
+	}
+	return &APIError{URL: noaaAPIURL, Operation: "process " + product, Err: fmt.Errorf("no valid readings in %d", len(raw.Data))}
+}
+
+// current drops readings older than stationSensorMaxAge at now.
+func (s StationSensors) current(now time.Time) StationSensors {
+	fresh := func(at time.Time) bool { return now.Sub(at) <= stationSensorMaxAge }
+	if s.WaterTemperature != nil && !fresh(s.WaterTemperature.At) {
+		s.WaterTemperature = nil
+	}
+	if s.AirPressure != nil && !fresh(s.AirPressure.At) {
+		s.AirPressure = nil
+	}
+	if s.Wind != nil && !fresh(s.Wind.At) {
+		s.Wind = nil
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestBuildStationSensorURL(t *testing.T) {
+	sensorURL, err := buildStationSensorURL(noaaAPIURLDefault+"&datum=MLLW&interval=hilo", "8720218", "water_temperature")
+	if err != nil {
+		t.Fatalf("buildStationSensorURL() error = %v", err)
+	}
+	parsedURL, err := url.Parse(sensorURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	query := parsedURL.Query()
+	if query.Get("product") != "water_temperature" || query.Get("date") != "latest" || query.Get("units") != "english" {
+		t.Fatalf("query = %v", query)
+	}
+	if query.Has("datum") || query.Has("interval") {
+		t.Fatalf("query = %v; want the prediction-only parameters dropped", query)
+	}
+}
+
+func TestGetStationSensors(t *testing.T) {
+	oldCache, oldNOAAURL, oldHTTPClient := tideCache, noaaAPIURL, httpClient
+	defer func() { tideCache, noaaAPIURL, httpClient = oldCache, oldNOAAURL, oldHTTPClient }()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("product") {
+		case "water_temperature":
+			_, _ = w.Write([]byte(`{"data":[{"t":"2024-04-08 07:54","v":"72.3","f":"0,0,0"}]}`))
+		case "wind":
+			_, _ = w.Write([]byte(`{"data":[{"t":"2024-04-08 07:54","s":"8.55","d":"110.00","dr":"ESE","g":"11.66","f":"0,0"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"error":{"message":"No data was found. This product may not be offered at this station at the requested time."}}`))
+		}
+	}))
+	defer server.Close()
+
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+
+	for range 2 {
+		sensors, err := getStationSensors(context.Background(), defaultLocation)
+		if err != nil {
+			t.Fatalf("getStationSensors() error = %v", err)
+		}
+		if sensors.WaterTemperature == nil || sensors.WaterTemperature.Value != 72.3 {
+			t.Fatalf("water temperature = %+v; want 72.3", sensors.WaterTemperature)
+		}
+		if wind := sensors.Wind; wind == nil || wind.Speed != 8.55 || wind.Gust != 11.66 || wind.Direction != 110 || wind.Compass != "ESE" {
+			t.Fatalf("wind = %+v", sensors.Wind)
+		}
+		if sensors.AirPressure != nil {
+			t.Fatalf("air pressure = %+v; want nil for a station without a barometer", sensors.AirPressure)
+		}
+	}
+	if got := requests.Load(); got != int32(len(stationSensorProducts)) {
+		t.Fatalf("NOAA requests = %d; want one per product", got)
+	}
+}
+
+func TestGetStationSensors_FailsWhenNoSensorAnswers(t *testing.T) {
+	oldCache, oldNOAAURL, oldHTTPClient := tideCache, noaaAPIURL, httpClient
+	defer func() { tideCache, noaaAPIURL, httpClient = oldCache, oldNOAAURL, oldHTTPClient }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+
+	if _, err := getStationSensors(context.Background(), defaultLocation); err == nil {
+		t.Fatal("getStationSensors() expected an error")
+	}
+}
+
+func TestStationSensorsCurrent(t *testing.T) {
+	now := time.Date(2024, time.April, 8, 12, 0, 0, 0, time.UTC)
+	sensors := StationSensors{
+		WaterTemperature: &SensorReading{At: now.Add(-10 * time.Minute), Value: 72},
+		AirPressure:      &SensorReading{At: now.Add(-4 * time.Hour), Value: 1016},
+		Wind:             &WindReading{At: now.AddDate(0, 0, -2), Speed: 10},
+	}
+	got := sensors.current(now)
+	if got.WaterTemperature == nil || got.AirPressure != nil || got.Wind != nil {
+		t.Fatalf("current() = %+v; want only the fresh water temperature", got)
+	}
+	if sensors.AirPressure == nil {
+		t.Fatal("current() should not modify its receiver")
+	}
+}
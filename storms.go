@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nhcAPIURLDefault = "https://www.nhc.noaa.gov/CurrentStorms.json"
+	nhcAPITimeout    = 3 * time.Second
+	// stormCacheExpiration is well inside the three hours between NHC
+	// intermediate advisories.
+	stormCacheExpiration = 15 * time.Minute
+	stormsCacheKey       = "nhc-storms"
+
+	earthRadiusMiles = 3958.8
+)
+
+var nhcAPIURL = nhcAPIURLDefault
+
+// stormClassifications names the NHC classification codes.
+var stormClassifications = map[string]string{
+	"TD":  "Tropical Depression",
+	"STD": "Subtropical Depression",
+	"TS":  "Tropical Storm",
+	"STS": "Subtropical Storm",
+	"HU":  "Hurricane",
+	"TY":  "Typhoon",
+	"PTC": "Post-Tropical Cyclone",
+}
+
+// Storm is one active tropical cyclone from the NHC, at its last advisory
+// position.
+type Storm struct {
+	ID             string
+	Name           string
+	Classification string
+	WindKnots      float64
+	Latitude       float64
+	Longitude      float64
+	LastUpdate     time.Time
+}
+
+// stormsCacheEntry wraps the storm list so that no storms is cached too.
+type stormsCacheEntry struct {
+	Storms []Storm
+}
+
+// NearbyStorm is a storm with its distance and initial bearing from the
+// location.
+type NearbyStorm struct {
+	Storm
+	DistanceMiles float64
+	BearingDeg    float64
+}
+
+type nhcCurrentStorms struct {
+	ActiveStorms []struct {
+		ID               string    `json:"id"`
+		Name             string    `json:"name"`
+		Classification   string    `json:"classification"`
+		Intensity        string    `json:"intensity"`
+		LatitudeNumeric  float64   `json:"latitudeNumeric"`
+		LongitudeNumeric float64   `json:"longitudeNumeric"`
+		LastUpdate       time.Time `json:"lastUpdate"`
+	} `json:"activeStorms"`
+}
+
+// getStorms returns the active storms from the cache or the NHC. The list
+// is the same for every location.
+func getStorms(ctx context.Context) ([]Storm, error) {
+	if cachedData, found := weatherCache.Get(stormsCacheKey); found {
+		return cachedData.(stormsCacheEntry).Storms, nil
+	}
+	return refreshStorms(ctx)
+}
+
+// refreshStorms fetches the active storms and caches them, ignoring any
+// cached entry.
+func refreshStorms(ctx context.Context) ([]Storm, error) {
+	result, err := shareUpstream(ctx, stormsCacheKey, func(ctx context.Context) (any, error) {
+		storms, err := fetchStorms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		weatherCache.Set(stormsCacheKey, stormsCacheEntry{Storms: storms}, stormCacheExpiration)
+		return storms, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]Storm), nil
+}
+
+func fetchStorms(ctx context.Context) ([]Storm, error) {
+	apiRequestsTotal.WithLabelValues("storms").Inc()
+
+	requestContext, cancel := context.WithTimeout(ctx, nhcAPITimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, nhcAPIURL, nil)
+	if err != nil {
+		return nil, &APIError{URL: nhcAPIURL, Operation: "build storm request", Err: err}
+	}
+	req.Header.Set("User-Agent", "kindle-weather/1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &APIError{URL: nhcAPIURL, Operation: "GET storm data", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{URL: nhcAPIURL, Operation: "GET storm data", Err: fmt.Errorf("status code %d", resp.StatusCode)}
+	}
+
+	var data nhcCurrentStorms
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, &APIError{URL: nhcAPIURL, Operation: "decode storm data", Err: err}
+	}
+
+	storms := []Storm{}
+	for _, s := range data.ActiveStorms {
+		// Intensity is the maximum sustained wind in knots, as a string.
+		wind, _ := strconv.ParseFloat(strings.TrimSpace(s.Intensity), 64)
+		storms = append(storms, Storm{
+			ID:             s.ID,
+			Name:           s.Name,
+			Classification: strings.ToUpper(s.Classification),
+			WindKnots:      wind,
+			Latitude:       s.LatitudeNumeric,
+			Longitude:      s.LongitudeNumeric,
+			LastUpdate:     s.LastUpdate,
+		})
+	}
+	return storms, nil
+}
+
+// nearestStorm returns the storm closest to loc, or nil when there are none.
+func nearestStorm(storms []Storm, loc Location) *NearbyStorm {
+	var nearest *NearbyStorm
+	for _, storm := range storms {
+		distance, bearing := greatCircle(loc.Latitude, loc.Longitude, storm.Latitude, storm.Longitude)
+		if nearest == nil || distance < nearest.DistanceMiles {
+			nearest = &NearbyStorm{Storm: storm, DistanceMiles: distance, BearingDeg: bearing}
+		}
+	}
+	return nearest
+}
+
+// greatCircle returns the distance in miles and the initial bearing in
+// compass degrees from the first point to the second.
+func greatCircle(lat1, lon1, lat2, lon2 float64) (float64, float64) {
+	radians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi, dLambda := radians(lat2-lat1), radians(lon2-lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	distance := 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(a)))
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	bearing := math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+	return distance, bearing
+}
+
+// Title names the storm as the NHC does: "Hurricane Ernesto".
+func (s Storm) Title() string {
+	kind, ok := stormClassifications[s.Classification]
+	if !ok {
+		kind = "Tropical Cyclone"
+	}
+	return kind + " " + s.Name
+}
+
+// Category is the Saffir-Simpson category of a hurricane, or 0 for any
+// weaker storm.
+func (s Storm) Category() int {
+	if s.Classification != "HU" && s.Classification != "TY" {
+		return 0
+	}
+	switch {
+	case s.WindKnots >= 137:
+		return 5
+	case s.WindKnots >= 113:
+		return 4
+	case s.WindKnots >= 96:
+		return 3
+	case s.WindKnots >= 83:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Direction is the compass point the storm lies in from the location.
+func (s NearbyStorm) Direction() string {
+	return compassPoint(s.BearingDeg)
+}
+
+// Text is the panel line: "Hurricane Ernesto, Cat 1, 420 mi SE".
+func (s NearbyStorm) Text() string {
+	parts := []string{s.Title()}
+	if category := s.Category(); category > 0 {
+		parts = append(parts, fmt.Sprintf("Cat %d", category))
+	}
+	parts = append(parts, fmt.Sprintf("%.0f mi %s", s.DistanceMiles, s.Direction()))
+	return strings.Join(parts, ", ")
+}
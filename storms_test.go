@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestGetStorms_DecodesAndCachesCurrentStorms(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, err := os.ReadFile("testdata/nhc_current_storms.json")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	oldURL, oldClient, oldCache := nhcAPIURL, httpClient, weatherCache
+	nhcAPIURL = server.URL
+	httpClient = server.Client()
+	weatherCache = cache.New(time.Hour, time.Hour)
+	defer func() { nhcAPIURL, httpClient, weatherCache = oldURL, oldClient, oldCache }()
+
+	storms, err := getStorms(context.Background())
+	if err != nil {
+		t.Fatalf("getStorms() error = %v", err)
+	}
+	want := Storm{
+		ID:             "al052024",
+		Name:           "Ernesto",
+		Classification: "HU",
+		WindKnots:      85,
+		Latitude:       25,
+		Longitude:      -77,
+		LastUpdate:     time.Date(2024, time.August, 15, 21, 0, 0, 0, time.UTC),
+	}
+	if len(storms) != 2 || storms[0] != want {
+		t.Fatalf("storms = %+v; want Ernesto first of two", storms)
+	}
+
+	if _, err := getStorms(context.Background()); err != nil {
+		t.Fatalf("getStorms() error = %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d; want the second call served from the cache", requests)
+	}
+}
+
+func TestNearestStorm(t *testing.T) {
+	if got := nearestStorm(nil, defaultLocation); got != nil {
+		t.Fatalf("nearestStorm() = %+v; want nil without active storms", got)
+	}
+
+	storms := []Storm{
+		{Name: "Hone", Classification: "TS", WindKnots: 50, Latitude: 17.5, Longitude: -150.2},
+		{Name: "Ernesto", Classification: "HU", WindKnots: 85, Latitude: 25, Longitude: -77},
+	}
+	got := nearestStorm(storms, defaultLocation)
+	if got == nil || got.Name != "Ernesto" {
+		t.Fatalf("nearestStorm() = %+v; want Ernesto", got)
+	}
+	if math.Abs(got.DistanceMiles-412) > 1 || math.Abs(got.BearingDeg-140) > 1 {
+		t.Fatalf("distance/bearing = %.1f mi/%.1f°; want about 412 mi at 140°", got.DistanceMiles, got.BearingDeg)
+	}
+	if text := got.Text(); text != "Hurricane Ernesto, Cat 2, 412 mi SE" {
+		t.Fatalf("Text() = %q", text)
+	}
+}
+
+func TestStorm_Category(t *testing.T) {
+	tests := []struct {
+		storm Storm
+		title string
+		want  int
+	}{
+		{storm: Storm{Name: "Five", Classification: "TD", WindKnots: 30}, title: "Tropical Depression Five", want: 0},
+		{storm: Storm{Name: "Hone", Classification: "TS", WindKnots: 60}, title: "Tropical Storm Hone", want: 0},
+		{storm: Storm{Name: "Debby", Classification: "HU", WindKnots: 70}, title: "Hurricane Debby", want: 1},
+		{storm: Storm{Name: "Helene", Classification: "HU", WindKnots: 120}, title: "Hurricane Helene", want: 4},
+		{storm: Storm{Name: "Milton", Classification: "HU", WindKnots: 155}, title: "Hurricane Milton", want: 5},
+		{storm: Storm{Name: "Oscar", Classification: "XX", WindKnots: 40}, title: "Tropical Cyclone Oscar", want: 0},
+	}
+	for _, tt := range tests {
+		if got := tt.storm.Category(); got != tt.want {
+			t.Errorf("%s Category() = %d; want %d", tt.storm.Name, got, tt.want)
+		}
+		if got := tt.storm.Title(); got != tt.title {
+			t.Errorf("Title() = %q; want %q", got, tt.title)
+		}
+	}
+}
+
+func TestNearbyStorm_Direction(t *testing.T) {
+	for bearing, want := range map[float64]string{0: "N", 10: "N", 12: "NNE", 140: "SE", 275.9: "W", 350: "N"} {
+		if got := (NearbyStorm{BearingDeg: bearing}).Direction(); got != want {
+			t.Errorf("Direction(%v°) = %q; want %q", bearing, got, want)
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"os"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,58 +16,132 @@ import (
 )
 
 const (
-	surfAPIURLDefault = "https://marine-api.open-meteo.com/v1/marine?latitude=29.65&longitude=-81.20&hourly=wave_height,wave_direction,wave_period&forecast_hours=24&length_unit=imperial&timeformat=unixtime&cell_selection=sea"
-
-	// Crescent Beach surf preferences. These intentionally describe a friendly,
-	// broadly surfable day rather than large or expert-only conditions.
-	surfMinWaveHeightFeet       = 1.5
-	surfMaxWaveHeightFeet       = 6.0
-	surfMinWavePeriodSeconds    = 7.0
-	surfMinWaveDirectionDegrees = 20.0
-	surfMaxWaveDirectionDegrees = 160.0
-	surfLightWindMPH            = 5.0
-	surfMaxWindMPH              = 12.0
-	surfMinOffshoreWindDegrees  = 195
-	surfMaxOffshoreWindDegrees  = 315
-	surfWindMatchWindow         = 90 * time.Minute
-	surfAPITimeout              = 3 * time.Second
+	surfAPIURLDefault = "https://marine-api.open-meteo.com/v1/marine"
+
+	surfWindMatchWindow = 90 * time.Minute
+	surfAPITimeout      = 3 * time.Second
+
+	// Hours are rated 0 to surfMaxRating; surfGoodRating and up is worth a
+	// session.
+	surfGoodRating = 3
+	surfMaxRating  = 5
+
+	// The outlook row covers minSurfOutlookDays to maxSurfOutlookDays days,
+	// today first.
+	minSurfOutlookDays = 3
+	maxSurfOutlookDays = 5
+
+	surfWindLight    = "light"
+	surfWindOffshore = "offshore"
+	surfWindOnshore  = "onshore"
+	surfWindStrong   = "strong"
 
 	surfCacheKey       = "forecast"
 	surfLatestCacheKey = "latest-successful"
 )
 
 var (
-	surfAPIURL = surfAPIURLDefault
-	surfCache  = cache.New(30*time.Minute, time.Hour)
+	surfAPIURL      = surfAPIURLDefault
+	surfCache       = cache.New(30*time.Minute, time.Hour)
+	surfOutlookDays = maxSurfOutlookDays
 )
 
+// SurfProfile is what makes a surfable hour at a spot. Direction arcs are
+// compass degrees read clockwise from min to max, so an arc may wrap past
+// north (min 300, max 60). The tide preferences are optional: TidePhases
+// lists surfTidePhases, and a nil bound on the tide height is no limit.
+type SurfProfile struct {
+	MinWaveHeightFeet        float64  `yaml:"min_wave_height_feet"`
+	MaxWaveHeightFeet        float64  `yaml:"max_wave_height_feet"`
+	MinWavePeriodSeconds     float64  `yaml:"min_wave_period_seconds"`
+	MaxWavePeriodSeconds     float64  `yaml:"max_wave_period_seconds"` // 0 means no limit
+	MinSwellDirectionDegrees float64  `yaml:"min_swell_direction_degrees"`
+	MaxSwellDirectionDegrees float64  `yaml:"max_swell_direction_degrees"`
+	MinOffshoreWindDegrees   float64  `yaml:"min_offshore_wind_degrees"`
+	MaxOffshoreWindDegrees   float64  `yaml:"max_offshore_wind_degrees"`
+	LightWindMPH             float64  `yaml:"light_wind_mph"`
+	MaxWindMPH               float64  `yaml:"max_wind_mph"`
+	TidePhases               []string `yaml:"tide_phases"`
+	MinTideFeet              *float64 `yaml:"min_tide_feet"`
+	MaxTideFeet              *float64 `yaml:"max_tide_feet"`
+}
+
+// surfTidePhases are the tide phases a profile can prefer. The stages split
+// each swing between a low and a high into thirds by height; the directions
+// say which way it is swinging. An hour must match one listed stage, if any
+// are listed, and one listed direction, if any are listed, so [mid, rising]
+// means mid tide on the incoming.
+var surfTidePhases = []string{"low", "mid", "high", "rising", "falling"}
+
+// usesTide reports whether the profile has any tide preference.
+func (p SurfProfile) usesTide() bool {
+	return len(p.TidePhases) > 0 || p.MinTideFeet != nil || p.MaxTideFeet != nil
+}
+
+// clone copies the profile so a dashboard can change it without touching
+// the one it inherited.
+func (p SurfProfile) clone() SurfProfile {
+	p.TidePhases = slices.Clone(p.TidePhases)
+	if p.MinTideFeet != nil {
+		v := *p.MinTideFeet
+		p.MinTideFeet = &v
+	}
+	if p.MaxTideFeet != nil {
+		v := *p.MaxTideFeet
+		p.MaxTideFeet = &v
+	}
+	return p
+}
+
+// defaultSurfProfile suits Crescent Beach's east-facing break. It
+// intentionally describes a friendly, broadly surfable day rather than large
+// or expert-only conditions.
+var defaultSurfProfile = SurfProfile{
+	MinWaveHeightFeet:        1.5,
+	MaxWaveHeightFeet:        6.0,
+	MinWavePeriodSeconds:     7.0,
+	MinSwellDirectionDegrees: 20,
+	MaxSwellDirectionDegrees: 160,
+	MinOffshoreWindDegrees:   195,
+	MaxOffshoreWindDegrees:   315,
+	LightWindMPH:             5,
+	MaxWindMPH:               12,
+}
+
 type SurfForecast struct {
 	Hourly SurfHourlyForecast `json:"hourly"`
 }
 
+// SurfHourlyForecast holds Open-Meteo's hourly marine arrays. The wave_*
+// series combine swell and wind waves; the swell and wind wave components
+// are optional and may be shorter than Time.
 type SurfHourlyForecast struct {
-	Time          []int64   `json:"time"`
-	WaveHeight    []float64 `json:"wave_height"`
-	WaveDirection []float64 `json:"wave_direction"`
-	WavePeriod    []float64 `json:"wave_period"`
+	Time               []int64   `json:"time"`
+	WaveHeight         []float64 `json:"wave_height"`
+	WaveDirection      []float64 `json:"wave_direction"`
+	WavePeriod         []float64 `json:"wave_period"`
+	SwellWaveHeight    []float64 `json:"swell_wave_height"`
+	SwellWavePeriod    []float64 `json:"swell_wave_period"`
+	SwellWaveDirection []float64 `json:"swell_wave_direction"`
+	WindWaveHeight     []float64 `json:"wind_wave_height"`
 }
 
-func configureSurfRuntime(cleanup time.Duration) {
-	surfAPIURL = strings.TrimSpace(os.Getenv("SURF_API_URL"))
-	if surfAPIURL == "" {
-		surfAPIURL = surfAPIURLDefault
-	}
-	surfCache = cache.New(parseEnvDurationSeconds("SURF_CACHE_EXPIRATION", 30*time.Minute), cleanup)
+func configureSurfRuntime(cfg Config, cleanup time.Duration) {
+	surfAPIURL = cfg.APIs.SurfURL
+	surfCache = cache.New(secondsDuration(cfg.Cache.SurfSeconds), cleanup)
+	surfOutlookDays = cfg.Surf.OutlookDays
 }
 
-func getSurfForecast(ctx context.Context) (SurfForecast, error) {
-	if cachedData, found := surfCache.Get(surfCacheKey); found {
+func getSurfForecast(ctx context.Context, loc Location) (SurfForecast, error) {
+	cacheKey := surfCacheKey + ":" + loc.coordinatesKey()
+	latestKey := surfLatestCacheKey + ":" + loc.coordinatesKey()
+	if cachedData, found := surfCache.Get(cacheKey); found {
 		return cachedData.(SurfForecast), nil
 	}
 
-	forecast, err := fetchSurfForecast(ctx)
+	forecast, err := refreshSurfForecast(ctx, loc)
 	if err != nil {
-		if cachedData, found := surfCache.Get(surfLatestCacheKey); found {
+		if cachedData, found := surfCache.Get(latestKey); found {
 			logJSON(logEntry{
 				Timestamp: time.Now().Format(time.RFC3339),
 				Level:     "WARN",
@@ -76,21 +152,43 @@ func getSurfForecast(ctx context.Context) (SurfForecast, error) {
 		return SurfForecast{}, err
 	}
 
-	surfCache.Set(surfCacheKey, forecast, cache.DefaultExpiration)
-	surfCache.Set(surfLatestCacheKey, forecast, cache.NoExpiration)
 	return forecast, nil
 }
 
-func fetchSurfForecast(ctx context.Context) (SurfForecast, error) {
+// refreshSurfForecast fetches the surf forecast and caches it, ignoring any
+// cached entry.
+func refreshSurfForecast(ctx context.Context, loc Location) (SurfForecast, error) {
+	cacheKey := surfCacheKey + ":" + loc.coordinatesKey()
+	result, err := shareUpstream(ctx, "surf:"+cacheKey, func(ctx context.Context) (any, error) {
+		forecast, err := fetchSurfForecast(ctx, loc)
+		if err != nil {
+			return SurfForecast{}, err
+		}
+		surfCache.Set(cacheKey, forecast, cache.DefaultExpiration)
+		surfCache.Set(surfLatestCacheKey+":"+loc.coordinatesKey(), forecast, cache.NoExpiration)
+		return forecast, nil
+	})
+	if err != nil {
+		return SurfForecast{}, err
+	}
+	return result.(SurfForecast), nil
+}
+
+func fetchSurfForecast(ctx context.Context, loc Location) (SurfForecast, error) {
 	apiRequestsTotal.WithLabelValues("surf").Inc()
 	if strings.TrimSpace(surfAPIURL) == "" {
 		return SurfForecast{}, fmt.Errorf("surf API URL is not configured")
 	}
 
+	apiURL, err := buildSurfURL(surfAPIURL, loc)
+	if err != nil {
+		return SurfForecast{}, err
+	}
+
 	requestContext, cancel := context.WithTimeout(ctx, surfAPITimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, surfAPIURL, nil)
+	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return SurfForecast{}, &APIError{URL: surfAPIURL, Operation: "build surf request", Err: err}
 	}
@@ -117,64 +215,331 @@ func fetchSurfForecast(ctx context.Context) (SurfForecast, error) {
 	return forecast, nil
 }
 
+func buildSurfURL(baseURL string, loc Location) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build surf request", Err: err}
+	}
+	q := u.Query()
+	q.Set("latitude", loc.latitudeParam())
+	q.Set("longitude", loc.longitudeParam())
+	q.Set("hourly", "wave_height,wave_direction,wave_period,swell_wave_height,swell_wave_period,swell_wave_direction,wind_wave_height")
+	q.Set("forecast_days", strconv.Itoa(surfOutlookDays))
+	q.Set("timezone", loc.Timezone)
+	q.Set("length_unit", "imperial")
+	q.Set("timeformat", "unixtime")
+	q.Set("cell_selection", "sea")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func usableSurfHours(hourly SurfHourlyForecast) int {
 	return min(len(hourly.Time), len(hourly.WaveHeight), len(hourly.WaveDirection), len(hourly.WavePeriod))
 }
 
-func isGoodSurfToday(forecast SurfForecast, weather WeatherData, now time.Time) bool {
-	loc := surfLocation(weather)
-	localNow := now.In(loc)
-	sunrise, sunset := surfDaylightWindow(weather, localNow, loc)
-	if localNow.After(sunset) {
+// SurfHour is one forecast hour rated against a surf profile. The swell and
+// wind wave components are NaN when Open-Meteo did not send them, and the
+// tide is NaN and TideStage empty when the tide predictions do not cover it.
+type SurfHour struct {
+	Time           time.Time
+	Rating         int
+	WaveHeight     float64
+	WavePeriod     float64
+	WaveDirection  float64
+	SwellHeight    float64
+	SwellPeriod    float64
+	SwellDirection float64
+	WindWaveHeight float64
+	Wind           string
+	TideHeight     float64
+	TideStage      string
+	TideRising     bool
+}
+
+// SurfWindow is the best run of consecutive hours today; End is the end of
+// its last hour.
+type SurfWindow struct {
+	Start  time.Time
+	End    time.Time
+	Rating int
+	Hour   SurfHour
+}
+
+// rateSurfHours rates every complete forecast hour, matching each with the
+// nearest wind from the weather forecast and the tide from NOAA's
+// predictions, preferring the dense curve when there is one. Times are in
+// loc.
+func rateSurfHours(forecast SurfForecast, weather WeatherData, tide TideData, curve TideCurve, profile SurfProfile, loc *time.Location) []SurfHour {
+	predictions := sortedTidePredictions(tide.Predictions)
+	hours := make([]SurfHour, 0, usableSurfHours(forecast.Hourly))
+	for i := 0; i < usableSurfHours(forecast.Hourly); i++ {
+		hour := SurfHour{
+			Time:          time.Unix(forecast.Hourly.Time[i], 0).In(loc),
+			WaveHeight:    forecast.Hourly.WaveHeight[i],
+			WavePeriod:    forecast.Hourly.WavePeriod[i],
+			WaveDirection: forecast.Hourly.WaveDirection[i],
+
+			SwellHeight:    surfComponent(forecast.Hourly.SwellWaveHeight, i),
+			SwellPeriod:    surfComponent(forecast.Hourly.SwellWavePeriod, i),
+			SwellDirection: surfComponent(forecast.Hourly.SwellWaveDirection, i),
+			WindWaveHeight: surfComponent(forecast.Hourly.WindWaveHeight, i),
+		}
+		if windSpeed, windDirection, ok := nearestWind(weather, hour.Time); ok {
+			hour.Wind = profile.surfWind(windSpeed, windDirection)
+		}
+		hour.TideHeight, hour.TideStage, hour.TideRising = surfTideAt(predictions, curve, hour.Time)
+		hour.Rating = profile.rateSurf(hour.WaveHeight, hour.WavePeriod, hour.WaveDirection, hour.Wind)
+		if !profile.suitsTide(hour) {
+			hour.Rating = min(hour.Rating, 1)
+		}
+		hours = append(hours, hour)
+	}
+	return hours
+}
+
+func surfComponent(values []float64, i int) float64 {
+	if i >= len(values) {
+		return math.NaN()
+	}
+	return values[i]
+}
+
+// surfTideAt returns the tide height at t, its stage between the
+// surrounding low and high, and whether it is rising. sorted must be the
+// sorted highs and lows; the height comes from the curve when it covers t.
+// The stage is empty when the highs and lows do not cover t.
+func surfTideAt(sorted []TidePrediction, curve TideCurve, t time.Time) (height float64, stage string, rising bool) {
+	for i := 1; i < len(sorted); i++ {
+		prev, next := sorted[i-1], sorted[i]
+		if t.Before(prev.Time) || t.After(next.Time) {
+			continue
+		}
+		height, rising, _ = tideHeightAt(sorted[i-1:i+1], t)
+		if level, _, ok := tideLevelAt(curve.Levels, t); ok {
+			height = level
+		}
+		low, high := min(prev.Height, next.Height), max(prev.Height, next.Height)
+		switch position := (height - low) / (high - low); {
+		case high <= low:
+			stage = "mid"
+		case position < 1.0/3:
+			stage = "low"
+		case position > 2.0/3:
+			stage = "high"
+		default:
+			stage = "mid"
+		}
+		return height, stage, rising
+	}
+	return math.NaN(), "", false
+}
+
+// tideDirection is "rising" or "falling", or "" when the tide is unknown.
+func (h SurfHour) tideDirection() string {
+	switch {
+	case h.TideStage == "":
+		return ""
+	case h.TideRising:
+		return "rising"
+	default:
+		return "falling"
+	}
+}
+
+// suitsTide reports whether the hour's tide fits the profile. An hour
+// without tide data passes, so a missing prediction does not hide good
+// waves.
+func (p SurfProfile) suitsTide(hour SurfHour) bool {
+	if hour.TideStage == "" || !p.usesTide() {
+		return true
+	}
+	var stages, directions []string
+	for _, phase := range p.TidePhases {
+		if phase == "rising" || phase == "falling" {
+			directions = append(directions, phase)
+		} else {
+			stages = append(stages, phase)
+		}
+	}
+	if len(stages) > 0 && !slices.Contains(stages, hour.TideStage) {
 		return false
 	}
+	if len(directions) > 0 && !slices.Contains(directions, hour.tideDirection()) {
+		return false
+	}
+	if p.MinTideFeet != nil && hour.TideHeight < *p.MinTideFeet {
+		return false
+	}
+	return p.MaxTideFeet == nil || hour.TideHeight <= *p.MaxTideFeet
+}
+
+// rateSurf scores an hour from 0 to surfMaxRating. Waves outside the profile
+// score 0 and surfable waves under a bad or unknown wind score 1; otherwise
+// the hour is good, with a point each for size in the upper two thirds of
+// the height range and for a period three seconds past the minimum.
+func (p SurfProfile) rateSurf(height, period, direction float64, wind string) int {
+	if !p.isSurfableWave(height, period, direction) {
+		return 0
+	}
+	if !isSurfableWind(wind) {
+		return 1
+	}
+	rating := surfGoodRating
+	if height >= p.MinWaveHeightFeet+(p.MaxWaveHeightFeet-p.MinWaveHeightFeet)/3 {
+		rating++
+	}
+	if period >= p.MinWavePeriodSeconds+3 {
+		rating++
+	}
+	return rating
+}
 
+// bestSurfWindow finds the highest-rated run of hours left in today's
+// daylight, preferring the longest run and then the earliest. It returns nil
+// when no hour rates surfGoodRating. now is in the dashboard location's
+// zone; it is only used when the weather data does not name a timezone.
+func bestSurfWindow(hours []SurfHour, weather WeatherData, now time.Time) *SurfWindow {
+	loc := surfLocation(weather, now.Location())
+	localNow := now.In(loc)
+	sunrise, sunset := surfDaylightWindow(weather, localNow, loc)
 	windowStart := localNow
 	if windowStart.Before(sunrise) {
 		windowStart = sunrise
 	}
 
-	for i := 0; i < usableSurfHours(forecast.Hourly); i++ {
-		forecastTime := time.Unix(forecast.Hourly.Time[i], 0).In(loc)
-		if !sameDate(forecastTime, localNow) || forecastTime.Before(windowStart) || forecastTime.After(sunset) {
+	var best *SurfWindow
+	var current *SurfWindow
+	for _, hour := range hours {
+		at := hour.Time.In(loc)
+		if !sameDate(at, localNow) || at.Before(windowStart) || at.After(sunset) || hour.Rating < surfGoodRating {
+			current = nil
 			continue
 		}
-		if !isSurfableWave(
-			forecast.Hourly.WaveHeight[i],
-			forecast.Hourly.WavePeriod[i],
-			forecast.Hourly.WaveDirection[i],
-		) {
+		if current != nil && current.Rating == hour.Rating && at.Equal(current.End) {
+			current.End = at.Add(time.Hour)
+		} else {
+			current = &SurfWindow{Start: at, End: at.Add(time.Hour), Rating: hour.Rating, Hour: hour}
+		}
+		if best == nil || current.Rating > best.Rating ||
+			(current.Rating == best.Rating && current.End.Sub(current.Start) > best.End.Sub(best.Start)) {
+			window := *current
+			best = &window
+		}
+	}
+	return best
+}
+
+// SurfDay is one day of the surf outlook. WavesOnly marks a day past the
+// hourly wind forecast, rated as if the wind cooperates.
+type SurfDay struct {
+	Date      time.Time
+	Label     string
+	Rating    int
+	WavesOnly bool
+}
+
+// Dots lists surfMaxRating dots for the outlook row, the first Rating of
+// them filled.
+func (d SurfDay) Dots() []bool {
+	dots := make([]bool, surfMaxRating)
+	for i := range dots {
+		dots[i] = i < d.Rating
+	}
+	return dots
+}
+
+// surfOutlook gives the best rating in each day's daylight for days days
+// starting today; today only counts the hours still to come. A day with no
+// forecast hours in daylight is left out.
+func surfOutlook(hours []SurfHour, profile SurfProfile, weather WeatherData, now time.Time, days int) []SurfDay {
+	loc := surfLocation(weather, now.Location())
+	localNow := now.In(loc)
+
+	var outlook []SurfDay
+	for offset := range days {
+		date := time.Date(localNow.Year(), localNow.Month(), localNow.Day()+offset, 0, 0, 0, 0, loc)
+		sunrise, sunset := surfDaylightWindow(weather, date.Add(12*time.Hour), loc)
+		if offset == 0 && localNow.After(sunrise) {
+			sunrise = localNow
+		}
+
+		var daylight []SurfHour
+		wavesOnly := true
+		for _, hour := range hours {
+			at := hour.Time.In(loc)
+			if !sameDate(at, date) || at.Before(sunrise) || at.After(sunset) {
+				continue
+			}
+			daylight = append(daylight, hour)
+			if hour.Wind != "" {
+				wavesOnly = false
+			}
+		}
+		if len(daylight) == 0 {
 			continue
 		}
 
-		windSpeed, windDirection, ok := nearestWind(weather, forecastTime)
-		if ok && isSurfableWind(windSpeed, windDirection) {
-			return true
+		day := SurfDay{Date: date, Label: date.Format("Mon"), WavesOnly: wavesOnly}
+		if offset == 0 {
+			day.Label = "Today"
+		}
+		for _, hour := range daylight {
+			rating := hour.Rating
+			if wavesOnly {
+				rating = profile.rateSurf(hour.WaveHeight, hour.WavePeriod, hour.WaveDirection, surfWindLight)
+				if !profile.suitsTide(hour) {
+					rating = min(rating, 1)
+				}
+			}
+			day.Rating = max(day.Rating, rating)
 		}
+		outlook = append(outlook, day)
 	}
-
-	return false
+	return outlook
 }
 
-func isSurfableWave(height, period, direction float64) bool {
+func (p SurfProfile) isSurfableWave(height, period, direction float64) bool {
 	if math.IsNaN(height) || math.IsNaN(period) || math.IsNaN(direction) {
 		return false
 	}
-	return height >= surfMinWaveHeightFeet &&
-		height <= surfMaxWaveHeightFeet &&
-		period >= surfMinWavePeriodSeconds &&
-		direction >= surfMinWaveDirectionDegrees &&
-		direction <= surfMaxWaveDirectionDegrees
+	if p.MaxWavePeriodSeconds > 0 && period > p.MaxWavePeriodSeconds {
+		return false
+	}
+	return height >= p.MinWaveHeightFeet &&
+		height <= p.MaxWaveHeightFeet &&
+		period >= p.MinWavePeriodSeconds &&
+		inDirectionArc(direction, p.MinSwellDirectionDegrees, p.MaxSwellDirectionDegrees)
 }
 
-func isSurfableWind(speed float64, direction int) bool {
-	if speed < 0 || speed > surfMaxWindMPH {
-		return false
+// isSurfableWind reports whether a wind classified by surfWind leaves the
+// waves clean enough to surf.
+func isSurfableWind(wind string) bool {
+	return wind == surfWindLight || wind == surfWindOffshore
+}
+
+// surfWind classifies the wind for the profile: light enough to ignore its
+// direction, offshore, or too strong or onshore to surf.
+func (p SurfProfile) surfWind(speed float64, direction int) string {
+	switch {
+	case speed < 0 || speed > p.MaxWindMPH:
+		return surfWindStrong
+	case speed <= p.LightWindMPH:
+		return surfWindLight
+	case inDirectionArc(float64(direction), p.MinOffshoreWindDegrees, p.MaxOffshoreWindDegrees):
+		return surfWindOffshore
+	default:
+		return surfWindOnshore
 	}
-	if speed <= surfLightWindMPH {
-		return true
+}
+
+// inDirectionArc reports whether direction lies on the clockwise arc from
+// start to end, both ends included.
+func inDirectionArc(direction, start, end float64) bool {
+	direction = math.Mod(math.Mod(direction, 360)+360, 360)
+	if start <= end {
+		return direction >= start && direction <= end
 	}
-	return direction >= surfMinOffshoreWindDegrees && direction <= surfMaxOffshoreWindDegrees
+	return direction >= start || direction <= end
 }
 
 func nearestWind(weather WeatherData, target time.Time) (float64, int, bool) {
@@ -205,16 +570,13 @@ func nearestWind(weather WeatherData, target time.Time) (float64, int, bool) {
 	return bestSpeed, bestDirection, true
 }
 
-func surfLocation(weather WeatherData) *time.Location {
+func surfLocation(weather WeatherData, fallback *time.Location) *time.Location {
 	if weather.Timezone != "" {
 		if loc, err := time.LoadLocation(weather.Timezone); err == nil {
 			return loc
 		}
 	}
-	if loc, err := time.LoadLocation("America/New_York"); err == nil {
-		return loc
-	}
-	return time.FixedZone("Eastern", weather.TimezoneOffset)
+	return fallback
 }
 
 func surfDaylightWindow(weather WeatherData, localNow time.Time, loc *time.Location) (time.Time, time.Time) {
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
 
-func TestIsGoodSurfToday(t *testing.T) {
+func TestBestSurfWindow_GoodSurfToday(t *testing.T) {
 	loc, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		t.Fatalf("time.LoadLocation() error = %v", err)
@@ -59,14 +63,15 @@ func TestIsGoodSurfToday(t *testing.T) {
 				WaveDirection: []float64{tt.direction},
 			}}
 
-			if got := isGoodSurfToday(forecast, weather, now); got != tt.want {
-				t.Fatalf("isGoodSurfToday() = %v; want %v", got, tt.want)
+			hours := rateSurfHours(forecast, weather, TideData{}, TideCurve{}, defaultSurfProfile, loc)
+			if got := bestSurfWindow(hours, weather, now) != nil; got != tt.want {
+				t.Fatalf("good surf today = %v; want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestIsGoodSurfToday_IgnoresTomorrowAndPastDaylight(t *testing.T) {
+func TestBestSurfWindow_IgnoresTomorrowAndPastDaylight(t *testing.T) {
 	loc, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		t.Fatalf("time.LoadLocation() error = %v", err)
@@ -88,8 +93,68 @@ func TestIsGoodSurfToday_IgnoresTomorrowAndPastDaylight(t *testing.T) {
 		WaveDirection: []float64{90},
 	}}
 
-	if isGoodSurfToday(forecast, weather, now) {
-		t.Fatal("isGoodSurfToday() = true after today's daylight window")
+	hours := rateSurfHours(forecast, weather, TideData{}, TideCurve{}, defaultSurfProfile, loc)
+	if best := bestSurfWindow(hours, weather, now); best != nil {
+		t.Fatalf("bestSurfWindow() = %+v after today's daylight window", best)
+	}
+}
+
+func TestBestSurfWindow_WeighsWavesWindAndTide(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	now := time.Date(2026, time.August, 11, 8, 0, 0, 0, loc)
+	goodHour := now.Add(2 * time.Hour)
+	tide := TideData{Predictions: []TidePrediction{
+		{Time: now.Add(-time.Hour), Type: "L", Height: 0},
+		{Time: now.Add(5 * time.Hour), Type: "H", Height: 4},
+	}}
+	feet := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		height    float64
+		period    float64
+		windSpeed float64
+		windDeg   int
+		phases    []string
+		minTide   *float64
+		want      bool
+	}{
+		{name: "good waves, offshore wind, preferred tide", height: 2.5, period: 9, windSpeed: 8, windDeg: 270, phases: []string{"mid", "rising"}, want: true},
+		{name: "good waves and wind at the wrong tide", height: 2.5, period: 9, windSpeed: 8, windDeg: 270, phases: []string{"high"}},
+		{name: "good waves and wind below the tide minimum", height: 2.5, period: 9, windSpeed: 8, windDeg: 270, minTide: feet(3)},
+		{name: "preferred tide with onshore wind", height: 2.5, period: 9, windSpeed: 8, windDeg: 90, phases: []string{"mid"}},
+		{name: "preferred tide with small waves", height: 1, period: 9, windSpeed: 4, windDeg: 90, phases: []string{"mid"}},
+		{name: "light onshore wind above the tide minimum", height: 2.5, period: 9, windSpeed: 4, windDeg: 90, minTide: feet(1), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := WeatherData{
+				Timezone: "America/New_York",
+				Current: CurrentWeather{
+					Dt:      now.Unix(),
+					Sunrise: time.Date(2026, time.August, 11, 6, 45, 0, 0, loc).Unix(),
+					Sunset:  time.Date(2026, time.August, 11, 20, 5, 0, 0, loc).Unix(),
+				},
+				Hourly: []HourlyWeather{{Dt: goodHour.Unix(), WindSpeed: tt.windSpeed, WindDeg: tt.windDeg}},
+			}
+			forecast := SurfForecast{Hourly: SurfHourlyForecast{
+				Time:          []int64{goodHour.Unix()},
+				WaveHeight:    []float64{tt.height},
+				WavePeriod:    []float64{tt.period},
+				WaveDirection: []float64{90},
+			}}
+			profile := defaultSurfProfile
+			profile.TidePhases = tt.phases
+			profile.MinTideFeet = tt.minTide
+
+			hours := rateSurfHours(forecast, weather, tide, TideCurve{}, profile, loc)
+			if got := bestSurfWindow(hours, weather, now) != nil; got != tt.want {
+				t.Fatalf("good surf today = %v; want %v (hour %+v)", got, tt.want, hours[0])
+			}
+		})
 	}
 }
 
@@ -110,7 +175,7 @@ func TestFetchSurfForecast(t *testing.T) {
 	surfAPIURL = server.URL
 	httpClient = server.Client()
 
-	forecast, err := fetchSurfForecast(context.Background())
+	forecast, err := fetchSurfForecast(context.Background(), defaultLocation)
 	if err != nil {
 		t.Fatalf("fetchSurfForecast() error = %v", err)
 	}
@@ -119,6 +184,28 @@ func TestFetchSurfForecast(t *testing.T) {
 	}
 }
 
+func TestBuildSurfURL(t *testing.T) {
+	surfURL, err := buildSurfURL(surfAPIURLDefault, Location{Latitude: 33.66, Longitude: -118.0, Timezone: "America/Los_Angeles"})
+	if err != nil {
+		t.Fatalf("buildSurfURL() error = %v", err)
+	}
+
+	parsedURL, err := url.Parse(surfURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	q := parsedURL.Query()
+	if q.Get("latitude") != "33.66" || q.Get("longitude") != "-118" {
+		t.Fatalf("unexpected coordinates in %s", surfURL)
+	}
+	if hourly := q.Get("hourly"); !strings.HasPrefix(hourly, "wave_height,wave_direction,wave_period,") || !strings.Contains(hourly, "swell_wave_period") || !strings.Contains(hourly, "wind_wave_height") {
+		t.Fatalf("hourly = %q", hourly)
+	}
+	if q.Get("forecast_days") != strconv.Itoa(surfOutlookDays) || q.Get("timezone") != "America/Los_Angeles" {
+		t.Fatalf("want %d local days in %s", surfOutlookDays, surfURL)
+	}
+}
+
 func TestFetchSurfForecast_RejectsIncompleteData(t *testing.T) {
 	oldURL := surfAPIURL
 	oldHTTPClient := httpClient
@@ -136,7 +223,362 @@ func TestFetchSurfForecast_RejectsIncompleteData(t *testing.T) {
 	surfAPIURL = server.URL
 	httpClient = server.Client()
 
-	if _, err := fetchSurfForecast(context.Background()); err == nil {
+	if _, err := fetchSurfForecast(context.Background(), defaultLocation); err == nil {
 		t.Fatal("fetchSurfForecast() expected validation error")
 	}
 }
+
+func TestSurfProfile_CustomBreak(t *testing.T) {
+	// A north-facing break whose swell and offshore arcs both wrap past north.
+	profile := SurfProfile{
+		MinWaveHeightFeet:        3,
+		MaxWaveHeightFeet:        12,
+		MinWavePeriodSeconds:     10,
+		MaxWavePeriodSeconds:     18,
+		MinSwellDirectionDegrees: 300,
+		MaxSwellDirectionDegrees: 40,
+		MinOffshoreWindDegrees:   135,
+		MaxOffshoreWindDegrees:   225,
+		LightWindMPH:             3,
+		MaxWindMPH:               20,
+	}
+
+	waves := []struct {
+		height, period, direction float64
+		want                      bool
+	}{
+		{height: 5, period: 12, direction: 350, want: true},
+		{height: 5, period: 12, direction: 10, want: true},
+		{height: 5, period: 12, direction: 90},
+		{height: 2, period: 12, direction: 350},
+		{height: 5, period: 20, direction: 350},
+	}
+	for _, tt := range waves {
+		if got := profile.isSurfableWave(tt.height, tt.period, tt.direction); got != tt.want {
+			t.Errorf("isSurfableWave(%v, %v, %v) = %v; want %v", tt.height, tt.period, tt.direction, got, tt.want)
+		}
+	}
+
+	winds := []struct {
+		speed     float64
+		direction int
+		want      bool
+	}{
+		{speed: 15, direction: 180, want: true},
+		{speed: 15, direction: 270},
+		{speed: 2, direction: 270, want: true},
+		{speed: 25, direction: 180},
+	}
+	for _, tt := range winds {
+		if got := isSurfableWind(profile.surfWind(tt.speed, tt.direction)); got != tt.want {
+			t.Errorf("isSurfableWind(surfWind(%v, %d)) = %v; want %v", tt.speed, tt.direction, got, tt.want)
+		}
+	}
+}
+
+func TestRateSurf(t *testing.T) {
+	profile := defaultSurfProfile
+	tests := []struct {
+		name      string
+		height    float64
+		period    float64
+		direction float64
+		wind      string
+		want      int
+	}{
+		{name: "too small", height: 1.4, period: 12, direction: 90, wind: surfWindOffshore, want: 0},
+		{name: "too big", height: 6.1, period: 12, direction: 90, wind: surfWindOffshore, want: 0},
+		{name: "missing height", height: math.NaN(), period: 12, direction: 90, wind: surfWindOffshore, want: 0},
+		{name: "wrong direction", height: 3, period: 12, direction: 200, wind: surfWindOffshore, want: 0},
+		{name: "unknown wind", height: 3, period: 12, direction: 90, want: 1},
+		{name: "onshore wind", height: 3, period: 12, direction: 90, wind: surfWindOnshore, want: 1},
+		{name: "strong wind", height: 3, period: 12, direction: 90, wind: surfWindStrong, want: 1},
+		{name: "smallest good hour", height: 1.5, period: 7, direction: 20, wind: surfWindLight, want: surfGoodRating},
+		{name: "just under the size point", height: 2.99, period: 7, direction: 90, wind: surfWindOffshore, want: 3},
+		{name: "size point", height: 3, period: 7, direction: 90, wind: surfWindOffshore, want: 4},
+		{name: "period point", height: 2, period: 10, direction: 90, wind: surfWindOffshore, want: 4},
+		{name: "best hour", height: 6, period: 14, direction: 160, wind: surfWindLight, want: surfMaxRating},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profile.rateSurf(tt.height, tt.period, tt.direction, tt.wind); got != tt.want {
+				t.Fatalf("rateSurf() = %d; want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSurfWind(t *testing.T) {
+	profile := defaultSurfProfile
+	for _, tt := range []struct {
+		speed     float64
+		direction int
+		want      string
+	}{
+		{speed: 5, direction: 90, want: surfWindLight},
+		{speed: 8, direction: 270, want: surfWindOffshore},
+		{speed: 8, direction: 90, want: surfWindOnshore},
+		{speed: 12, direction: 270, want: surfWindOffshore},
+		{speed: 12.1, direction: 270, want: surfWindStrong},
+	} {
+		if got := profile.surfWind(tt.speed, tt.direction); got != tt.want {
+			t.Errorf("surfWind(%v, %d) = %q; want %q", tt.speed, tt.direction, got, tt.want)
+		}
+	}
+}
+
+func TestBestSurfWindow(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	at := func(hour int) time.Time { return time.Date(2026, time.August, 11, hour, 0, 0, 0, loc) }
+	weather := WeatherData{
+		Timezone: "America/New_York",
+		Current:  CurrentWeather{Sunrise: at(7).Add(-15 * time.Minute).Unix(), Sunset: at(20).Unix()},
+	}
+	hoursRated := func(ratings map[int]int) []SurfHour {
+		var hours []SurfHour
+		for hour := 5; hour <= 23; hour++ {
+			hours = append(hours, SurfHour{Time: at(hour), Rating: ratings[hour], WaveHeight: float64(hour)})
+		}
+		return hours
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		ratings    map[int]int
+		wantStart  int
+		wantEnd    int
+		wantRating int
+	}{
+		{
+			name:      "longest run wins a tie",
+			now:       at(6),
+			ratings:   map[int]int{8: 3, 11: 3, 12: 3, 13: 3},
+			wantStart: 11, wantEnd: 14, wantRating: 3,
+		},
+		{
+			name:      "higher rating beats a longer run",
+			now:       at(6),
+			ratings:   map[int]int{8: 3, 9: 3, 10: 3, 15: 4},
+			wantStart: 15, wantEnd: 16, wantRating: 4,
+		},
+		{
+			name:      "earliest of equal runs",
+			now:       at(6),
+			ratings:   map[int]int{8: 4, 9: 4, 14: 4, 15: 4},
+			wantStart: 8, wantEnd: 10, wantRating: 4,
+		},
+		{
+			name:      "a rating change splits a run",
+			now:       at(6),
+			ratings:   map[int]int{8: 3, 9: 4, 10: 3, 11: 3},
+			wantStart: 9, wantEnd: 10, wantRating: 4,
+		},
+		{
+			name:      "hours before sunrise and after sunset are skipped",
+			now:       at(5),
+			ratings:   map[int]int{5: 5, 6: 5, 7: 3, 21: 5},
+			wantStart: 7, wantEnd: 8, wantRating: 3,
+		},
+		{
+			name:      "past hours are skipped",
+			now:       at(10),
+			ratings:   map[int]int{8: 5, 9: 5, 12: 3},
+			wantStart: 12, wantEnd: 13, wantRating: 3,
+		},
+		{name: "nothing good", now: at(6), ratings: map[int]int{8: 2, 9: 1}},
+		{name: "after sunset", now: at(21), ratings: map[int]int{22: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bestSurfWindow(hoursRated(tt.ratings), weather, tt.now)
+			if tt.wantRating == 0 {
+				if got != nil {
+					t.Fatalf("bestSurfWindow() = %+v; want nil", got)
+				}
+				return
+			}
+			if got == nil || !got.Start.Equal(at(tt.wantStart)) || !got.End.Equal(at(tt.wantEnd)) || got.Rating != tt.wantRating {
+				t.Fatalf("bestSurfWindow() = %+v; want %d:00–%d:00 rated %d", got, tt.wantStart, tt.wantEnd, tt.wantRating)
+			}
+			if got.Hour.WaveHeight != float64(tt.wantStart) {
+				t.Fatalf("window hour = %+v; want the first hour of the run", got.Hour)
+			}
+		})
+	}
+}
+
+func TestSurfOutlook(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	at := func(day, hour int) time.Time { return time.Date(2026, time.August, 11+day, hour, 0, 0, 0, loc) }
+	now := at(0, 13)
+	weather := WeatherData{
+		Timezone: "America/New_York",
+		Current:  CurrentWeather{Sunrise: at(0, 7).Unix(), Sunset: at(0, 20).Unix()},
+	}
+	good := func(t time.Time, rating int) SurfHour {
+		return SurfHour{Time: t, Rating: rating, WaveHeight: 3, WavePeriod: 9, WaveDirection: 90, Wind: surfWindOffshore}
+	}
+	hours := []SurfHour{
+		good(at(0, 9), 5), // already past
+		good(at(0, 15), 3),
+		good(at(1, 4), 5), // before daylight
+		good(at(1, 10), 4),
+		good(at(1, 11), 2),
+		// No wind forecast this far out: rated on the waves alone.
+		{Time: at(2, 9), Rating: 1, WaveHeight: 3, WavePeriod: 10, WaveDirection: 90},
+		{Time: at(2, 12), Rating: 1, WaveHeight: 1, WavePeriod: 10, WaveDirection: 90},
+		// Day 3 has no forecast hours.
+		good(at(4, 12), 3),
+	}
+
+	got := surfOutlook(hours, defaultSurfProfile, weather, now, 5)
+	want := []SurfDay{
+		{Date: at(0, 0), Label: "Today", Rating: 3},
+		{Date: at(1, 0), Label: "Wed", Rating: 4},
+		{Date: at(2, 0), Label: "Thu", Rating: 5, WavesOnly: true},
+		{Date: at(4, 0), Label: "Sat", Rating: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("surfOutlook() = %+v; want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || got[i].Label != want[i].Label || got[i].Rating != want[i].Rating || got[i].WavesOnly != want[i].WavesOnly {
+			t.Errorf("day %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+
+	if dots := got[1].Dots(); len(dots) != surfMaxRating || !dots[3] || dots[4] {
+		t.Errorf("Dots() = %v; want 4 of %d filled", dots, surfMaxRating)
+	}
+	if got := surfOutlook(hours, defaultSurfProfile, weather, now, 3); len(got) != 3 {
+		t.Errorf("surfOutlook(3 days) = %d days; want 3", len(got))
+	}
+}
+
+func TestRateSurfHours_KeepsSwellComponents(t *testing.T) {
+	at := time.Date(2026, time.August, 11, 9, 0, 0, 0, time.UTC)
+	forecast := SurfForecast{Hourly: SurfHourlyForecast{
+		Time:            []int64{at.Unix(), at.Add(time.Hour).Unix()},
+		WaveHeight:      []float64{3, 3},
+		WavePeriod:      []float64{9, 9},
+		WaveDirection:   []float64{90, 90},
+		SwellWaveHeight: []float64{2.4},
+		SwellWavePeriod: []float64{11},
+		WindWaveHeight:  []float64{0.8},
+	}}
+	weather := WeatherData{Hourly: []HourlyWeather{{Dt: at.Unix(), WindSpeed: 4, WindDeg: 90}}}
+
+	hours := rateSurfHours(forecast, weather, TideData{}, TideCurve{}, defaultSurfProfile, time.UTC)
+	if len(hours) != 2 {
+		t.Fatalf("rateSurfHours() = %d hours; want 2", len(hours))
+	}
+	if h := hours[0]; h.SwellHeight != 2.4 || h.SwellPeriod != 11 || h.WindWaveHeight != 0.8 || !math.IsNaN(h.SwellDirection) {
+		t.Fatalf("first hour = %+v", h)
+	}
+	if h := hours[1]; !math.IsNaN(h.SwellHeight) || !math.IsNaN(h.WindWaveHeight) {
+		t.Fatalf("second hour = %+v; want missing components as NaN", h)
+	}
+	if hours[0].Wind != surfWindLight || hours[0].Rating != 4 {
+		t.Fatalf("first hour wind = %q, rating = %d; want light, 4", hours[0].Wind, hours[0].Rating)
+	}
+}
+
+func TestSurfTideAt(t *testing.T) {
+	start := time.Date(2026, time.August, 11, 0, 0, 0, 0, time.UTC)
+	tides := []TidePrediction{
+		{Time: start, Type: "L", Height: 0},
+		{Time: start.Add(6 * time.Hour), Type: "H", Height: 3},
+		{Time: start.Add(12 * time.Hour), Type: "L", Height: 0},
+	}
+
+	tests := []struct {
+		at     time.Duration
+		stage  string
+		rising bool
+	}{
+		{at: 30 * time.Minute, stage: "low", rising: true},
+		{at: 3 * time.Hour, stage: "mid", rising: true},
+		{at: 5*time.Hour + 30*time.Minute, stage: "high", rising: true},
+		{at: 9 * time.Hour, stage: "mid"},
+		{at: 11*time.Hour + 30*time.Minute, stage: "low"},
+	}
+	for _, tt := range tests {
+		height, stage, rising := surfTideAt(tides, TideCurve{}, start.Add(tt.at))
+		if stage != tt.stage || rising != tt.rising || math.IsNaN(height) {
+			t.Errorf("surfTideAt(+%v) = %.2f, %q, %v; want %q, %v", tt.at, height, stage, rising, tt.stage, tt.rising)
+		}
+	}
+
+	curve := TideCurve{Levels: []TideLevel{{At: start.Add(2 * time.Hour), Height: 2.5}, {At: start.Add(4 * time.Hour), Height: 2.7}}}
+	if height, stage, _ := surfTideAt(tides, curve, start.Add(3*time.Hour)); math.Abs(height-2.6) > 0.01 || stage != "high" {
+		t.Errorf("surfTideAt() with curve = %v, %q; want 2.6, high", height, stage)
+	}
+	if height, stage, _ := surfTideAt(tides, TideCurve{}, start.Add(13*time.Hour)); !math.IsNaN(height) || stage != "" {
+		t.Errorf("surfTideAt() past the predictions = %v, %q; want unknown", height, stage)
+	}
+}
+
+func TestSurfProfile_SuitsTide(t *testing.T) {
+	feet := func(v float64) *float64 { return &v }
+	midRising := SurfHour{TideHeight: 1.5, TideStage: "mid", TideRising: true}
+	lowFalling := SurfHour{TideHeight: 0.2, TideStage: "low"}
+	unknown := SurfHour{TideHeight: math.NaN()}
+
+	tests := []struct {
+		name    string
+		profile SurfProfile
+		hour    SurfHour
+		want    bool
+	}{
+		{name: "no preference", hour: lowFalling, want: true},
+		{name: "stage and direction", profile: SurfProfile{TidePhases: []string{"mid", "rising"}}, hour: midRising, want: true},
+		{name: "wrong direction", profile: SurfProfile{TidePhases: []string{"mid", "falling"}}, hour: midRising},
+		{name: "either stage", profile: SurfProfile{TidePhases: []string{"low", "high"}}, hour: lowFalling, want: true},
+		{name: "wrong stage", profile: SurfProfile{TidePhases: []string{"low", "high"}}, hour: midRising},
+		{name: "above minimum", profile: SurfProfile{MinTideFeet: feet(1)}, hour: midRising, want: true},
+		{name: "below minimum", profile: SurfProfile{MinTideFeet: feet(1)}, hour: lowFalling},
+		{name: "above maximum", profile: SurfProfile{MaxTideFeet: feet(1)}, hour: midRising},
+		{name: "unknown tide", profile: SurfProfile{TidePhases: []string{"high"}, MinTideFeet: feet(2)}, hour: unknown, want: true},
+	}
+	for _, tt := range tests {
+		if got := tt.profile.suitsTide(tt.hour); got != tt.want {
+			t.Errorf("%s: suitsTide() = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateSurfHours_TidePreference(t *testing.T) {
+	start := time.Date(2026, time.August, 11, 6, 0, 0, 0, time.UTC)
+	tide := TideData{Predictions: []TidePrediction{
+		{Time: start.Add(6 * time.Hour), Type: "H", Height: 3},
+		{Time: start, Type: "L", Height: 0},
+	}}
+	forecast := SurfForecast{Hourly: SurfHourlyForecast{
+		Time:          []int64{start.Add(time.Hour).Unix(), start.Add(3 * time.Hour).Unix(), start.Add(7 * time.Hour).Unix()},
+		WaveHeight:    []float64{3, 3, 3},
+		WavePeriod:    []float64{9, 9, 9},
+		WaveDirection: []float64{90, 90, 90},
+	}}
+	var weather WeatherData
+	for _, at := range forecast.Hourly.Time {
+		weather.Hourly = append(weather.Hourly, HourlyWeather{Dt: at, WindSpeed: 4, WindDeg: 90})
+	}
+	profile := defaultSurfProfile
+	profile.TidePhases = []string{"mid", "rising"}
+
+	hours := rateSurfHours(forecast, weather, tide, TideCurve{}, profile, time.UTC)
+	got := []int{hours[0].Rating, hours[1].Rating, hours[2].Rating}
+	if got[0] != 1 || got[1] != 4 || got[2] != 4 {
+		t.Fatalf("ratings = %v; want the low tide hour capped at 1 and the hour past the tides left alone", got)
+	}
+	if hours[1].TideStage != "mid" || !hours[1].TideRising || hours[2].TideStage != "" {
+		t.Fatalf("tides = %+v, %+v", hours[1], hours[2])
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// The tide chart is drawn on a 600x95 canvas: the curve between the axis
+// ends, day and "now" labels above it, and the high/low labels below.
+const (
+	tideChartLeft       = 35.0
+	tideChartRight      = 565.0
+	tideChartCurveTop   = 22.0
+	tideChartCurveBase  = 58.0
+	tideChartAxisY      = 44.0
+	tideChartSampleStep = 10 * time.Minute
+	// tideChartLabelGap keeps neighbouring high/low labels from overlapping
+	// where tides come close together.
+	tideChartLabelGap = 72.0
+	// maxTideWindowHours bounds each side of the chart window.
+	maxTideWindowHours = 72
+)
+
+// tideWindowPast and tideWindowFuture set the span of the tide chart around
+// the current time. tideCurveInterval is the NOAA interval of the dense
+// series the curve is drawn from, or tideCurveIntervalNone to draw it from
+// the highs and lows alone.
+var (
+	tideWindowPast    = 6 * time.Hour
+	tideWindowFuture  = 30 * time.Hour
+	tideCurveInterval = "6"
+)
+
+const tideCurveIntervalNone = "none"
+
+// tideCurveIntervals are the NOAA prediction intervals accepted for the
+// curve: six-minute and hourly.
+var tideCurveIntervals = []string{"6", "h", tideCurveIntervalNone}
+
+// getTideCurve returns the dense predictions for today's chart from the
+// cache or NOAA. Unlike getTide there is no stale fallback: without a curve
+// the chart is drawn from the highs and lows instead.
+func getTideCurve(ctx context.Context, loc Location) (TideCurve, error) {
+	if tideCurveInterval == tideCurveIntervalNone {
+		return TideCurve{}, nil
+	}
+	if cachedData, found := tideCache.Get(tideCurveCacheKey(time.Now(), loc)); found {
+		return cachedData.(TideCurve), nil
+	}
+	return refreshTideCurve(ctx, loc)
+}
+
+// needsTideCurve reports whether a dashboard reads the dense curve: the tide
+// chart draws it, the beach status measures a surge in the observed level
+// against it, and surf ratings with tide preferences take heights from it.
+func needsTideCurve(panels Panels, loc Location) bool {
+	return panels.Tide ||
+		(panels.Beach && showObservedWaterLevel) ||
+		((panels.Beach || panels.Surf) && loc.Surf.usesTide())
+}
+
+func tideCurveCacheKey(now time.Time, loc Location) string {
+	return loc.TideStation + ":curve:" + tideCurveInterval + ":" + now.In(loc.timeLocation()).Format("2006-01-02")
+}
+
+// refreshTideCurve fetches today's dense predictions and caches them,
+// ignoring any cached entry.
+func refreshTideCurve(ctx context.Context, loc Location) (TideCurve, error) {
+	cacheKey := tideCurveCacheKey(time.Now(), loc)
+	result, err := shareUpstream(ctx, "tide:"+cacheKey, func(ctx context.Context) (any, error) {
+		curve, err := fetchTideCurveFromAPI(ctx, loc)
+		if err != nil {
+			return TideCurve{}, err
+		}
+		tideCache.Set(cacheKey, curve, cache.DefaultExpiration)
+		return curve, nil
+	})
+	if err != nil {
+		return TideCurve{}, err
+	}
+	return result.(TideCurve), nil
+}
+
+// fetchTideCurveFromAPI fetches the tideCurveInterval series over the same
+// dates as fetchTideFromAPI.
+func fetchTideCurveFromAPI(ctx context.Context, loc Location) (TideCurve, error) {
+	tz := loc.timeLocation()
+	begin, end := tideFetchRange(time.Now().In(tz))
+	curveURL, err := buildTideURL(noaaAPIURL, loc.TideStation, tideCurveInterval, begin, end)
+	if err != nil {
+		return TideCurve{}, err
+	}
+
+	var rawData noaaPredictions
+	if err := fetchNOAAJSON(ctx, "tide curve", curveURL, &rawData); err != nil {
+		return TideCurve{}, err
+	}
+	return processTideCurve(rawData, tz)
+}
+
+func processTideCurve(rawData noaaPredictions, tz *time.Location) (TideCurve, error) {
+	var curve TideCurve
+	for _, p := range rawData.Predictions {
+		at, err := time.ParseInLocation("2006-01-02 15:04", p.Time, tz)
+		if err != nil {
+			continue
+		}
+		height, err := strconv.ParseFloat(p.Height, 64)
+		if err != nil {
+			continue
+		}
+		curve.Levels = append(curve.Levels, TideLevel{At: at, Height: height})
+	}
+	if len(curve.Levels) < 2 {
+		return TideCurve{}, &APIError{URL: noaaAPIURL, Operation: "process tide curve", Err: fmt.Errorf("got %d valid predictions of %d", len(curve.Levels), len(rawData.Predictions))}
+	}
+	sort.SliceStable(curve.Levels, func(i, j int) bool { return curve.Levels[i].At.Before(curve.Levels[j].At) })
+	return curve, nil
+}
+
+// tideChartPoint is a point on the chart canvas. For highs and lows it also
+// carries the labels drawn under it.
+type tideChartPoint struct {
+	X, Y      float64
+	Type      string
+	Time      string
+	Height    string
+	ShowLabel bool
+}
+
+// tideChartDay marks a local midnight inside the window.
+type tideChartDay struct {
+	X     float64
+	Label string
+	// ShowLabel is false when the day name would collide with the "now"
+	// label or run off the chart.
+	ShowLabel bool
+}
+
+// tideChartNow is the current-time marker: a vertical line, a dot on the
+// curve and the interpolated height with a rising or falling arrow.
+type tideChartNow struct {
+	X, Y   float64
+	Label  string
+	Anchor string
+}
+
+// tideChart is the laid-out chart. The SVG and PNG renderers both draw from
+// it so the two stay identical.
+type tideChart struct {
+	Curve []tideChartPoint
+	// Observed is the measured water level, drawn dashed over the curve.
+	Observed []tideChartPoint
+	Points   []tideChartPoint
+	Days     []tideChartDay
+	Now      *tideChartNow
+}
+
+// buildTideChart places the predictions on a real time axis running from
+// tideWindowPast before now to tideWindowFuture after it. The curve follows
+// NOAA's dense series where it covers the window; otherwise it is a cosine
+// between the highs and lows, which is close but flattens asymmetric tides.
+// Observed levels inside the window are overlaid on the prediction. A chart
+// with no curve means the predictions do not cover the window.
+func buildTideChart(predictions []TidePrediction, curve TideCurve, observed []TideLevel, now time.Time) tideChart {
+	sorted := sortedTidePredictions(predictions)
+	start := now.Add(-tideWindowPast)
+	end := now.Add(tideWindowFuture)
+	span := end.Sub(start)
+	xAt := func(t time.Time) float64 {
+		return tideChartLeft + float64(t.Sub(start))/float64(span)*(tideChartRight-tideChartLeft)
+	}
+
+	type sample struct {
+		at     time.Time
+		height float64
+	}
+	var samples []sample
+	for _, level := range curve.Levels {
+		if !level.At.Before(start) && !level.At.After(end) {
+			samples = append(samples, sample{at: level.At, height: level.Height})
+		}
+	}
+	heightAt := func(t time.Time) (float64, bool, bool) { return tideLevelAt(curve.Levels, t) }
+	if len(samples) < 2 {
+		samples = nil
+		heightAt = func(t time.Time) (float64, bool, bool) { return tideHeightAt(sorted, t) }
+		for t := start; !t.After(end); t = t.Add(tideChartSampleStep) {
+			if height, _, ok := tideHeightAt(sorted, t); ok {
+				samples = append(samples, sample{at: t, height: height})
+			}
+		}
+	}
+	var inWindow []TidePrediction
+	for _, p := range sorted {
+		if !p.Time.Before(start) && !p.Time.After(end) {
+			inWindow = append(inWindow, p)
+			samples = append(samples, sample{at: p.Time, height: p.Height})
+		}
+	}
+	if len(samples) == 0 {
+		return tideChart{}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].at.Before(samples[j].at) })
+
+	var observedInWindow []TideLevel
+	for _, level := range observed {
+		if !level.At.Before(start) && !level.At.After(end) {
+			observedInWindow = append(observedInWindow, level)
+		}
+	}
+
+	minHeight, maxHeight := samples[0].height, samples[0].height
+	for _, s := range samples {
+		minHeight = math.Min(minHeight, s.height)
+		maxHeight = math.Max(maxHeight, s.height)
+	}
+	for _, level := range observedInWindow {
+		minHeight = math.Min(minHeight, level.Height)
+		maxHeight = math.Max(maxHeight, level.Height)
+	}
+	yAt := func(height float64) float64 {
+		scale := 0.5
+		if maxHeight != minHeight {
+			scale = (height - minHeight) / (maxHeight - minHeight)
+		}
+		return tideChartCurveBase - scale*(tideChartCurveBase-tideChartCurveTop)
+	}
+
+	var chart tideChart
+	for _, s := range samples {
+		chart.Curve = append(chart.Curve, tideChartPoint{X: xAt(s.at), Y: yAt(s.height)})
+	}
+	if len(observedInWindow) >= 2 {
+		for _, level := range observedInWindow {
+			chart.Observed = append(chart.Observed, tideChartPoint{X: xAt(level.At), Y: yAt(level.Height)})
+		}
+	}
+
+	var nowX float64
+	if height, rising, ok := heightAt(now); ok {
+		nowX = xAt(now)
+		arrow := "↓"
+		if rising {
+			arrow = "↑"
+		}
+		anchor := "middle"
+		switch {
+		case nowX < tideChartLeft+45:
+			anchor = "start"
+		case nowX > tideChartRight-45:
+			anchor = "end"
+		}
+		chart.Now = &tideChartNow{
+			X:      nowX,
+			Y:      yAt(height),
+			Label:  fmt.Sprintf("%s ft %s", formatTideHeight(height), arrow),
+			Anchor: anchor,
+		}
+	}
+
+	lastLabelX := math.Inf(-1)
+	for _, p := range inWindow {
+		x := xAt(p.Time)
+		point := tideChartPoint{
+			X:      x,
+			Y:      yAt(p.Height),
+			Type:   p.Type,
+			Time:   p.Time.In(now.Location()).Format("3:04 PM"),
+			Height: formatTideHeight(p.Height) + " ft",
+		}
+		if x-lastLabelX >= tideChartLabelGap {
+			point.ShowLabel = true
+			lastLabelX = x
+		}
+		chart.Points = append(chart.Points, point)
+	}
+
+	loc := now.Location()
+	local := start.In(loc)
+	for day := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc); day.Before(end); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		x := xAt(day)
+		chart.Days = append(chart.Days, tideChartDay{
+			X:         x,
+			Label:     day.Format("Mon"),
+			ShowLabel: x < tideChartRight-30 && (chart.Now == nil || math.Abs(x-nowX) > 70),
+		})
+	}
+
+	return chart
+}
+
+// tideHeightAt interpolates the tide height at t from the surrounding high
+// and low, and reports whether the tide is rising. ok is false outside the
+// predictions.
+func tideHeightAt(sorted []TidePrediction, t time.Time) (height float64, rising bool, ok bool) {
+	for i := 1; i < len(sorted); i++ {
+		prev, next := sorted[i-1], sorted[i]
+		if t.Before(prev.Time) || t.After(next.Time) {
+			continue
+		}
+		span := next.Time.Sub(prev.Time)
+		if span <= 0 {
+			return prev.Height, next.Height > prev.Height, true
+		}
+		fraction := float64(t.Sub(prev.Time)) / float64(span)
+		height = prev.Height + (next.Height-prev.Height)*(1-math.Cos(math.Pi*fraction))/2
+		return height, next.Height > prev.Height, true
+	}
+	return 0, false, false
+}
+
+// tideLevelAt interpolates linearly between the dense levels around t. The
+// levels must be sorted.
+func tideLevelAt(levels []TideLevel, t time.Time) (height float64, rising bool, ok bool) {
+	if len(levels) < 2 {
+		return 0, false, false
+	}
+	i := sort.Search(len(levels), func(i int) bool { return !levels[i].At.Before(t) })
+	if i == len(levels) || (i == 0 && levels[0].At.After(t)) {
+		return 0, false, false
+	}
+	if i == 0 {
+		i = 1
+	}
+	prev, next := levels[i-1], levels[i]
+	span := next.At.Sub(prev.At)
+	if span <= 0 {
+		return prev.Height, next.Height > prev.Height, true
+	}
+	fraction := float64(t.Sub(prev.At)) / float64(span)
+	return prev.Height + (next.Height-prev.Height)*fraction, next.Height > prev.Height, true
+}
+
+func sortedTidePredictions(predictions []TidePrediction) []TidePrediction {
+	sorted := make([]TidePrediction, 0, len(predictions))
+	for _, p := range predictions {
+		if !p.Time.IsZero() {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	return sorted
+}
+
+// formatTideHeight prints a height in feet with one decimal, without a
+// negative zero.
+func formatTideHeight(height float64) string {
+	text := fmt.Sprintf("%.1f", height)
+	if text == "-0.0" {
+		return "0.0"
+	}
+	return text
+}
+
+func generateTideSVG(chart tideChart) (template.HTML, error) {
+	if len(chart.Curve) == 0 {
+		return tideUnavailableSVG(), nil
+	}
+	const svgTemplate = `
+    <svg width="600" height="95" viewBox="0 0 600 95">
+        <line x1="35" y1="44" x2="565" y2="44" stroke="black" stroke-width="1.5" />
+        {{range .Days}}
+        <line x1="{{printf "%.1f" .X}}" y1="16" x2="{{printf "%.1f" .X}}" y2="62" stroke="black" stroke-width="1" stroke-dasharray="3 3" />
+        {{if .ShowLabel}}<text x="{{printf "%.1f" .X}}" y="13" font-size="13" text-anchor="start" dx="3">{{.Label}}</text>{{end}}
+        {{end}}
+        <path
+            fill="none"
+            stroke="black"
+            stroke-width="3"
+            d="{{.Path}}"
+        />
+        {{if .ObservedPath}}
+        <path
+            class="tide-observed"
+            fill="none"
+            stroke="black"
+            stroke-width="2"
+            stroke-dasharray="5 3"
+            d="{{.ObservedPath}}"
+        />
+        {{end}}
+        {{range .Points}}
+        <circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="5" fill="black" />
+        {{if .ShowLabel}}
+        <text x="{{printf "%.1f" .X}}" y="78" font-size="16" text-anchor="middle" font-weight="bold">{{.Type}} {{.Height}}</text>
+        <text x="{{printf "%.1f" .X}}" y="91" font-size="15" text-anchor="middle" font-weight="bold">{{.Time}}</text>
+        {{end}}
+        {{end}}
+        {{with .Now}}
+        <g class="tide-now">
+            <line x1="{{printf "%.1f" .X}}" y1="16" x2="{{printf "%.1f" .X}}" y2="62" stroke="black" stroke-width="2" />
+            <circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="6" fill="white" stroke="black" stroke-width="2.5" />
+            <text x="{{printf "%.1f" .X}}" y="13" font-size="15" text-anchor="{{.Anchor}}" font-weight="bold">{{.Label}}</text>
+        </g>
+        {{end}}
+    </svg>`
+
+	tmpl := template.Must(template.New("svg").Parse(svgTemplate))
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		tideChart
+		Path         string
+		ObservedPath string
+	}{
+		tideChart:    chart,
+		Path:         tideChartPath(chart.Curve),
+		ObservedPath: tideChartPath(chart.Observed),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering SVG: %w", err)
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// tideChartPath joins points into SVG path data.
+func tideChartPath(points []tideChartPoint) string {
+	var path strings.Builder
+	for i, p := range points {
+		if i == 0 {
+			fmt.Fprintf(&path, "M %.1f %.1f", p.X, p.Y)
+		} else {
+			fmt.Fprintf(&path, " L %.1f %.1f", p.X, p.Y)
+		}
+	}
+	return path.String()
+}
+
+func tideUnavailableSVG() template.HTML {
+	return template.HTML(`
+    <svg width="600" height="95" viewBox="0 0 600 95">
+        <line x1="35" y1="44" x2="565" y2="44" stroke="black" stroke-width="2" stroke-dasharray="6 6" />
+        <text x="300" y="49" font-size="18" text-anchor="middle" font-weight="bold">Tide data unavailable</text>
+    </svg>`)
+}
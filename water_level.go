@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// waterLevelCacheExpiration matches NOAA's six-minute observation
+	// cadence; older readings would hide a surge that is still building.
+	waterLevelCacheExpiration = 6 * time.Minute
+	// waterLevelMaxAge is how old the latest reading may be and still be
+	// compared against the prediction.
+	waterLevelMaxAge = time.Hour
+)
+
+// showObservedWaterLevel turns the observed overlay and surge notice on.
+// tideAnomalyThreshold is the observed-minus-predicted difference, in feet
+// either way, that puts the anomaly in the beach status.
+var (
+	showObservedWaterLevel = true
+	tideAnomalyThreshold   = 1.0
+)
+
+// WaterLevelData is the recent observed water level at a station, oldest
+// first, in feet above MLLW.
+type WaterLevelData struct {
+	Observed []TideLevel
+}
+
+// noaaWaterLevels is the water_level product as NOAA returns it. Stations
+// without a gauge answer 200 with an error message instead of data.
+type noaaWaterLevels struct {
+	Data []struct {
+		Time   string `json:"t"`
+		Height string `json:"v"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// getWaterLevel returns the recent observations from the cache or NOAA.
+// There is no stale fallback: old readings say nothing about the water now.
+func getWaterLevel(ctx context.Context, loc Location) (WaterLevelData, error) {
+	if cachedData, found := tideCache.Get(waterLevelCacheKey(loc)); found {
+		return cachedData.(WaterLevelData), nil
+	}
+	return refreshWaterLevel(ctx, loc)
+}
+
+func waterLevelCacheKey(loc Location) string {
+	return loc.TideStation + ":observed"
+}
+
+// refreshWaterLevel fetches the recent observations and caches them,
+// ignoring any cached entry.
+func refreshWaterLevel(ctx context.Context, loc Location) (WaterLevelData, error) {
+	cacheKey := waterLevelCacheKey(loc)
+	result, err := shareUpstream(ctx, "tide:"+cacheKey, func(ctx context.Context) (any, error) {
+		levels, err := fetchWaterLevelFromAPI(ctx, loc)
+		if err != nil {
+			return WaterLevelData{}, err
+		}
+		tideCache.Set(cacheKey, levels, waterLevelCacheExpiration)
+		return levels, nil
+	})
+	if err != nil {
+		return WaterLevelData{}, err
+	}
+	return result.(WaterLevelData), nil
+}
+
+// fetchWaterLevelFromAPI fetches the observations covering the past part of
+// the chart window.
+func fetchWaterLevelFromAPI(ctx context.Context, loc Location) (WaterLevelData, error) {
+	levelURL, err := buildWaterLevelURL(noaaAPIURL, loc.TideStation, max(int(tideWindowPast/time.Hour), 1))
+	if err != nil {
+		return WaterLevelData{}, err
+	}
+
+	var rawData noaaWaterLevels
+	if err := fetchNOAAJSON(ctx, "water level", levelURL, &rawData); err != nil {
+		return WaterLevelData{}, err
+	}
+	return processWaterLevel(rawData, loc.timeLocation())
+}
+
+// buildWaterLevelURL asks NOAA for the last hours of observations on the
+// same datum and clock as the predictions.
+func buildWaterLevelURL(baseURL, station string, hours int) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build water level request", Err: err}
+	}
+	q := u.Query()
+	q.Set("station", station)
+	q.Set("product", "water_level")
+	q.Set("datum", "MLLW")
+	q.Set("units", "english")
+	q.Set("time_zone", "lst_ldt")
+	q.Set("format", "json")
+	q.Set("range", strconv.Itoa(hours))
+	q.Del("interval")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func processWaterLevel(rawData noaaWaterLevels, tz *time.Location) (WaterLevelData, error) {
+	if rawData.Error != nil {
+		return WaterLevelData{}, &APIError{URL: noaaAPIURL, Operation: "process water level", Err: fmt.Errorf("%s", rawData.Error.Message)}
+	}
+	var levels WaterLevelData
+	for _, d := range rawData.Data {
+		at, err := time.ParseInLocation("2006-01-02 15:04", d.Time, tz)
+		if err != nil {
+			continue
+		}
+		// Gaps in the gauge record come back with an empty value.
+		height, err := strconv.ParseFloat(d.Height, 64)
+		if err != nil {
+			continue
+		}
+		levels.Observed = append(levels.Observed, TideLevel{At: at, Height: height})
+	}
+	if len(levels.Observed) == 0 {
+		return WaterLevelData{}, &APIError{URL: noaaAPIURL, Operation: "process water level", Err: fmt.Errorf("no valid observations in %d readings", len(rawData.Data))}
+	}
+	sort.SliceStable(levels.Observed, func(i, j int) bool { return levels.Observed[i].At.Before(levels.Observed[j].At) })
+	return levels, nil
+}
+
+// tideAnomaly is the latest observed level minus the predicted level at the
+// same moment, taken from the dense curve when it covers that moment and the
+// highs and lows otherwise. ok is false without a recent reading to compare.
+func tideAnomaly(levels WaterLevelData, tide TideData, curve TideCurve, now time.Time) (anomaly float64, ok bool) {
+	if len(levels.Observed) == 0 {
+		return 0, false
+	}
+	latest := levels.Observed[len(levels.Observed)-1]
+	if now.Sub(latest.At) > waterLevelMaxAge {
+		return 0, false
+	}
+	predicted, _, ok := tideLevelAt(curve.Levels, latest.At)
+	if !ok {
+		predicted, _, ok = tideHeightAt(sortedTidePredictions(tide.Predictions), latest.At)
+	}
+	if !ok {
+		return 0, false
+	}
+	return latest.Height - predicted, true
+}
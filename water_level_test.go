@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func TestBuildWaterLevelURL(t *testing.T) {
+	levelURL, err := buildWaterLevelURL(noaaAPIURLDefault, "8720218", 6)
+	if err != nil {
+		t.Fatalf("buildWaterLevelURL() error = %v", err)
+	}
+	parsedURL, err := url.Parse(levelURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	query := parsedURL.Query()
+	for key, want := range map[string]string{
+		"station":   "8720218",
+		"product":   "water_level",
+		"datum":     "MLLW",
+		"time_zone": "lst_ldt",
+		"range":     "6",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestProcessWaterLevel(t *testing.T) {
+	var raw noaaWaterLevels
+	if err := json.Unmarshal([]byte(`{"data":[
+		{"t":"2024-04-08 07:06","v":"2.412"},
+		{"t":"2024-04-08 07:00","v":"2.301"},
+		{"t":"2024-04-08 07:12","v":""}
+	]}`), &raw); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	levels, err := processWaterLevel(raw, time.UTC)
+	if err != nil {
+		t.Fatalf("processWaterLevel() error = %v", err)
+	}
+	if len(levels.Observed) != 2 || levels.Observed[0].Height != 2.301 || levels.Observed[1].Height != 2.412 {
+		t.Fatalf("observed = %+v; want the two readings oldest first", levels.Observed)
+	}
+
+	raw = noaaWaterLevels{}
+	if err := json.Unmarshal([]byte(`{"error":{"message":"No data was found."}}`), &raw); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, err := processWaterLevel(raw, time.UTC); err == nil || !strings.Contains(err.Error(), "No data was found") {
+		t.Fatalf("processWaterLevel() error = %v; want NOAA's message", err)
+	}
+}
+
+func TestTideAnomaly(t *testing.T) {
+	now := time.Date(2024, time.April, 8, 12, 0, 0, 0, time.UTC)
+	tide := TideData{Predictions: []TidePrediction{
+		{Time: now.Add(-6 * time.Hour), Type: "L", Height: 0},
+		{Time: now.Add(6 * time.Hour), Type: "H", Height: 4},
+	}}
+	levels := WaterLevelData{Observed: []TideLevel{
+		{At: now.Add(-12 * time.Minute), Height: 2.5},
+		{At: now.Add(-6 * time.Minute), Height: 3.2},
+	}}
+
+	// Between the low and high the cosine is near 1.97 ft.
+	anomaly, ok := tideAnomaly(levels, tide, TideCurve{}, now)
+	predicted, _, _ := tideHeightAt(tide.Predictions, now.Add(-6*time.Minute))
+	if !ok || math.Abs(anomaly-(3.2-predicted)) > 1e-9 {
+		t.Fatalf("tideAnomaly() = %.3f, %v; want %.3f from the highs and lows", anomaly, ok, 3.2-predicted)
+	}
+
+	curve := TideCurve{Levels: []TideLevel{
+		{At: now.Add(-time.Hour), Height: 1.5},
+		{At: now, Height: 2.0},
+	}}
+	if anomaly, ok := tideAnomaly(levels, tide, curve, now); !ok || math.Abs(anomaly-1.25) > 1e-9 {
+		t.Fatalf("tideAnomaly() with curve = %.3f, %v; want 1.25", anomaly, ok)
+	}
+
+	if _, ok := tideAnomaly(levels, tide, curve, now.Add(2*time.Hour)); ok {
+		t.Fatal("tideAnomaly() should ignore a reading over an hour old")
+	}
+	if _, ok := tideAnomaly(WaterLevelData{}, tide, curve, now); ok {
+		t.Fatal("tideAnomaly() should need an observation")
+	}
+}
+
+func TestGetWaterLevel_CachesObservations(t *testing.T) {
+	oldCache, oldNOAAURL, oldHTTPClient := tideCache, noaaAPIURL, httpClient
+	defer func() { tideCache, noaaAPIURL, httpClient = oldCache, oldNOAAURL, oldHTTPClient }()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.URL.Query().Get("product"); got != "water_level" {
+			t.Errorf("product = %q; want water_level", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"t":"2024-04-08 07:00","v":"2.301"}]}`))
+	}))
+	defer server.Close()
+
+	tideCache = cache.New(time.Hour, time.Hour)
+	noaaAPIURL = server.URL
+	httpClient = server.Client()
+
+	for range 2 {
+		levels, err := getWaterLevel(context.Background(), defaultLocation)
+		if err != nil {
+			t.Fatalf("getWaterLevel() error = %v", err)
+		}
+		if len(levels.Observed) != 1 || levels.Observed[0].Height != 2.301 {
+			t.Fatalf("observed = %+v", levels.Observed)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("NOAA requests = %d; want 1", requests)
+	}
+}
+
+func TestGenerateTideSVG_ObservedOverlay(t *testing.T) {
+	now := time.Date(2024, time.April, 8, 12, 0, 0, 0, time.UTC)
+	predictions := []TidePrediction{
+		{Time: now.Add(-8 * time.Hour), Type: "L", Height: 0},
+		{Time: now.Add(4 * time.Hour), Type: "H", Height: 4},
+		{Time: now.Add(16 * time.Hour), Type: "L", Height: 0},
+		{Time: now.Add(28 * time.Hour), Type: "H", Height: 4},
+		{Time: now.Add(40 * time.Hour), Type: "L", Height: 0},
+	}
+	observed := []TideLevel{
+		{At: now.AddDate(0, 0, -1), Height: 9},
+		{At: now.Add(-time.Hour), Height: 5},
+		{At: now.Add(-30 * time.Minute), Height: 5.5},
+	}
+
+	chart := buildTideChart(predictions, TideCurve{}, observed, now)
+	if len(chart.Observed) != 2 {
+		t.Fatalf("observed points = %d; want the 2 inside the window", len(chart.Observed))
+	}
+	// The surge sets the top of the scale.
+	if got := chart.Observed[1].Y; math.Abs(got-tideChartCurveTop) > 0.01 {
+		t.Errorf("highest observation y = %.2f; want %.2f", got, tideChartCurveTop)
+	}
+	svg, err := generateTideSVG(chart)
+	if err != nil {
+		t.Fatalf("generateTideSVG() error = %v", err)
+	}
+	if !strings.Contains(string(svg), `class="tide-observed"`) {
+		t.Fatalf("SVG is missing the observed overlay: %s", svg)
+	}
+
+	chart = buildTideChart(predictions, TideCurve{}, nil, now)
+	if svg, _ := generateTideSVG(chart); strings.Contains(string(svg), "tide-observed") {
+		t.Fatal("SVG should have no overlay without observations")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WeatherAlert is a government warning, watch or advisory for the location,
+// in OpenWeather's One Call shape. Start and End are Unix seconds; End is 0
+// when the alert has no end time.
+type WeatherAlert struct {
+	SenderName string `json:"sender_name"`
+	Event      string `json:"event"`
+	Start      int64  `json:"start"`
+	End        int64  `json:"end"`
+}
+
+// Alert levels, from least to most serious. Events that are none of these,
+// such as statements, are left to the beach hazards.
+const (
+	alertLevelNone = iota
+	alertLevelAdvisory
+	alertLevelWatch
+	alertLevelWarning
+)
+
+// alertLevel reads the level from the event name: "Tropical Storm Warning"
+// is a warning.
+func alertLevel(event string) int {
+	event = strings.ToLower(event)
+	switch {
+	case strings.HasSuffix(event, "warning"):
+		return alertLevelWarning
+	case strings.HasSuffix(event, "watch"):
+		return alertLevelWatch
+	case strings.HasSuffix(event, "advisory"):
+		return alertLevelAdvisory
+	default:
+		return alertLevelNone
+	}
+}
+
+// topWeatherAlert returns the most serious alert in effect now, the earliest
+// to end among equals, or nil when there is none.
+func topWeatherAlert(alerts []WeatherAlert, now time.Time) *WeatherAlert {
+	var top *WeatherAlert
+	for i, alert := range alerts {
+		level := alertLevel(alert.Event)
+		if level == alertLevelNone || alert.Start > now.Unix() || (alert.End != 0 && alert.End <= now.Unix()) {
+			continue
+		}
+		if top == nil {
+			top = &alerts[i]
+			continue
+		}
+		topLevel := alertLevel(top.Event)
+		if level > topLevel || (level == topLevel && alert.End != 0 && (top.End == 0 || alert.End < top.End)) {
+			top = &alerts[i]
+		}
+	}
+	return top
+}
+
+// getPageNotice decides what the notice slot under the weather shows. A
+// warning in effect takes it over from the beach status; a watch or
+// advisory only fills it when the beach has nothing to say.
+func getPageNotice(alerts []WeatherAlert, beach *BeachStatus, now time.Time) *BeachStatus {
+	alert := topWeatherAlert(alerts, now)
+	if alert == nil {
+		return beach
+	}
+	if alertLevel(alert.Event) == alertLevelWarning {
+		return &BeachStatus{Kind: "warning", Text: weatherAlertText(*alert, now)}
+	}
+	if beach != nil {
+		return beach
+	}
+	return &BeachStatus{Kind: "alert", Text: weatherAlertText(*alert, now)}
+}
+
+// weatherAlertText names the alert and when it ends: "Tropical Storm
+// Warning until Thu 8 PM".
+func weatherAlertText(alert WeatherAlert, now time.Time) string {
+	if alert.End == 0 {
+		return alert.Event
+	}
+	return alert.Event + " until " + alertClock(time.Unix(alert.End, 0), now)
+}
+
+// weatherAlert converts an NWS alert to the One Call shape.
+func (a NWSAlert) weatherAlert() WeatherAlert {
+	alert := WeatherAlert{SenderName: a.Sender, Event: a.Event}
+	if !a.Onset.IsZero() {
+		alert.Start = a.Onset.Unix()
+	}
+	if !a.Ends.IsZero() {
+		alert.End = a.Ends.Unix()
+	}
+	return alert
+}
+
+// alerts fetches the alerts in effect at loc. The forecast does not depend
+// on them, so callers log a failure rather than failing the weather.
+func (p nwsProvider) alerts(ctx context.Context, loc Location) ([]WeatherAlert, error) {
+	alertsURL, err := buildNWSAlertsURL(p.baseURL, loc)
+	if err != nil {
+		return nil, err
+	}
+	var response nwsAlerts
+	if err := getWeatherJSON(ctx, alertsURL, alertsURL, &response); err != nil {
+		return nil, err
+	}
+	var alerts []WeatherAlert
+	for _, alert := range response.alerts() {
+		alerts = append(alerts, alert.weatherAlert())
+	}
+	return alerts, nil
+}
+
+// buildNWSAlertsURL builds /alerts/active?point={lat},{lon}, rounded as for
+// the /points lookup.
+func buildNWSAlertsURL(baseURL string, loc Location) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build weather request", Err: err}
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/alerts/active"
+	u.RawQuery = url.Values{"point": {nwsPointParam(loc)}}.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWeatherData_DecodesOneCallAlerts(t *testing.T) {
+	var data WeatherData
+	err := json.Unmarshal([]byte(`{"alerts": [{"sender_name": "NWS Jacksonville FL", "event": "Tropical Storm Warning",
+		"start": 1718982000, "end": 1719025200, "description": "...", "tags": ["Wind"]}]}`), &data)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := WeatherAlert{SenderName: "NWS Jacksonville FL", Event: "Tropical Storm Warning", Start: 1718982000, End: 1719025200}
+	if len(data.Alerts) != 1 || data.Alerts[0] != want {
+		t.Fatalf("alerts = %+v; want %+v", data.Alerts, want)
+	}
+}
+
+func TestTopWeatherAlert(t *testing.T) {
+	now := time.Date(2026, time.August, 11, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) int64 { return now.Add(d).Unix() }
+	advisory := WeatherAlert{Event: "Heat Advisory", Start: at(-time.Hour), End: at(8 * time.Hour)}
+	watch := WeatherAlert{Event: "Hurricane Watch", Start: at(-time.Hour)}
+	warning := WeatherAlert{Event: "Tropical Storm Warning", Start: at(-time.Hour), End: at(36 * time.Hour)}
+	sooner := WeatherAlert{Event: "Flood Warning", End: at(3 * time.Hour)}
+	statement := WeatherAlert{Event: "Special Weather Statement", Start: at(-time.Hour), End: at(time.Hour)}
+	later := WeatherAlert{Event: "Hurricane Warning", Start: at(time.Hour)}
+	ended := WeatherAlert{Event: "Tornado Warning", Start: at(-2 * time.Hour), End: at(-time.Hour)}
+
+	tests := []struct {
+		name   string
+		alerts []WeatherAlert
+		want   string
+	}{
+		{name: "none", alerts: nil},
+		{name: "statement only", alerts: []WeatherAlert{statement}},
+		{name: "warning over watch", alerts: []WeatherAlert{advisory, watch, warning}, want: warning.Event},
+		{name: "watch over advisory", alerts: []WeatherAlert{advisory, watch}, want: watch.Event},
+		{name: "sooner warning", alerts: []WeatherAlert{warning, sooner}, want: sooner.Event},
+		{name: "not yet or no longer", alerts: []WeatherAlert{later, ended, advisory}, want: advisory.Event},
+	}
+	for _, tt := range tests {
+		got := topWeatherAlert(tt.alerts, now)
+		if (got == nil && tt.want != "") || (got != nil && got.Event != tt.want) {
+			t.Errorf("%s: topWeatherAlert() = %+v; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetPageNotice_WarningTakesOverBeachStatus(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	now := time.Date(2026, time.August, 11, 9, 0, 0, 0, loc)
+	surf := &BeachStatus{Kind: "surf", Text: "Best 7–10 AM, 3 ft @ 9 s, offshore"}
+	warning := WeatherAlert{Event: "Tropical Storm Warning", End: time.Date(2026, time.August, 13, 20, 0, 0, 0, loc).Unix()}
+	advisory := WeatherAlert{Event: "Small Craft Advisory", End: time.Date(2026, time.August, 11, 18, 0, 0, 0, loc).Unix()}
+
+	tests := []struct {
+		name   string
+		alerts []WeatherAlert
+		beach  *BeachStatus
+		want   BeachStatus
+	}{
+		{name: "warning", alerts: []WeatherAlert{advisory, warning}, beach: surf, want: BeachStatus{Kind: "warning", Text: "Tropical Storm Warning until Thu 8 PM"}},
+		{name: "advisory yields", alerts: []WeatherAlert{advisory}, beach: surf, want: *surf},
+		{name: "advisory fills", alerts: []WeatherAlert{advisory}, want: BeachStatus{Kind: "alert", Text: "Small Craft Advisory until 6 PM"}},
+		{name: "no alerts", beach: surf, want: *surf},
+	}
+	for _, tt := range tests {
+		got := getPageNotice(tt.alerts, tt.beach, now)
+		if got == nil || *got != tt.want {
+			t.Errorf("%s: getPageNotice() = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+	if got := getPageNotice(nil, nil, now); got != nil {
+		t.Errorf("getPageNotice() = %+v; want nil with nothing to say", got)
+	}
+	if got := weatherAlertText(WeatherAlert{Event: "Hurricane Watch"}, now); got != "Hurricane Watch" {
+		t.Errorf("weatherAlertText() = %q; want the event alone without an end", got)
+	}
+}
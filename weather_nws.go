@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// nwsProvider uses the US National Weather Service API (api.weather.gov).
+// It is keyless but only covers the United States. A forecast takes three
+// requests: the /points lookup, which maps coordinates to a forecast grid
+// and is cached indefinitely, then the hourly and daily forecasts.
+type nwsProvider struct {
+	baseURL string
+}
+
+type nwsPoint struct {
+	Properties struct {
+		Forecast       string `json:"forecast"`
+		ForecastHourly string `json:"forecastHourly"`
+		ForecastZone   string `json:"forecastZone"`
+		TimeZone       string `json:"timeZone"`
+	} `json:"properties"`
+}
+
+type nwsForecast struct {
+	Properties struct {
+		Periods []nwsPeriod `json:"periods"`
+	} `json:"properties"`
+}
+
+type nwsPeriod struct {
+	StartTime                  time.Time `json:"startTime"`
+	IsDaytime                  bool      `json:"isDaytime"`
+	Temperature                float64   `json:"temperature"`
+	ProbabilityOfPrecipitation nwsValue  `json:"probabilityOfPrecipitation"`
+	Dewpoint                   nwsValue  `json:"dewpoint"`
+	RelativeHumidity           nwsValue  `json:"relativeHumidity"`
+	WindSpeed                  string    `json:"windSpeed"`
+	WindDirection              string    `json:"windDirection"`
+	Icon                       string    `json:"icon"`
+	ShortForecast              string    `json:"shortForecast"`
+	DetailedForecast           string    `json:"detailedForecast"`
+}
+
+// nwsValue is a quantitative value; Value is null when the NWS has no data.
+type nwsValue struct {
+	Value *float64 `json:"value"`
+}
+
+func (v nwsValue) orZero() float64 {
+	if v.Value == nil {
+		return 0
+	}
+	return *v.Value
+}
+
+func (nwsProvider) Name() string { return weatherProviderNWS }
+
+func (p nwsProvider) FetchWeather(ctx context.Context, loc Location) (WeatherData, error) {
+	point, err := p.point(ctx, loc)
+	if err != nil {
+		return WeatherData{}, err
+	}
+
+	var hourly, daily nwsForecast
+	if err := getWeatherJSON(ctx, nwsForecastURL(point.Properties.ForecastHourly), point.Properties.ForecastHourly, &hourly); err != nil {
+		return WeatherData{}, err
+	}
+	if err := getWeatherJSON(ctx, nwsForecastURL(point.Properties.Forecast), point.Properties.Forecast, &daily); err != nil {
+		return WeatherData{}, err
+	}
+
+	data, err := nwsWeatherData(point, hourly.Properties.Periods, daily.Properties.Periods, loc, time.Now())
+	if err != nil {
+		return WeatherData{}, err
+	}
+	if data.Alerts, err = p.alerts(ctx, loc); err != nil {
+		logJSON(logEntry{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Error getting weather alerts: %v", err),
+		})
+	}
+	return data, nil
+}
+
+// point resolves the forecast URLs and zone for loc. Grid assignments
+// practically never change, so the lookup is cached without expiry; a point
+// cached before the zone was read is looked up again.
+func (p nwsProvider) point(ctx context.Context, loc Location) (nwsPoint, error) {
+	cacheKey := "nws-point:" + loc.coordinatesKey()
+	if cached, found := weatherCache.Get(cacheKey); found && cached.(nwsPoint).Properties.ForecastZone != "" {
+		return cached.(nwsPoint), nil
+	}
+
+	pointURL, err := buildNWSPointURL(p.baseURL, loc)
+	if err != nil {
+		return nwsPoint{}, err
+	}
+	var point nwsPoint
+	if err := getWeatherJSON(ctx, pointURL, pointURL, &point); err != nil {
+		return nwsPoint{}, err
+	}
+	if point.Properties.Forecast == "" || point.Properties.ForecastHourly == "" {
+		return nwsPoint{}, &APIError{URL: pointURL, Operation: "process weather data", Err: fmt.Errorf("no forecast for this location")}
+	}
+
+	weatherCache.Set(cacheKey, point, cache.NoExpiration)
+	return point, nil
+}
+
+// buildNWSPointURL builds /points/{lat},{lon}. The NWS redirects requests
+// with more than four decimal places, so coordinates are rounded.
+func buildNWSPointURL(baseURL string, loc Location) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build weather request", Err: err}
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/points/" + nwsPointParam(loc)
+	return u.String(), nil
+}
+
+// nwsPointParam writes loc as "{lat},{lon}" to four decimal places.
+func nwsPointParam(loc Location) string {
+	round := func(v float64) string {
+		return strconv.FormatFloat(math.Round(v*10000)/10000, 'f', -1, 64)
+	}
+	return round(loc.Latitude) + "," + round(loc.Longitude)
+}
+
+func nwsForecastURL(forecastURL string) string {
+	u, err := url.Parse(forecastURL)
+	if err != nil {
+		return forecastURL
+	}
+	q := u.Query()
+	q.Set("units", "us")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func nwsWeatherData(point nwsPoint, hourly, daily []nwsPeriod, loc Location, now time.Time) (WeatherData, error) {
+	if len(hourly) == 0 || len(daily) == 0 {
+		return WeatherData{}, &APIError{URL: weatherProviderNWS, Operation: "process weather data", Err: fmt.Errorf("no forecast periods")}
+	}
+
+	timezone := point.Properties.TimeZone
+	tz, err := time.LoadLocation(timezone)
+	if err != nil {
+		timezone = loc.Timezone
+		tz = loc.timeLocation()
+	}
+	localNow := now.In(tz)
+
+	data := WeatherData{
+		Timezone:       timezone,
+		TimezoneOffset: timezoneOffset(tz, now),
+	}
+	for _, period := range hourly {
+		data.Hourly = append(data.Hourly, period.hourlyWeather())
+	}
+
+	// The NWS hourly forecast starts at the current hour, which is the
+	// closest thing it offers to current conditions without a station lookup.
+	first := data.Hourly[0]
+	data.Current = CurrentWeather{
+		Dt:        now.Unix(),
+		Temp:      first.Temp,
+		FeelsLike: first.FeelsLike,
+		Humidity:  first.Humidity,
+		DewPoint:  first.DewPoint,
+		WindSpeed: first.WindSpeed,
+		WindDeg:   first.WindDeg,
+		Weather:   first.Weather,
+	}
+	if sunrise, sunset, ok := sunTimes(localNow, loc.Latitude, loc.Longitude); ok {
+		data.Current.Sunrise = sunrise.Unix()
+		data.Current.Sunset = sunset.Unix()
+	}
+
+	// Daily periods alternate day and night; each calendar day gets the
+	// detailed text of its first period as the summary. The daytime period
+	// gives the high and the conditions, the night starting that evening the
+	// low. A day that starts at night has only a low, and takes its
+	// conditions from the night.
+	days := map[string]int{}
+	for _, period := range daily {
+		start := period.StartTime.In(tz)
+		key := start.Format("2006-01-02")
+		i, seen := days[key]
+		if !seen {
+			summary := period.DetailedForecast
+			if summary == "" {
+				summary = period.ShortForecast
+			}
+			midnight := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, tz)
+			data.Daily = append(data.Daily, DailyWeather{
+				Dt:        midnight.Unix(),
+				MoonPhase: moonPhase(start),
+				Summary:   summary,
+			})
+			i = len(data.Daily) - 1
+			days[key] = i
+		}
+
+		day := &data.Daily[i]
+		temp := period.Temperature
+		if period.IsDaytime {
+			day.Temp.Max = &temp
+		} else if day.Temp.Min == nil {
+			day.Temp.Min = &temp
+		}
+		if period.IsDaytime || !seen {
+			hour := period.hourlyWeather()
+			day.Weather = hour.Weather
+			day.Pop = hour.Pop
+			day.WindSpeed = hour.WindSpeed
+			day.WindDeg = hour.WindDeg
+		}
+	}
+
+	return data, nil
+}
+
+func (p nwsPeriod) hourlyWeather() HourlyWeather {
+	temp := p.Temperature
+	condition := nwsCondition(p.Icon)
+	weather := condition.condition(p.IsDaytime)
+	if p.ShortForecast != "" {
+		weather.Description = strings.ToLower(p.ShortForecast)
+	}
+
+	return HourlyWeather{
+		Dt:        p.StartTime.Unix(),
+		Temp:      temp,
+		FeelsLike: temp,
+		Humidity:  int(p.RelativeHumidity.orZero()),
+		DewPoint:  celsiusToFahrenheit(p.Dewpoint.orZero()),
+		WindSpeed: parseNWSWindSpeed(p.WindSpeed),
+		WindDeg:   compassDegrees(p.WindDirection),
+		Weather:   []WeatherCondition{weather},
+		Pop:       p.ProbabilityOfPrecipitation.orZero() / 100,
+	}
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// parseNWSWindSpeed reads "10 mph" or "5 to 10 mph", keeping the upper end.
+func parseNWSWindSpeed(s string) float64 {
+	var speed float64
+	for _, field := range strings.Fields(s) {
+		if v, err := strconv.ParseFloat(field, 64); err == nil {
+			speed = v
+		}
+	}
+	return speed
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassPoint names the nearest of the sixteen compass points to deg.
+func compassPoint(deg float64) string {
+	i := int(math.Round(math.Mod(deg, 360)/22.5)) % len(compassPoints)
+	if i < 0 {
+		i += len(compassPoints)
+	}
+	return compassPoints[i]
+}
+
+func compassDegrees(direction string) int {
+	for i, point := range compassPoints {
+		if strings.EqualFold(direction, point) {
+			return int(float64(i) * 22.5)
+		}
+	}
+	return 0
+}
+
+// nwsConditions maps NWS icon codes (the path segment after day/ or night/
+// in an icon URL) onto the nearest OpenWeather condition.
+var nwsConditions = map[string]weatherCondition{
+	"skc":             {800, "Clear", "clear"},
+	"few":             {801, "Clouds", "a few clouds"},
+	"sct":             {802, "Clouds", "partly cloudy"},
+	"bkn":             {803, "Clouds", "mostly cloudy"},
+	"ovc":             {804, "Clouds", "overcast"},
+	"wind_skc":        {800, "Clear", "clear and windy"},
+	"wind_few":        {801, "Clouds", "a few clouds and windy"},
+	"wind_sct":        {802, "Clouds", "partly cloudy and windy"},
+	"wind_bkn":        {803, "Clouds", "mostly cloudy and windy"},
+	"wind_ovc":        {804, "Clouds", "overcast and windy"},
+	"snow":            {601, "Snow", "snow"},
+	"rain_snow":       {616, "Snow", "rain and snow"},
+	"rain_sleet":      {611, "Snow", "rain and sleet"},
+	"snow_sleet":      {611, "Snow", "snow and sleet"},
+	"fzra":            {511, "Rain", "freezing rain"},
+	"rain_fzra":       {511, "Rain", "rain and freezing rain"},
+	"snow_fzra":       {511, "Rain", "freezing rain and snow"},
+	"sleet":           {611, "Snow", "sleet"},
+	"rain":            {501, "Rain", "rain"},
+	"rain_showers":    {521, "Rain", "rain showers"},
+	"rain_showers_hi": {520, "Rain", "isolated showers"},
+	"tsra":            {211, "Thunderstorm", "thunderstorms"},
+	"tsra_sct":        {210, "Thunderstorm", "scattered thunderstorms"},
+	"tsra_hi":         {210, "Thunderstorm", "isolated thunderstorms"},
+	"tornado":         {781, "Tornado", "tornado"},
+	"hurricane":       {781, "Tornado", "hurricane conditions"},
+	"tropical_storm":  {781, "Tornado", "tropical storm conditions"},
+	"dust":            {761, "Dust", "dust"},
+	"smoke":           {711, "Smoke", "smoke"},
+	"haze":            {721, "Haze", "haze"},
+	"hot":             {800, "Clear", "hot"},
+	"cold":            {800, "Clear", "cold"},
+	"blizzard":        {602, "Snow", "blizzard"},
+	"fog":             {741, "Fog", "fog"},
+}
+
+// nwsCondition reads the condition from an icon URL such as
+// https://api.weather.gov/icons/land/day/tsra_hi,40?size=medium. Icons for a
+// changing hour list two codes; the first is used.
+func nwsCondition(iconURL string) weatherCondition {
+	u, err := url.Parse(iconURL)
+	if err == nil {
+		segments := strings.Split(u.Path, "/")
+		for i, segment := range segments {
+			if (segment == "day" || segment == "night") && i+1 < len(segments) {
+				code, _, _ := strings.Cut(segments[i+1], ",")
+				if c, ok := nwsConditions[code]; ok {
+					return c
+				}
+			}
+		}
+	}
+	return weatherCondition{804, "Clouds", "cloudy"}
+}
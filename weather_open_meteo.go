@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// openMeteoProvider uses the keyless Open-Meteo forecast API, the same
+// service the surf forecast comes from.
+type openMeteoProvider struct {
+	baseURL string
+}
+
+// openMeteoResponse is the subset of the forecast response that is used.
+// Open-Meteo returns hourly and daily data as parallel arrays.
+type openMeteoResponse struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Current          struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		RelativeHumidity    float64 `json:"relative_humidity_2m"`
+		DewPoint            float64 `json:"dew_point_2m"`
+		PressureMSL         float64 `json:"pressure_msl"`
+		CloudCover          float64 `json:"cloud_cover"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindDirection       float64 `json:"wind_direction_10m"`
+		WindGusts           float64 `json:"wind_gusts_10m"`
+		UVIndex             float64 `json:"uv_index"`
+		WeatherCode         int     `json:"weather_code"`
+		IsDay               int     `json:"is_day"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		ApparentTemperature      []float64 `json:"apparent_temperature"`
+		RelativeHumidity         []float64 `json:"relative_humidity_2m"`
+		DewPoint                 []float64 `json:"dew_point_2m"`
+		PressureMSL              []float64 `json:"pressure_msl"`
+		CloudCover               []float64 `json:"cloud_cover"`
+		Visibility               []float64 `json:"visibility"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
+		WindDirection            []float64 `json:"wind_direction_10m"`
+		WindGusts                []float64 `json:"wind_gusts_10m"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Rain                     []float64 `json:"rain"`
+		UVIndex                  []float64 `json:"uv_index"`
+		WeatherCode              []int     `json:"weather_code"`
+		IsDay                    []int     `json:"is_day"`
+	} `json:"hourly"`
+	Daily struct {
+		Time           []int64   `json:"time"`
+		WeatherCode    []int     `json:"weather_code"`
+		TemperatureMax []float64 `json:"temperature_2m_max"`
+		TemperatureMin []float64 `json:"temperature_2m_min"`
+		Sunrise        []int64   `json:"sunrise"`
+		Sunset         []int64   `json:"sunset"`
+		// Daily precipitation probability is the day's highest hourly one.
+		PrecipitationProbability []float64 `json:"precipitation_probability_max"`
+		WindSpeed                []float64 `json:"wind_speed_10m_max"`
+		WindGusts                []float64 `json:"wind_gusts_10m_max"`
+		WindDirection            []float64 `json:"wind_direction_10m_dominant"`
+	} `json:"daily"`
+}
+
+func (openMeteoProvider) Name() string { return weatherProviderOpenMeteo }
+
+func (p openMeteoProvider) FetchWeather(ctx context.Context, loc Location) (WeatherData, error) {
+	apiURL, err := buildOpenMeteoURL(p.baseURL, loc)
+	if err != nil {
+		return WeatherData{}, err
+	}
+
+	var raw openMeteoResponse
+	if err := getWeatherJSON(ctx, apiURL, p.baseURL, &raw); err != nil {
+		return WeatherData{}, err
+	}
+	return raw.weatherData(loc)
+}
+
+func buildOpenMeteoURL(baseURL string, loc Location) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build weather request", Err: err}
+	}
+	q := u.Query()
+	q.Set("latitude", loc.latitudeParam())
+	q.Set("longitude", loc.longitudeParam())
+	q.Set("current", "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,pressure_msl,cloud_cover,wind_speed_10m,wind_direction_10m,wind_gusts_10m,uv_index,weather_code,is_day")
+	q.Set("hourly", "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,pressure_msl,cloud_cover,visibility,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation_probability,rain,uv_index,weather_code,is_day")
+	q.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,sunrise,sunset,precipitation_probability_max,wind_speed_10m_max,wind_gusts_10m_max,wind_direction_10m_dominant")
+	q.Set("temperature_unit", "fahrenheit")
+	q.Set("wind_speed_unit", "mph")
+	q.Set("timeformat", "unixtime")
+	q.Set("timezone", "auto")
+	// Ask for the longest strip any dashboard can show, plus a day for a
+	// cached forecast that outlives midnight, so dashboards at the same
+	// coordinates share one cached forecast.
+	q.Set("forecast_days", strconv.Itoa(maxForecastDays+1))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (raw openMeteoResponse) weatherData(loc Location) (WeatherData, error) {
+	if len(raw.Daily.Time) == 0 {
+		return WeatherData{}, &APIError{URL: weatherProviderOpenMeteo, Operation: "process weather data", Err: fmt.Errorf("no daily forecast")}
+	}
+
+	data := WeatherData{
+		Timezone:       raw.Timezone,
+		TimezoneOffset: raw.UTCOffsetSeconds,
+	}
+	tz, err := time.LoadLocation(raw.Timezone)
+	if err != nil {
+		tz = loc.timeLocation()
+		data.Timezone = loc.Timezone
+	}
+
+	c := raw.Current
+	data.Current = CurrentWeather{
+		Dt:        c.Time,
+		Sunrise:   valueAt(raw.Daily.Sunrise, 0),
+		Sunset:    valueAt(raw.Daily.Sunset, 0),
+		Temp:      c.Temperature,
+		FeelsLike: c.ApparentTemperature,
+		Pressure:  int(c.PressureMSL),
+		Humidity:  int(c.RelativeHumidity),
+		DewPoint:  c.DewPoint,
+		Uvi:       c.UVIndex,
+		Clouds:    c.CloudCover,
+		WindSpeed: c.WindSpeed,
+		WindDeg:   int(c.WindDirection),
+		WindGust:  c.WindGusts,
+		Weather:   []WeatherCondition{wmoCondition(c.WeatherCode).condition(c.IsDay == 1)},
+	}
+
+	// Open-Meteo starts the hourly series at local midnight; OpenWeather
+	// starts at the current hour, which is what the forecast columns expect.
+	h := raw.Hourly
+	for i, dt := range h.Time {
+		if dt+3600 <= c.Time {
+			continue
+		}
+		data.Hourly = append(data.Hourly, HourlyWeather{
+			Dt:         dt,
+			Temp:       valueAt(h.Temperature, i),
+			FeelsLike:  valueAt(h.ApparentTemperature, i),
+			Pressure:   int(valueAt(h.PressureMSL, i)),
+			Humidity:   int(valueAt(h.RelativeHumidity, i)),
+			DewPoint:   valueAt(h.DewPoint, i),
+			Uvi:        valueAt(h.UVIndex, i),
+			Clouds:     valueAt(h.CloudCover, i),
+			Visibility: int(valueAt(h.Visibility, i)),
+			WindSpeed:  valueAt(h.WindSpeed, i),
+			WindGust:   valueAt(h.WindGusts, i),
+			WindDeg:    int(valueAt(h.WindDirection, i)),
+			Weather:    []WeatherCondition{wmoCondition(valueAt(h.WeatherCode, i)).condition(valueAt(h.IsDay, i) == 1)},
+			Pop:        valueAt(h.PrecipitationProbability, i) / 100,
+			Rain:       Rain{OneH: valueAt(h.Rain, i)},
+		})
+	}
+
+	d := raw.Daily
+	for i, dt := range d.Time {
+		day := time.Unix(dt, 0).In(tz)
+		condition := wmoCondition(valueAt(d.WeatherCode, i)).condition(true)
+		high, low := valueAt(d.TemperatureMax, i), valueAt(d.TemperatureMin, i)
+		data.Daily = append(data.Daily, DailyWeather{
+			Dt:        dt,
+			MoonPhase: moonPhase(day),
+			Summary:   weatherSummary(condition, high, low),
+			Temp:      DailyTemp{Min: &low, Max: &high},
+			Pop:       valueAt(d.PrecipitationProbability, i) / 100,
+			WindSpeed: valueAt(d.WindSpeed, i),
+			WindGust:  valueAt(d.WindGusts, i),
+			WindDeg:   int(valueAt(d.WindDirection, i)),
+			Weather:   []WeatherCondition{condition},
+		})
+	}
+
+	return data, nil
+}
+
+// valueAt tolerates the shorter arrays Open-Meteo returns when a variable
+// is unavailable for part of the range.
+func valueAt[T any](values []T, i int) T {
+	var zero T
+	if i < 0 || i >= len(values) {
+		return zero
+	}
+	return values[i]
+}
+
+// wmoConditions maps WMO weather interpretation codes, which Open-Meteo
+// reports, onto the nearest OpenWeather condition.
+var wmoConditions = map[int]weatherCondition{
+	0:  {800, "Clear", "clear sky"},
+	1:  {801, "Clouds", "mainly clear"},
+	2:  {802, "Clouds", "partly cloudy"},
+	3:  {804, "Clouds", "overcast"},
+	45: {741, "Fog", "fog"},
+	48: {741, "Fog", "freezing fog"},
+	51: {300, "Drizzle", "light drizzle"},
+	53: {301, "Drizzle", "drizzle"},
+	55: {302, "Drizzle", "heavy drizzle"},
+	56: {511, "Rain", "freezing drizzle"},
+	57: {511, "Rain", "heavy freezing drizzle"},
+	61: {500, "Rain", "light rain"},
+	63: {501, "Rain", "moderate rain"},
+	65: {502, "Rain", "heavy rain"},
+	66: {511, "Rain", "freezing rain"},
+	67: {511, "Rain", "heavy freezing rain"},
+	71: {600, "Snow", "light snow"},
+	73: {601, "Snow", "snow"},
+	75: {602, "Snow", "heavy snow"},
+	77: {600, "Snow", "snow grains"},
+	80: {520, "Rain", "light showers"},
+	81: {521, "Rain", "showers"},
+	82: {522, "Rain", "heavy showers"},
+	85: {620, "Snow", "light snow showers"},
+	86: {621, "Snow", "snow showers"},
+	95: {211, "Thunderstorm", "thunderstorm"},
+	96: {201, "Thunderstorm", "thunderstorm with hail"},
+	99: {202, "Thunderstorm", "severe thunderstorm with hail"},
+}
+
+func wmoCondition(code int) weatherCondition {
+	if c, ok := wmoConditions[code]; ok {
+		return c
+	}
+	return weatherCondition{804, "Clouds", "cloudy"}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	weatherProviderOpenWeather = "openweather"
+	weatherProviderOpenMeteo   = "open-meteo"
+	weatherProviderNWS         = "nws"
+)
+
+// WeatherProvider fetches the forecast for a location. Every provider returns
+// OpenWeather-shaped WeatherData, including OpenWeather condition IDs and
+// "01d"-style icon codes, so the templates and getIconClassName do not need
+// to know where the data came from. Rounding and time formatting are applied
+// by fetchWeatherFromAPI afterwards.
+type WeatherProvider interface {
+	Name() string
+	FetchWeather(ctx context.Context, loc Location) (WeatherData, error)
+}
+
+// weatherProviders is tried in order: the configured provider first, then
+// each fallback, so an outage at one vendor does not blank the dashboard.
+var weatherProviders = []WeatherProvider{openWeatherProvider{baseURL: weatherAPIURLDefault}}
+
+var weatherProviderRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "weather_provider_requests_total",
+	Help: "Weather fetches by provider and result",
+}, []string{"provider", "result"})
+
+// weatherProviderTitles are the names shown in the page footer.
+var weatherProviderTitles = map[string]string{
+	weatherProviderOpenWeather: "OpenWeather",
+	weatherProviderOpenMeteo:   "Open-Meteo",
+	weatherProviderNWS:         "NWS",
+}
+
+// configuredWeatherProviderNames lists the primary provider followed by the
+// fallbacks, in the order they are tried.
+func configuredWeatherProviderNames(apis APIConfig) []string {
+	return append([]string{apis.WeatherProvider}, apis.WeatherFallbacks...)
+}
+
+// newWeatherProviders builds the configured providers in the order they are
+// tried.
+func newWeatherProviders(apis APIConfig) ([]WeatherProvider, error) {
+	var providers []WeatherProvider
+	for _, name := range configuredWeatherProviderNames(apis) {
+		provider, err := newWeatherProvider(name, apis)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// newWeatherProvider builds the provider named in the config.
+func newWeatherProvider(name string, apis APIConfig) (WeatherProvider, error) {
+	switch name {
+	case weatherProviderOpenWeather:
+		return openWeatherProvider{baseURL: apis.WeatherURL, apiKey: apis.OpenWeatherAPIKey}, nil
+	case weatherProviderOpenMeteo:
+		return openMeteoProvider{baseURL: apis.OpenMeteoURL}, nil
+	case weatherProviderNWS:
+		return nwsProvider{baseURL: apis.NWSURL}, nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider %q", name)
+	}
+}
+
+func weatherProviderNames() []string {
+	names := []string{weatherProviderOpenWeather, weatherProviderOpenMeteo, weatherProviderNWS}
+	sort.Strings(names)
+	return names
+}
+
+// openWeatherProvider speaks the OpenWeather One Call 3.0 API, whose response
+// WeatherData mirrors directly.
+type openWeatherProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+func (openWeatherProvider) Name() string { return weatherProviderOpenWeather }
+
+func (p openWeatherProvider) FetchWeather(ctx context.Context, loc Location) (WeatherData, error) {
+	if strings.TrimSpace(p.baseURL) == "" {
+		return WeatherData{}, fmt.Errorf("weather API URL is not configured")
+	}
+
+	apiURL, err := buildWeatherURL(p.baseURL, p.apiKey, loc)
+	if err != nil {
+		return WeatherData{}, err
+	}
+
+	var data WeatherData
+	if err := getWeatherJSON(ctx, apiURL, p.baseURL, &data); err != nil {
+		return WeatherData{}, err
+	}
+	return data, nil
+}
+
+// buildWeatherURL adds the location and units to an OpenWeather One Call base
+// URL. The API key is only added when the base URL does not carry one.
+func buildWeatherURL(baseURL, apiKey string, loc Location) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", &APIError{URL: baseURL, Operation: "build weather request", Err: err}
+	}
+	q := u.Query()
+	q.Set("lat", loc.latitudeParam())
+	q.Set("lon", loc.longitudeParam())
+	q.Set("exclude", "minutely")
+	q.Set("units", "imperial")
+	if apiKey != "" && q.Get("appid") == "" {
+		q.Set("appid", apiKey)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// getWeatherJSON GETs apiURL and decodes the JSON body into out. Errors are
+// reported against reportURL so API keys in the query string stay out of logs.
+func getWeatherJSON(ctx context.Context, apiURL, reportURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return &APIError{URL: reportURL, Operation: "build weather request", Err: err}
+	}
+	req.Header.Set("User-Agent", "kindle-weather/1.0")
+	req.Header.Set("Accept", "application/geo+json, application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &APIError{URL: reportURL, Operation: "GET weather data", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{URL: reportURL, Operation: "GET weather data", Err: fmt.Errorf("status code %d", resp.StatusCode)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &APIError{URL: reportURL, Operation: "decode weather data", Err: err}
+	}
+	return nil
+}
+
+// weatherCondition describes an OpenWeather condition code.
+type weatherCondition struct {
+	ID          int
+	Main        string
+	Description string
+}
+
+// condition builds the WeatherCondition for c with the day or night icon.
+func (c weatherCondition) condition(isDay bool) WeatherCondition {
+	icon := owmIconCode(c.ID)
+	if isDay {
+		icon += "d"
+	} else {
+		icon += "n"
+	}
+	return WeatherCondition{ID: c.ID, Main: c.Main, Description: c.Description, Icon: icon}
+}
+
+// owmIconCode returns the OpenWeather icon number for a condition ID. Only
+// the day/night suffix matters to getIconClassName, but keeping the numbers
+// right means the data matches what OpenWeather itself would return.
+func owmIconCode(id int) string {
+	switch {
+	case id >= 200 && id < 300:
+		return "11"
+	case id >= 300 && id < 400, id >= 520 && id < 600:
+		return "09"
+	case id == 511, id >= 600 && id < 700:
+		return "13"
+	case id >= 500 && id < 600:
+		return "10"
+	case id >= 700 && id < 800:
+		return "50"
+	case id == 800:
+		return "01"
+	case id == 801:
+		return "02"
+	case id == 802:
+		return "03"
+	default:
+		return "04"
+	}
+}
+
+// weatherSummary stands in for OpenWeather's daily summary sentence.
+func weatherSummary(condition WeatherCondition, high, low float64) string {
+	description := condition.Description
+	if description == "" {
+		return fmt.Sprintf("High %.0f°F, low %.0f°F", high, low)
+	}
+	return fmt.Sprintf("%s%s today, high %.0f°F and low %.0f°F",
+		strings.ToUpper(description[:1]), description[1:], high, low)
+}
+
+func timezoneOffset(tz *time.Location, at time.Time) int {
+	_, offset := at.In(tz).Zone()
+	return offset
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOpenMeteoProvider_FetchWeather(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"timezone": "America/New_York",
+			"utc_offset_seconds": -14400,
+			"current": {"time": 1718971200, "temperature_2m": 84.6, "apparent_temperature": 90.2,
+				"wind_speed_10m": 8.4, "wind_direction_10m": 250, "weather_code": 2, "is_day": 1},
+			"hourly": {
+				"time": [1718956800, 1718971200, 1718974800],
+				"temperature_2m": [79, 84.6, 85.1],
+				"precipitation_probability": [0, 10, 40],
+				"weather_code": [0, 2, 61],
+				"is_day": [0, 1, 1]
+			},
+			"daily": {
+				"time": [1718942400, 1719028800],
+				"weather_code": [61, 0],
+				"temperature_2m_max": [88.4, 90],
+				"temperature_2m_min": [74.2, 75],
+				"sunrise": [1718965511, 1719051900],
+				"sunset": [1719016087, 1719102500],
+				"precipitation_probability_max": [80, 5],
+				"wind_speed_10m_max": [12.4, 9],
+				"wind_gusts_10m_max": [21, 15.2],
+				"wind_direction_10m_dominant": [225, 90]
+			}
+		}`))
+	}))
+	defer server.Close()
+	oldClient := httpClient
+	httpClient = server.Client()
+	defer func() { httpClient = oldClient }()
+
+	data, err := openMeteoProvider{baseURL: server.URL}.FetchWeather(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("FetchWeather() error = %v", err)
+	}
+
+	for _, want := range []string{"latitude=29.65", "longitude=-81.2", "temperature_unit=fahrenheit", "timeformat=unixtime", "forecast_days=8"} {
+		if !strings.Contains(gotQuery, want) {
+			t.Errorf("query %q does not contain %q", gotQuery, want)
+		}
+	}
+	if data.Timezone != "America/New_York" || data.Current.Temp != 84.6 || data.Current.WindDeg != 250 {
+		t.Fatalf("unexpected current weather: %+v", data.Current)
+	}
+	if got := getIconClassName(data.Current.Weather[0].Icon, data.Current.Weather[0].ID); got != "wi wi-owm-day-802" {
+		t.Fatalf("current icon = %q; want wi wi-owm-day-802", got)
+	}
+	if data.Current.Sunrise != 1718965511 || data.Current.Sunset != 1719016087 {
+		t.Fatalf("sunrise/sunset = %d/%d", data.Current.Sunrise, data.Current.Sunset)
+	}
+	if len(data.Hourly) != 2 || data.Hourly[0].Dt != 1718971200 {
+		t.Fatalf("hourly should start at the current hour: %+v", data.Hourly)
+	}
+	if rain := data.Hourly[1]; rain.Pop != 0.4 || rain.Weather[0].ID != 500 {
+		t.Fatalf("unexpected rain hour: %+v", rain)
+	}
+	if len(data.Daily) != 2 || data.Daily[0].Summary != "Light rain today, high 88°F and low 74°F" {
+		t.Fatalf("unexpected daily: %+v", data.Daily)
+	}
+	today := data.Daily[0]
+	if today.Dt != 1718942400 || *today.Temp.Max != 88.4 || *today.Temp.Min != 74.2 || today.Pop != 0.8 {
+		t.Fatalf("unexpected daily temperatures or rain: %+v", today)
+	}
+	if today.WindSpeed != 12.4 || today.WindGust != 21 || today.WindDeg != 225 || today.Weather[0].ID != 500 {
+		t.Fatalf("unexpected daily wind or conditions: %+v", today)
+	}
+}
+
+func TestNWSProvider_FetchWeather(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "User-Agent required", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		switch r.URL.Path {
+		case "/points/29.65,-81.2":
+			fmt.Fprintf(w, `{"properties": {"forecast": "%[1]s/gridpoints/JAX/80,40/forecast",
+				"forecastHourly": "%[1]s/gridpoints/JAX/80,40/forecast/hourly", "timeZone": "America/New_York"}}`, server.URL)
+		case "/gridpoints/JAX/80,40/forecast/hourly":
+			_, _ = w.Write([]byte(`{"properties": {"periods": [
+				{"startTime": "2024-06-21T14:00:00-04:00", "isDaytime": true, "temperature": 86,
+				 "probabilityOfPrecipitation": {"value": 20}, "dewpoint": {"value": 23.3}, "relativeHumidity": {"value": 65},
+				 "windSpeed": "5 to 10 mph", "windDirection": "SW",
+				 "icon": "https://api.weather.gov/icons/land/day/tsra_hi,20?size=small", "shortForecast": "Slight Chance Showers And Thunderstorms"},
+				{"startTime": "2024-06-21T21:00:00-04:00", "isDaytime": false, "temperature": 78,
+				 "probabilityOfPrecipitation": {"value": null}, "windSpeed": "5 mph", "windDirection": "E",
+				 "icon": "https://api.weather.gov/icons/land/night/few?size=small", "shortForecast": "Mostly Clear"}
+			]}}`))
+		case "/gridpoints/JAX/80,40/forecast":
+			_, _ = w.Write([]byte(`{"properties": {"periods": [
+				{"startTime": "2024-06-21T14:00:00-04:00", "isDaytime": true, "temperature": 88,
+				 "probabilityOfPrecipitation": {"value": 20}, "windSpeed": "5 to 10 mph", "windDirection": "SW",
+				 "icon": "https://api.weather.gov/icons/land/day/few?size=medium", "detailedForecast": "Sunny, with a high near 88."},
+				{"startTime": "2024-06-21T18:00:00-04:00", "isDaytime": false, "temperature": 74,
+				 "probabilityOfPrecipitation": {"value": 60}, "windSpeed": "5 mph", "windDirection": "E",
+				 "icon": "https://api.weather.gov/icons/land/night/rain?size=medium", "detailedForecast": "Mostly clear, with a low around 74."},
+				{"startTime": "2024-06-22T06:00:00-04:00", "isDaytime": true, "temperature": 90, "windSpeed": "10 mph", "windDirection": "E",
+				 "icon": "https://api.weather.gov/icons/land/day/sct?size=medium", "detailedForecast": "Partly sunny."}
+			]}}`))
+		case "/alerts/active":
+			if r.URL.Query().Get("point") != "29.65,-81.2" {
+				http.Error(w, "unexpected point", http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"features": [
+				{"properties": {"event": "Tropical Storm Warning", "senderName": "NWS Jacksonville FL",
+				 "onset": "2024-06-21T11:00:00-04:00", "ends": null, "expires": "2024-06-21T23:00:00-04:00"}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldClient, oldCache := httpClient, weatherCache
+	httpClient = server.Client()
+	weatherCache = cache.New(time.Hour, time.Hour)
+	defer func() { httpClient, weatherCache = oldClient, oldCache }()
+
+	data, err := nwsProvider{baseURL: server.URL}.FetchWeather(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("FetchWeather() error = %v", err)
+	}
+
+	current := data.Current
+	if current.Temp != 86 || current.WindSpeed != 10 || current.WindDeg != 225 {
+		t.Fatalf("unexpected current weather: %+v", current)
+	}
+	if got := getIconClassName(current.Weather[0].Icon, current.Weather[0].ID); got != "wi wi-owm-day-210" {
+		t.Fatalf("current icon = %q; want wi wi-owm-day-210", got)
+	}
+	if current.Weather[0].Description != "slight chance showers and thunderstorms" {
+		t.Fatalf("description = %q", current.Weather[0].Description)
+	}
+	if current.Sunrise == 0 || current.Sunset <= current.Sunrise {
+		t.Fatalf("expected computed sunrise before sunset, got %d/%d", current.Sunrise, current.Sunset)
+	}
+	night := data.Hourly[1]
+	if got := getIconClassName(night.Weather[0].Icon, night.Weather[0].ID); got != "wi wi-owm-night-801" || night.Pop != 0 {
+		t.Fatalf("unexpected night hour: icon %q, %+v", got, night)
+	}
+	if len(data.Daily) != 2 || data.Daily[0].Summary != "Sunny, with a high near 88." {
+		t.Fatalf("unexpected daily: %+v", data.Daily)
+	}
+	today, tomorrow := data.Daily[0], data.Daily[1]
+	if *today.Temp.Max != 88 || *today.Temp.Min != 74 || today.Pop != 0.2 || today.WindSpeed != 10 || today.WindDeg != 225 {
+		t.Fatalf("today should take the daytime period and the night's low: %+v", today)
+	}
+	if today.Dt != 1718942400 || today.Weather[0].ID != 801 {
+		t.Fatalf("unexpected today date or conditions: %+v", today)
+	}
+	if *tomorrow.Temp.Max != 90 || tomorrow.Temp.Min != nil {
+		t.Fatalf("tomorrow should have only a high: %+v", tomorrow)
+	}
+	if data.Timezone != "America/New_York" {
+		t.Fatalf("timezone = %q", data.Timezone)
+	}
+	want := WeatherAlert{SenderName: "NWS Jacksonville FL", Event: "Tropical Storm Warning", Start: 1718982000, End: 1719025200}
+	if len(data.Alerts) != 1 || data.Alerts[0] != want {
+		t.Fatalf("alerts = %+v; want %+v", data.Alerts, want)
+	}
+}
+
+func TestNWSWeatherData_DayStartingAtNightHasOnlyALow(t *testing.T) {
+	pop := 60.0
+	night := nwsPeriod{
+		StartTime:                  time.Date(2024, time.June, 21, 18, 0, 0, 0, time.FixedZone("EDT", -4*3600)),
+		Temperature:                74,
+		WindSpeed:                  "5 mph",
+		Icon:                       "https://api.weather.gov/icons/land/night/rain,60?size=medium",
+		ProbabilityOfPrecipitation: nwsValue{Value: &pop},
+		ShortForecast:              "Chance Rain Showers",
+	}
+	var point nwsPoint
+	point.Properties.TimeZone = "America/New_York"
+
+	data, err := nwsWeatherData(point, []nwsPeriod{night}, []nwsPeriod{night}, defaultLocation, night.StartTime)
+	if err != nil {
+		t.Fatalf("nwsWeatherData() error = %v", err)
+	}
+	tonight := data.Daily[0]
+	if tonight.Temp.Max != nil || *tonight.Temp.Min != 74 || tonight.Pop != 0.6 || tonight.Weather[0].ID != 501 {
+		t.Fatalf("tonight = %+v; want only a low and the night's rain", tonight)
+	}
+}
+
+func TestNWSCondition(t *testing.T) {
+	for icon, want := range map[string]int{
+		"https://api.weather.gov/icons/land/day/skc?size=small":            800,
+		"https://api.weather.gov/icons/land/night/rain_showers,40/tsra,60": 521,
+		"https://api.weather.gov/icons/land/day/wind_bkn?size=medium":      803,
+		"https://api.weather.gov/icons/land/day/something_new?size=medium": 804,
+		"not a url %": 804,
+		"https://api.weather.gov/icons/land/day/fog?size=medium&fontsize=12": 741,
+	} {
+		if got := nwsCondition(icon).ID; got != want {
+			t.Errorf("nwsCondition(%q) = %d; want %d", icon, got, want)
+		}
+	}
+}
+
+func TestProviderConditionsHaveIcons(t *testing.T) {
+	data, err := os.ReadFile(weatherIconCSSPath)
+	if err != nil {
+		t.Fatalf("read weather icon css: %v", err)
+	}
+	css := string(data)
+	conditions := []weatherCondition{}
+	for _, c := range wmoConditions {
+		conditions = append(conditions, c)
+	}
+	for _, c := range nwsConditions {
+		conditions = append(conditions, c)
+	}
+	for _, c := range conditions {
+		for _, isDay := range []bool{true, false} {
+			w := c.condition(isDay)
+			class := strings.TrimPrefix(getIconClassName(w.Icon, w.ID), "wi ")
+			if !strings.Contains(css, "."+class+":before") && !strings.Contains(css, "."+class+",") {
+				t.Errorf("no icon for %s (%+v)", class, c)
+			}
+		}
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	sunrise, sunset, ok := sunTimes(time.Date(2024, 6, 21, 9, 0, 0, 0, ny), 29.65, -81.2)
+	if !ok {
+		t.Fatal("sunTimes() ok = false")
+	}
+	// NOAA's solar calculator gives 6:25 AM and 8:27 PM for Crescent Beach.
+	if got := sunrise.Format("3:04 PM"); got != "6:25 AM" {
+		t.Errorf("sunrise = %s; want 6:25 AM", got)
+	}
+	if got := sunset.Format("3:04 PM"); got != "8:28 PM" && got != "8:27 PM" {
+		t.Errorf("sunset = %s; want about 8:27 PM", got)
+	}
+
+	if _, _, ok := sunTimes(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 80, 0); ok {
+		t.Error("expected no sunrise during polar day")
+	}
+}
+
+func TestMoonPhase(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	for _, tt := range []struct {
+		day  time.Time
+		want float64
+	}{
+		{time.Date(2024, 4, 8, 15, 0, 0, 0, ny), 0},
+		{time.Date(2024, 4, 15, 8, 0, 0, 0, ny), 0.25},
+		{time.Date(2024, 4, 23, 20, 0, 0, 0, ny), 0.5},
+	} {
+		if got := moonPhase(tt.day); got != tt.want {
+			t.Errorf("moonPhase(%s) = %v; want %v", tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+	if got := getMoonPhaseIcon(moonPhase(time.Date(2024, 4, 10, 12, 0, 0, 0, ny))); got != "wi-moon-waxing-crescent-3" {
+		t.Errorf("icon two days after new moon = %q", got)
+	}
+}
+
+type stubWeatherProvider struct {
+	name string
+	err  error
+	hits *int
+}
+
+func (p stubWeatherProvider) Name() string { return p.name }
+
+func (p stubWeatherProvider) FetchWeather(context.Context, Location) (WeatherData, error) {
+	*p.hits++
+	if p.err != nil {
+		return WeatherData{}, p.err
+	}
+	return WeatherData{Timezone: "America/New_York", Current: CurrentWeather{Temp: 71.6}, Daily: []DailyWeather{{MoonPhase: 0.5}}}, nil
+}
+
+func TestFetchWeatherFromAPI_FailsOverToNextProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	oldProviders, oldClient := weatherProviders, httpClient
+	defer func() { weatherProviders, httpClient = oldProviders, oldClient }()
+	httpClient = server.Client()
+
+	var meteoHits, nwsHits int
+	weatherProviders = []WeatherProvider{
+		openWeatherProvider{baseURL: server.URL},
+		stubWeatherProvider{name: weatherProviderOpenMeteo, hits: &meteoHits},
+		stubWeatherProvider{name: weatherProviderNWS, hits: &nwsHits},
+	}
+	before := testutil.ToFloat64(weatherProviderRequestsTotal.WithLabelValues(weatherProviderOpenWeather, "error"))
+
+	data, err := fetchWeatherFromAPI(context.Background(), defaultLocation)
+	if err != nil {
+		t.Fatalf("fetchWeatherFromAPI() error = %v", err)
+	}
+	if data.Source != weatherProviderOpenMeteo || data.Current.Temp != 72 {
+		t.Fatalf("unexpected weather: source %q, temp %v", data.Source, data.Current.Temp)
+	}
+	if meteoHits != 1 || nwsHits != 0 {
+		t.Fatalf("provider hits = %d/%d; want 1/0", meteoHits, nwsHits)
+	}
+	if got := testutil.ToFloat64(weatherProviderRequestsTotal.WithLabelValues(weatherProviderOpenWeather, "error")); got != before+1 {
+		t.Fatalf("openweather error count = %v; want %v", got, before+1)
+	}
+}
+
+func TestFetchWeatherFromAPI_FailsOverWhenProviderHangs(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	oldProviders, oldClient := weatherProviders, httpClient
+	defer func() { weatherProviders, httpClient = oldProviders, oldClient }()
+	httpClient = server.Client()
+
+	var meteoHits int
+	weatherProviders = []WeatherProvider{
+		openWeatherProvider{baseURL: server.URL},
+		stubWeatherProvider{name: weatherProviderOpenMeteo, hits: &meteoHits},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	data, err := fetchWeatherFromAPI(ctx, defaultLocation)
+	if err != nil {
+		t.Fatalf("fetchWeatherFromAPI() error = %v; want the fallback's weather", err)
+	}
+	if data.Source != weatherProviderOpenMeteo || meteoHits != 1 {
+		t.Fatalf("source = %q, Open-Meteo hits = %d; want the fallback to answer", data.Source, meteoHits)
+	}
+}
+
+func TestFetchWeatherFromAPI_ReportsEveryProviderError(t *testing.T) {
+	oldProviders := weatherProviders
+	defer func() { weatherProviders = oldProviders }()
+
+	var hits int
+	weatherProviders = []WeatherProvider{
+		stubWeatherProvider{name: weatherProviderOpenWeather, err: fmt.Errorf("status code 401"), hits: &hits},
+		stubWeatherProvider{name: weatherProviderNWS, err: fmt.Errorf("status code 503"), hits: &hits},
+	}
+
+	_, err := fetchWeatherFromAPI(context.Background(), defaultLocation)
+	if err == nil || !strings.Contains(err.Error(), "openweather: status code 401") || !strings.Contains(err.Error(), "nws: status code 503") {
+		t.Fatalf("fetchWeatherFromAPI() error = %v; want both provider errors", err)
+	}
+}